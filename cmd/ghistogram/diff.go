@@ -0,0 +1,86 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/couchbase/ghistogram"
+)
+
+// runDiff compares the histograms of two dump files, such as captures
+// taken around a config change, matching them up by name, and prints
+// their diffs, see ghistogram.Diff().  For example...
+//
+//    ghistogram diff before.json after.json
+//
+// A histogram missing from one of the dumps is compared against an
+// empty histogram.
+func runDiff(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("ghistogram diff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() != 2 {
+		fmt.Fprintln(stderr, "ghistogram diff: expected before and after files")
+		return 2
+	}
+
+	var dumps [2]ghistogram.Histograms
+
+	for i, path := range flags.Args() {
+		hmap, err := loadDumpFile(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "ghistogram diff: %s: %v\n", path, err)
+			return 1
+		}
+
+		dumps[i] = hmap
+	}
+
+	before, after := dumps[0], dumps[1]
+
+	var names []string
+	for name := range before {
+		names = append(names, name)
+	}
+	for name := range after {
+		if before[name] == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		b, a := before[name], after[name]
+		if b == nil {
+			b = a.CloneEmpty()
+		}
+		if a == nil {
+			a = b.CloneEmpty()
+		}
+
+		if i > 0 {
+			fmt.Fprintln(stdout)
+		}
+
+		if _, err := ghistogram.Diff(b, a).Fprint(stdout); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	}
+
+	return 0
+}
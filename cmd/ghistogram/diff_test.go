@@ -0,0 +1,72 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ghistogram")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	before := newNodeHistograms(5)
+	delete(before, "set")
+
+	after := newNodeHistograms(15)
+
+	b, _ := json.Marshal(before)
+	beforePath := filepath.Join(dir, "before.json")
+	ioutil.WriteFile(beforePath, b, 0644)
+
+	b, _ = json.Marshal(after)
+	afterPath := filepath.Join(dir, "after.json")
+	ioutil.WriteFile(afterPath, b, 0644)
+
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"diff", beforePath, afterPath}, nil,
+		&stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got: %d, stderr: %s",
+			code, stderr.String())
+	}
+
+	got := stdout.String()
+	if !strings.HasPrefix(got, "get (1 -> 1 Total, +0)\n"+
+		"[0 - 10]             1 ->          0         -1\n"+
+		"[10 - 20]            0 ->          1         +1\n") ||
+		!strings.Contains(got, "\n\nset (0 -> 2 Total, +2)\n") {
+		t.Errorf("unexpected output, got:\n%s", got)
+	}
+
+	tests := [][]string{
+		{"diff"},
+		{"diff", beforePath},
+		{"diff", beforePath, "/nonexistent/dump"},
+	}
+
+	for testi, test := range tests {
+		stdout.Reset()
+		stderr.Reset()
+
+		if run(test, nil, &stdout, &stderr) == 0 || stderr.Len() == 0 {
+			t.Errorf("test #%d, expected failure for: %v", testi, test)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+// Command ghistogram reads whitespace or newline separated numbers,
+// such as latencies extracted from logs, from the files given as
+// arguments or from stdin, and prints their histogram.  For example...
+//
+//    grep took logs/*.log | awk '{print $NF}' | ghistogram -numBins 20
+//
+// Integers are added as is, while decimals are rounded, see
+// ghistogram.Histogram.Observe().
+//
+// The merge subcommand instead sums the histograms of dumps, such as
+// those collected from the nodes of a cluster, see runMerge(), and
+// the diff subcommand compares two dumps, see runDiff().
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/couchbase/ghistogram"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command, returning the process exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) > 0 && args[0] == "merge" {
+		return runMerge(args[1:], stdout, stderr)
+	}
+	if len(args) > 0 && args[0] == "diff" {
+		return runDiff(args[1:], stdout, stderr)
+	}
+
+	flags := flag.NewFlagSet("ghistogram", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	name := flags.String("name", "values", "histogram name")
+	numBins := flags.Int("numBins", 30, "number of bins")
+	binFirst := flags.Uint64("binFirst", 1, "upper bound of the first bin")
+	growth := flags.Float64("growth", 2.0,
+		"bin growth factor, or 0 for equal width bins")
+	format := flags.String("format", ghistogram.FormatASCII,
+		"output format: ascii, csv, json, markdown, html or cbstats")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	gh, err := ghistogram.NewNamedHistogramChecked(*name,
+		*numBins, *binFirst, *growth)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	if err = readInputs(flags.Args(), stdin, gh); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if err = gh.Emit(*format, stdout); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if *format == ghistogram.FormatASCII {
+		printSummary(stdout, gh.Summary())
+	}
+
+	return 0
+}
+
+// readInputs adds the numbers of the named files, or of stdin when no
+// files are named, into the histogram.
+func readInputs(paths []string, stdin io.Reader,
+	gh *ghistogram.Histogram) error {
+	if len(paths) == 0 {
+		return readNumbers("stdin", stdin, gh)
+	}
+
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+
+		err = readNumbers(path, f, gh)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readNumbers(source string, r io.Reader, gh *ghistogram.Histogram) error {
+	s := bufio.NewScanner(r)
+	s.Split(bufio.ScanWords)
+
+	for s.Scan() {
+		word := s.Text()
+
+		if v, err := strconv.ParseUint(word, 10, 64); err == nil {
+			gh.Add(v, 1)
+			continue
+		}
+
+		v, err := strconv.ParseFloat(word, 64)
+		if err != nil || v < 0 || v != v {
+			return fmt.Errorf("ghistogram: %s, not a non-negative"+
+				" number: %q", source, word)
+		}
+
+		gh.Observe(v)
+	}
+
+	return s.Err()
+}
+
+func printSummary(w io.Writer, s ghistogram.Summary) {
+	fmt.Fprintf(w, "count: %d, min: %d, max: %d,"+
+		" p50: %d, p90: %d, p99: %d, p99.9: %d\n",
+		s.Count, s.Min, s.Max, s.P50, s.P90, s.P99, s.P999)
+}
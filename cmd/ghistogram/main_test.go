@@ -0,0 +1,90 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"-name", "lat", "-numBins", "4", "-binFirst", "10"},
+		strings.NewReader("1 5\n12 15.4\n\n35 1000\n"), &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got: %d, stderr: %s",
+			code, stderr.String())
+	}
+
+	exp := `lat (6 Total)
+[0 - 10]     33.33%   33.33% ############################## (2)
+[10 - 20]    33.33%   66.67% ############################## (2)
+[20 - 40]    16.67%   83.33% ############### (1)
+[40 - inf]   16.67%  100.00% ############### (1)
+count: 6, min: 1, max: 1000, p50: 15,`
+	if !strings.HasPrefix(stdout.String(), exp) {
+		t.Errorf("unexpected output, got:\n%s\nexp:\n%s",
+			stdout.String(), exp)
+	}
+}
+
+func TestRunFilesAndFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ghistogram")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "in.txt")
+	if err = ioutil.WriteFile(path, []byte("1 2 3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"-format", "csv", path, path},
+		strings.NewReader(""), &stdout, &stderr)
+	if code != 0 || !strings.HasPrefix(stdout.String(),
+		"start,end,count,percent,cumulative_percent\n") ||
+		strings.Contains(stdout.String(), "count: ") {
+		t.Errorf("unexpected output: %d, %s%s",
+			code, stdout.String(), stderr.String())
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		args  []string
+		input string
+		code  int
+	}{
+		{[]string{"-numBins", "0"}, "", 2},
+		{[]string{"-bogus"}, "", 2},
+		{nil, "1 two 3", 1},
+		{nil, "-1", 1},
+		{[]string{"-format", "bogus"}, "1", 1},
+		{[]string{"/nonexistent/input"}, "", 1},
+	}
+
+	for testi, test := range tests {
+		var stdout, stderr bytes.Buffer
+
+		code := run(test.args, strings.NewReader(test.input),
+			&stdout, &stderr)
+		if code != test.code || stderr.Len() == 0 {
+			t.Errorf("test #%d, expected code %d, got: %d, stderr: %s",
+				testi, test.code, code, stderr.String())
+		}
+	}
+}
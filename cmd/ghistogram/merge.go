@@ -0,0 +1,171 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/couchbase/ghistogram"
+)
+
+// runMerge sums the histograms of the dump files named by the args,
+// matching them up by name, and prints the result.  For example...
+//
+//    ghistogram merge -format json node*/memcached_histograms.json
+//
+// A dump is either an archive, see ghistogram.ArchiveWriter, or JSON
+// histograms or maps of histograms.  The EmitGraph() text isn't
+// supported, as it omits the empty bins needed to match up the bins.
+func runMerge(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("ghistogram merge", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	format := flags.String("format", ghistogram.FormatASCII,
+		"output format: ascii, csv, json, markdown, html or cbstats")
+	merged := flags.String("merged", "",
+		"if not empty, also merge all histograms into one of this name")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		fmt.Fprintln(stderr, "ghistogram merge: no files")
+		return 2
+	}
+
+	hmap := make(ghistogram.Histograms)
+
+	for _, path := range flags.Args() {
+		src, err := loadDumpFile(path)
+		if err == nil {
+			err = hmap.AddAll(src)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "ghistogram merge: %s: %v\n", path, err)
+			return 1
+		}
+	}
+
+	if *merged != "" {
+		gh, err := hmap.Merged(*merged)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+
+		hmap = ghistogram.Histograms{*merged: gh}
+	}
+
+	if err := hmap.Emit(*format, stdout); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+func loadDumpFile(path string) (ghistogram.Histograms, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return loadDump(f)
+}
+
+// loadDump reads a dump, detecting its kind from its first bytes.
+func loadDump(r io.Reader) (ghistogram.Histograms, error) {
+	br := bufio.NewReader(r)
+
+	start, _ := br.Peek(len("ghistogram-archive"))
+	start = bytes.TrimLeft(start, " \t\r\n")
+
+	switch {
+	case bytes.HasPrefix(start, []byte("ghistogram-archive")):
+		rv := make(ghistogram.Histograms)
+
+		var errAddAll error
+
+		err := ghistogram.ReadArchive(br, time.Time{}, time.Time{},
+			func(e ghistogram.ArchiveEntry) bool {
+				errAddAll = rv.AddAll(e.Histograms)
+				return errAddAll == nil
+			})
+		if err == nil {
+			err = errAddAll
+		}
+
+		return rv, err
+
+	case bytes.HasPrefix(start, []byte("{")):
+		return loadJSON(br)
+	}
+
+	return nil, errors.New("not an archive or JSON")
+}
+
+// loadJSON reads a stream of JSON objects, each of which is either a
+// histogram, as written by the json format, or a map of histograms.
+func loadJSON(r io.Reader) (ghistogram.Histograms, error) {
+	rv := make(ghistogram.Histograms)
+
+	d := json.NewDecoder(r)
+	for {
+		var raw map[string]json.RawMessage
+
+		err := d.Decode(&raw)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		src := make(ghistogram.Histograms)
+
+		if _, ok := raw["Ranges"]; ok {
+			var gh ghistogram.Histogram
+			if err = remarshal(raw, &gh); err != nil {
+				return nil, err
+			}
+			src[gh.Name] = &gh
+		} else if err = remarshal(raw, &src); err != nil {
+			return nil, err
+		}
+
+		if err = rv.AddAll(src); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rv) == 0 {
+		return nil, errors.New("no histograms")
+	}
+
+	return rv, nil
+}
+
+func remarshal(raw map[string]json.RawMessage, v interface{}) error {
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
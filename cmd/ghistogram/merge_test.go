@@ -0,0 +1,138 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/couchbase/ghistogram"
+)
+
+func newNodeHistograms(dp uint64) ghistogram.Histograms {
+	get := ghistogram.NewNamedHistogram("get", 4, 10, 2.0)
+	get.Add(dp, 1)
+	set := ghistogram.NewNamedHistogram("set", 4, 10, 2.0)
+	set.Add(dp*2, 2)
+	return ghistogram.Histograms{"get": get, "set": set}
+}
+
+func TestRunMerge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ghistogram")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name string, b []byte) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, b, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	var paths []string
+
+	b, _ := json.Marshal(newNodeHistograms(5))
+	paths = append(paths, write("map.json", b))
+
+	var buf bytes.Buffer
+	newNodeHistograms(15).Emit(ghistogram.FormatJSON, &buf)
+	paths = append(paths, write("lines.json", buf.Bytes()))
+
+	b, _ = json.Marshal(newNodeHistograms(25))
+	paths = append(paths, write("map2.json", append(b, '\n')))
+
+	buf.Reset()
+	aw, _ := ghistogram.NewArchiveWriter(&buf)
+	aw.Append(time.Now(), newNodeHistograms(45))
+	aw.Append(time.Now(), newNodeHistograms(45))
+	paths = append(paths, write("archive", buf.Bytes()))
+
+	var stdout, stderr bytes.Buffer
+
+	code := run(append([]string{"merge"}, paths...), nil, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got: %d, stderr: %s",
+			code, stderr.String())
+	}
+
+	exp := `get (5 Total)
+[0 - 10]     20.00%   20.00% ############### (1)
+[10 - 20]    20.00%   40.00% ############### (1)
+[20 - 40]    20.00%   60.00% ############### (1)
+[40 - inf]   40.00%  100.00% ############################## (2)
+set (10 Total)
+[10 - 20]    20.00%   20.00% ########## (2)
+[20 - 40]    20.00%   40.00% ########## (2)
+[40 - inf]   60.00%  100.00% ############################## (6)
+`
+	if stdout.String() != exp {
+		t.Errorf("unexpected output, got:\n%s\nexp:\n%s",
+			stdout.String(), exp)
+	}
+
+	stdout.Reset()
+
+	code = run(append([]string{"merge", "-merged", "all", "-format", "json"},
+		paths...), nil, &stdout, &stderr)
+
+	var gh ghistogram.Histogram
+	if code != 0 || json.Unmarshal(stdout.Bytes(), &gh) != nil ||
+		gh.Name != "all" || gh.TotCount != 15 {
+		t.Errorf("unexpected merged output: %d, %s%s",
+			code, stdout.String(), stderr.String())
+	}
+}
+
+func TestRunMergeErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ghistogram")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	bad := filepath.Join(dir, "bad.json")
+	ioutil.WriteFile(bad, []byte("{\"x\": 1}"), 0644)
+
+	b, _ := json.Marshal(ghistogram.NewNamedHistogram("get", 8, 10, 2.0))
+	other := filepath.Join(dir, "other.json")
+	ioutil.WriteFile(other, b, 0644)
+
+	b, _ = json.Marshal(newNodeHistograms(5))
+	first := filepath.Join(dir, "first.json")
+	ioutil.WriteFile(first, b, 0644)
+
+	graph := filepath.Join(dir, "graph.log")
+	ioutil.WriteFile(graph, []byte(newNodeHistograms(5).String()), 0644)
+
+	tests := [][]string{
+		{"merge"},
+		{"merge", "/nonexistent/dump"},
+		{"merge", bad},
+		{"merge", graph},
+		{"merge", first, other},
+		{"merge", "-format", "bogus", first},
+	}
+
+	for testi, test := range tests {
+		var stdout, stderr bytes.Buffer
+
+		if run(test, nil, &stdout, &stderr) == 0 || stderr.Len() == 0 {
+			t.Errorf("test #%d, expected failure for: %v, got: %s",
+				testi, test, stdout.String())
+		}
+	}
+}
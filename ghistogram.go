@@ -15,8 +15,12 @@ import (
 	"bytes"
 	"fmt"
 	"math"
+	"math/bits"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 )
 
 // Histogram is a simple uint64 histogram implementation that avoids
@@ -26,13 +30,27 @@ import (
 //
 // The histogram bins are split across the two arrays of Ranges and
 // Counts, where len(Ranges) == len(Counts).  These arrays are public
-// in case users wish to use reflection or JSON marhsaling.
+// in case users wish to use reflection or JSON marshaling, but are
+// only safe to read directly while the histogram is not concurrently
+// updated; see BinRanges() and BinCounts() for consistent copies.
 //
 // An optional growth factor for bin sizes is supported - see
 // NewHistogram() binGrowthFactor parameter.
 //
-// The histogram is concurrent safe.
+// The histogram is concurrent safe.  Every mutation, including Add(),
+// AddAll() and Reset(), happens while holding the histogram's lock,
+// and every read method, such as Snapshot() or EmitGraph(), holds
+// the lock for its whole duration, so reads never observe a torn or
+// partially merged state, like a TotCount that disagrees with the sum
+// of the Counts.  The exception is Total(), which is lock-free, so it
+// may briefly disagree with the TotCount and Counts read under the
+// lock.
 type Histogram struct {
+	// total mirrors TotCount for lock-free reads by Total().  It's
+	// first so it's 64-bit aligned for atomic access on 32-bit
+	// platforms.
+	total uint64
+
 	// Histogram name.
 	Name string
 
@@ -52,6 +70,58 @@ type Histogram struct {
 	MaxDataPoint uint64 // MaxDataPoint is the largest data point seen.
 
 	m sync.Mutex
+
+	boundary BinBoundary // See SetBinBoundary().
+
+	transform Transform // See SetTransform().
+
+	rounding Rounding // See SetRounding().
+
+	thresholdHooks []*slowOpHook // See OnThresholdExceeded().
+	slowOp         *slowOpHook   // See SlowOpHook(), in thresholdHooks.
+
+	overflow     uint64      // See OverflowCount().
+	overflowHook *slowOpHook // See OverflowHook().
+
+	saturated bool // See Saturated().
+
+	diag     Diagnostics // See Diagnostics(), not cleared by resets.
+	captured uint64      // The TotCount when last copied, see capture().
+
+	noCatchAll bool // See WithoutCatchAll().
+
+	unit string // See WithUnit().
+
+	rangeFormat RangeFormat // See SetRangeFormat().
+
+	slo *sloState // See WithSLOThresholds().
+
+	reservoir *reservoir // See SetReservoirSize().
+
+	delta *deltaState // See Delta().
+
+	resetTime   time.Time // See ResetWithReason().
+	resetReason string
+
+	start time.Time // See Window(), the creation or last reset time.
+	end   time.Time // See Window(), the capture time of copies.
+
+	autoRangeFraction float64 // See SetAutoRange().
+	autoRangeMinCount uint64
+
+	trackSampleTimes bool  // See SetTrackSampleTimes().
+	firstSample      int64 // Unix nanoseconds of the first sample, or 0.
+	lastSample       int64 // Unix nanoseconds of the last sample, or 0.
+
+	summary atomic.Value // Of *summaryCache, see SetSummaryMaxStaleness().
+
+	clock Clock // See SetClock(), nil means SystemClock.
+
+	history *historyState // See EnableHistory().
+
+	layout32 *layout32 // See NewHistogram32().
+
+	audit auditState // See the ghistogram_audit build tag.
 }
 
 // Creates a new Histogram whose name is "histogram"
@@ -68,6 +138,11 @@ func NewHistogram(
 //
 // A special case of binGrowthFactor of 0.0 means the the allocated
 // bins will have constant, non-growing size or "width".
+//
+// When numBins is so large that the bins would overflow a uint64, such
+// as more than 64 bins with a binGrowthFactor of 2.0, the last bin
+// starts at math.MaxUint64 instead and the histogram has fewer bins,
+// see NumBins().  NewNamedHistogramChecked() returns an error instead.
 func NewNamedHistogram(
 	name string,
 	numBins int,
@@ -80,23 +155,48 @@ func NewNamedHistogram(
 		TotCount:     0,
 		MinDataPoint: math.MaxUint64,
 		MaxDataPoint: 0,
+		start:        time.Now(),
 	}
 
 	gh.Ranges[0] = 0
 	gh.Ranges[1] = binFirst
 
 	for i := 2; i < len(gh.Ranges); i++ {
-		if binGrowthFactor == 0.0 {
-			gh.Ranges[i] = gh.Ranges[i-1] + binFirst
-		} else {
-			gh.Ranges[i] =
-				uint64(math.Ceil(binGrowthFactor * float64(gh.Ranges[i-1])))
+		next, ok := nextBinRange(gh.Ranges[i-1], binFirst, binGrowthFactor)
+		if !ok {
+			// The bins would overflow a uint64, so the last bin instead
+			// starts at math.MaxUint64 and there are fewer bins.
+			gh.Ranges[i] = math.MaxUint64
+			gh.Ranges = gh.Ranges[:i+1]
+			gh.Counts = gh.Counts[:i+1]
+			break
 		}
+
+		gh.Ranges[i] = next
 	}
 
 	return gh
 }
 
+// nextBinRange returns the lower bound of the bin after the bin that
+// starts at prev, or false if it would overflow a uint64.
+func nextBinRange(prev, binFirst uint64,
+	binGrowthFactor float64) (uint64, bool) {
+	if binGrowthFactor == 0.0 {
+		if prev > math.MaxUint64-binFirst {
+			return 0, false
+		}
+		return prev + binFirst, true
+	}
+
+	next := math.Ceil(binGrowthFactor * float64(prev))
+	if next >= math.MaxUint64 {
+		return 0, false
+	}
+
+	return uint64(next), true
+}
+
 // Creates a new Histogram whose name and ranges are identical to
 // the one provided. Note that the entries are not copied.
 func (gh *Histogram) CloneEmpty() *Histogram {
@@ -107,6 +207,33 @@ func (gh *Histogram) CloneEmpty() *Histogram {
 		TotCount:     0,
 		MinDataPoint: math.MaxUint64,
 		MaxDataPoint: 0,
+		boundary:     gh.boundary,
+		transform:    gh.transform,
+		rounding:     gh.rounding,
+
+		autoRangeFraction: gh.autoRangeFraction,
+		autoRangeMinCount: gh.autoRangeMinCount,
+
+		slo: gh.slo.cloneEmpty(),
+
+		reservoir: gh.reservoir.cloneEmpty(),
+
+		trackSampleTimes: gh.trackSampleTimes,
+
+		noCatchAll: gh.noCatchAll,
+
+		unit:        gh.unit,
+		rangeFormat: gh.rangeFormat,
+
+		clock: gh.clock,
+		start: gh.now(),
+	}
+
+	if c, ok := gh.summary.Load().(*summaryCache); ok {
+		newHist.summary.Store(&summaryCache{
+			maxStaleness: c.maxStaleness,
+			clock:        gh.clock,
+		})
 	}
 
 	for i := 0; i < len(gh.Ranges); i++ {
@@ -124,20 +251,269 @@ func (gh *Histogram) Add(dataPoint uint64, count uint64) {
 	gh.m.Unlock()
 }
 
-func (gh *Histogram) addUNLOCKED(dataPoint uint64, count uint64) {
-	idx := search(gh.Ranges, dataPoint)
-	if idx >= 0 {
-		gh.Counts[idx] += count
-		gh.TotCount += count
+// addUNLOCKED adds the data point, and returns false if it exceeded
+// the range of the histogram, see AddChecked().
+func (gh *Histogram) addUNLOCKED(dataPoint uint64, count uint64) bool {
+	idx := binIndex(gh.Ranges, gh.boundary, gh.binValue(dataPoint))
+	if idx < 0 {
+		gh.diag.Dropped += count
+		return false
+	}
+
+	// The first bin is never a catch-all, even when it's the only bin.
+	catchAll := idx > 0 && idx == len(gh.Counts)-1
 
-		gh.TotDataPoint += dataPoint
-		if gh.MinDataPoint > dataPoint {
-			gh.MinDataPoint = dataPoint
+	if catchAll && gh.noCatchAll {
+		gh.diag.Dropped += count
+		gh.overflow += count
+		if gh.overflowHook != nil {
+			gh.overflowHook.check(dataPoint, gh.clock)
 		}
-		if gh.MaxDataPoint < dataPoint {
-			gh.MaxDataPoint = dataPoint
+		return false
+	}
+
+	gh.Counts[idx] = gh.satAddUNLOCKED(gh.Counts[idx], count)
+	gh.TotCount = gh.satAddUNLOCKED(gh.TotCount, count)
+	atomic.StoreUint64(&gh.total, gh.TotCount)
+
+	gh.TotDataPoint = gh.satAddUNLOCKED(gh.TotDataPoint, dataPoint)
+	if gh.MinDataPoint > dataPoint {
+		gh.MinDataPoint = dataPoint
+	}
+	if gh.MaxDataPoint < dataPoint {
+		gh.MaxDataPoint = dataPoint
+	}
+
+	if gh.trackSampleTimes {
+		gh.lastSample = gh.now().UnixNano()
+		if gh.firstSample == 0 {
+			gh.firstSample = gh.lastSample
 		}
 	}
+
+	if gh.slo != nil {
+		gh.slo.add(dataPoint, count)
+	}
+
+	if gh.reservoir != nil {
+		gh.reservoir.add(dataPoint, count)
+	}
+
+	for _, h := range gh.thresholdHooks {
+		h.check(dataPoint, gh.clock)
+	}
+
+	if gh.history != nil {
+		gh.history.rotate(gh.now())
+		gh.history.cur.addUNLOCKED(dataPoint, count)
+	}
+
+	if catchAll {
+		gh.diag.Clamped += count
+		gh.overflow += count
+		if gh.overflowHook != nil {
+			gh.overflowHook.check(dataPoint, gh.clock)
+		}
+	}
+
+	if gh.autoRangeFraction > 0 && catchAll {
+		gh.maybeAutoRangeUNLOCKED()
+	}
+
+	return !catchAll
+}
+
+// Total returns the total count of data points.  It does not take
+// the lock, so it's cheap to poll, but a concurrent Snapshot() may be
+// ahead of or behind it.  Direct changes of the public TotCount field
+// are not reflected.
+func (gh *Histogram) Total() uint64 {
+	return atomic.LoadUint64(&gh.total)
+}
+
+// Snapshot returns a consistent copy of the histogram, captured under
+// a single hold of the lock.
+func (gh *Histogram) Snapshot() *Histogram {
+	return gh.capture(false)
+}
+
+// SnapshotAndReset returns a consistent copy of the histogram, like
+// Snapshot(), and resets the histogram under the same hold of the
+// lock, so no data points are lost between the copy and the reset.  A
+// non-empty reason is recorded like ResetWithReason() does.
+func (gh *Histogram) SnapshotAndReset(reason string) *Histogram {
+	gh.m.Lock()
+	rv := gh.CloneEmpty()
+	gh.copyIntoUNLOCKED(rv)
+	gh.resetUNLOCKED()
+	if reason != "" {
+		gh.resetTime = gh.now()
+		gh.resetReason = reason
+	}
+	gh.m.Unlock()
+
+	return rv
+}
+
+// NumBins returns the number of bins of the histogram.
+func (gh *Histogram) NumBins() int {
+	gh.m.Lock()
+	n := len(gh.Counts)
+	gh.m.Unlock()
+	return n
+}
+
+// BinRanges returns the [low, high) data point domain of every bin,
+// where the high of the last bin is math.MaxUint64.
+func (gh *Histogram) BinRanges() [][2]uint64 {
+	gh.m.Lock()
+	rv := make([][2]uint64, len(gh.Counts))
+	for i := range rv {
+		rv[i][0] = gh.rangeLabel(gh.Ranges[i])
+		if i < len(rv)-1 {
+			rv[i][1] = gh.rangeLabel(gh.Ranges[i+1])
+		} else {
+			rv[i][1] = math.MaxUint64
+		}
+	}
+	gh.m.Unlock()
+	return rv
+}
+
+// BinCounts returns a copy of the count of every bin.
+func (gh *Histogram) BinCounts() []uint64 {
+	gh.m.Lock()
+	rv := append([]uint64(nil), gh.Counts...)
+	gh.m.Unlock()
+	return rv
+}
+
+// VisitBins invokes f with the [start, end) data point domain and the
+// count of every bin, in order, until f returns false, where the end
+// of the last bin is math.MaxUint64.  The bins are visited while the
+// histogram is locked, without allocating, so f must not call methods
+// of the histogram.
+func (gh *Histogram) VisitBins(f func(start, end, count uint64) bool) {
+	gh.m.Lock()
+	defer gh.m.Unlock()
+
+	last := len(gh.Counts) - 1
+	for i, c := range gh.Counts {
+		end := uint64(math.MaxUint64)
+		if i < last {
+			end = gh.rangeLabel(gh.Ranges[i+1])
+		}
+
+		if !f(gh.rangeLabel(gh.Ranges[i]), end, c) {
+			return
+		}
+	}
+}
+
+// Reset clears all the counts and data point statistics of the
+// histogram, keeping its name and bin ranges.
+func (gh *Histogram) Reset() {
+	gh.m.Lock()
+	gh.resetUNLOCKED()
+	gh.m.Unlock()
+}
+
+// ResetWithReason resets the histogram like Reset(), and records the
+// time and reason of the reset, which subsequent graphs show so
+// operators can tell why the counts dropped to zero.
+func (gh *Histogram) ResetWithReason(reason string) {
+	gh.m.Lock()
+	gh.resetUNLOCKED()
+	gh.resetTime = gh.now()
+	gh.resetReason = reason
+	gh.m.Unlock()
+}
+
+func (gh *Histogram) resetUNLOCKED() {
+	gh.diag.Resets++
+	if gh.TotCount > gh.captured {
+		gh.diag.DiscardedByReset += gh.TotCount - gh.captured
+	}
+	gh.captured = 0
+
+	for i := range gh.Counts {
+		gh.Counts[i] = 0
+	}
+	gh.TotCount = 0
+	atomic.StoreUint64(&gh.total, 0)
+
+	gh.TotDataPoint = 0
+	gh.MinDataPoint = math.MaxUint64
+	gh.MaxDataPoint = 0
+
+	gh.overflow = 0
+	gh.saturated = false
+
+	gh.resetTime = time.Time{}
+	gh.resetReason = ""
+
+	gh.start = gh.now()
+	gh.end = time.Time{}
+
+	gh.firstSample = 0
+	gh.lastSample = 0
+
+	gh.slo.reset()
+	gh.reservoir.reset()
+	gh.delta.reset()
+
+	gh.invalidateSummaryUNLOCKED()
+}
+
+// SetTrackSampleTimes enables or disables tracking the times of the
+// first and last data points, see SampleTimes().  Tracking is off by
+// default, as reading the clock is a large part of the cost of Add().
+func (gh *Histogram) SetTrackSampleTimes(enabled bool) {
+	gh.m.Lock()
+	gh.trackSampleTimes = enabled
+	gh.m.Unlock()
+}
+
+// SampleTimes returns the times of the first and last data points
+// added since creation or the last reset, which allows detecting dead
+// metrics and computing the true observation window.  The times are
+// zero while no data points were tracked, see SetTrackSampleTimes().
+// AddAll() merges the sample times of the source histogram.
+func (gh *Histogram) SampleTimes() (first, last time.Time) {
+	gh.m.Lock()
+	if gh.firstSample != 0 {
+		first = time.Unix(0, gh.firstSample)
+		last = time.Unix(0, gh.lastSample)
+	}
+	gh.m.Unlock()
+	return first, last
+}
+
+// mergeSampleTimesUNLOCKED widens the sample times to include the
+// given sample times, where 0 means unknown.
+func (gh *Histogram) mergeSampleTimesUNLOCKED(first, last int64) {
+	if first != 0 && (gh.firstSample == 0 || first < gh.firstSample) {
+		gh.firstSample = first
+	}
+	if last > gh.lastSample {
+		gh.lastSample = last
+	}
+}
+
+// SetName renames the histogram while it's locked, so it's safe with
+// concurrent readers such as EmitGraph().  A method can't share the
+// name of the public Name field, so there's no Name() accessor; read
+// the field only while the histogram isn't concurrently renamed.
+func (gh *Histogram) SetName(name string) {
+	gh.m.Lock()
+	gh.Name = name
+	gh.m.Unlock()
+}
+
+// String returns the ascii graph of the histogram, see EmitGraph(),
+// so histograms can be used with %v in log statements.
+func (gh *Histogram) String() string {
+	return gh.EmitGraph(nil, nil).String()
 }
 
 // Finds the last arr index where the arr entry <= dataPoint.
@@ -158,34 +534,86 @@ func search(arr []uint64, dataPoint uint64) int {
 
 // AddAll adds all the Counts from the src histogram into this
 // histogram.  The src and this histogram must either have the same
-// exact creation parameters.
+// exact creation parameters, which SameLayout() checks.
 func (gh *Histogram) AddAll(src *Histogram) {
+	gh.addAll(src, nil, false)
+}
+
+// addAllSameLayout adds src like AddAll(), but only if both have the
+// same layout, which is checked while both are locked, so concurrent
+// auto ranging can't mismatch the bins, see SetAutoRange().  Returns
+// false, adding nothing, otherwise.
+func (gh *Histogram) addAllSameLayout(src *Histogram) bool {
+	return gh.addAll(src, nil, true)
+}
+
+// addAll adds the counts of src, multiplied by the optional weigh,
+// including into the current interval of the history.
+func (gh *Histogram) addAll(src *Histogram, weigh func(uint64) uint64,
+	checkLayout bool) bool {
 	src.m.Lock()
 	gh.m.Lock()
 
-	for i := 0; i < len(src.Counts); i++ {
-		gh.Counts[i] += src.Counts[i]
-	}
-	gh.TotCount += src.TotCount
+	ok := !checkLayout || sameRanges(gh, src)
+	if ok {
+		gh.addAllUNLOCKED(src, weigh)
 
-	gh.TotDataPoint += src.TotDataPoint
-	if gh.MinDataPoint > src.MinDataPoint {
-		gh.MinDataPoint = src.MinDataPoint
-	}
-	if gh.MaxDataPoint < src.MaxDataPoint {
-		gh.MaxDataPoint = src.MaxDataPoint
+		if gh.history != nil {
+			gh.history.rotate(gh.now())
+			gh.history.cur.addAllUNLOCKED(src, weigh)
+		}
 	}
 
 	gh.m.Unlock()
 	src.m.Unlock()
+
+	return ok
+}
+
+// addAllUNLOCKED adds the counts of src, while both are locked, each
+// multiplied by weigh when it's non-nil, see AddAllWeighted().
+func (gh *Histogram) addAllUNLOCKED(src *Histogram,
+	weigh func(uint64) uint64) {
+	if weigh == nil {
+		weigh = func(c uint64) uint64 { return c }
+	}
+
+	var added uint64
+	for i, c := range src.Counts {
+		c = weigh(c)
+		gh.Counts[i] = gh.satAddUNLOCKED(gh.Counts[i], c)
+		added = gh.satAddUNLOCKED(added, c)
+	}
+	gh.TotCount = gh.satAddUNLOCKED(gh.TotCount, added)
+	atomic.StoreUint64(&gh.total, gh.TotCount)
+
+	gh.TotDataPoint = gh.satAddUNLOCKED(gh.TotDataPoint,
+		weigh(src.TotDataPoint))
+	gh.saturated = gh.saturated || src.saturated
+	gh.overflow += weigh(src.overflow)
+	if added > 0 {
+		if gh.MinDataPoint > src.MinDataPoint {
+			gh.MinDataPoint = src.MinDataPoint
+		}
+		if gh.MaxDataPoint < src.MaxDataPoint {
+			gh.MaxDataPoint = src.MaxDataPoint
+		}
+
+		gh.mergeSampleTimesUNLOCKED(src.firstSample, src.lastSample)
+	}
+
+	gh.slo.addAll(src.slo, weigh)
 }
 
 // EmitGraph emits an ascii graph to the optional out buffer, allocating
 // an out buffer if none was supplied. Returns the out buffer. Each
 // line emitted may have an optional prefix.
 //
+// The header shows the elapsed time and throughput of the histogram's
+// Window() once it's at least a second long.
+//
 // For example:
-//    TestGraph (48 Total)
+//    TestGraph (48 Total in 2m0s, 0.40/sec)
 //    [0 - 10]        4.17%    4.17% ### (2)
 //    [10 - 20]      41.67%   45.83% ############################## (20)
 //    [20 - 40]      20.83%   66.67% ############### (10)
@@ -193,76 +621,183 @@ func (gh *Histogram) AddAll(src *Histogram) {
 func (gh *Histogram) EmitGraph(prefix []byte,
 	out *bytes.Buffer) *bytes.Buffer {
 	gh.m.Lock()
+	out = gh.emitGraphUNLOCKED(prefix, out, 0)
+	gh.m.Unlock()
 
-	ranges := gh.Ranges
+	return out
+}
+
+// emitGraphUNLOCKED emits the ascii graph. When groupTotCount is
+// non-zero, the header also shows the histogram's share of that total.
+func (gh *Histogram) emitGraphUNLOCKED(prefix []byte,
+	out *bytes.Buffer, groupTotCount uint64) *bytes.Buffer {
+	return gh.emitGraphOptsUNLOCKED(prefix, out, groupTotCount, nil)
+}
+
+// emitGraphOptsUNLOCKED is emitGraphUNLOCKED with optional bar options.
+func (gh *Histogram) emitGraphOptsUNLOCKED(prefix []byte,
+	out *bytes.Buffer, groupTotCount uint64,
+	opts *GraphOptions) *bytes.Buffer {
 	counts := gh.Counts
-	countsN := len(counts)
 
 	if out == nil {
-		out = bytes.NewBuffer(make([]byte, 0, 80*countsN))
+		out = bytes.NewBuffer(make([]byte, 0, 80*len(counts)))
 	}
 
-	var maxCount uint64
-	var bins []string
-	var longestRange int
+	bins := gh.binLabelsUNLOCKED()
 
-	for i, c := range counts {
-		if maxCount < c {
-			maxCount = c
+	window := gh.windowLabelUNLOCKED()
+
+	if groupTotCount > 0 {
+		p := percentHundredths(gh.TotCount, groupTotCount)
+		fmt.Fprintf(out, "%s (%v Total%s, %d.%02d%% of %v)\n",
+			gh.Name, gh.TotCount, window, p/100, p%100, groupTotCount)
+	} else {
+		fmt.Fprintf(out, "%s (%v Total%s)\n",
+			gh.Name, gh.TotCount, window)
+	}
+
+	if gh.resetReason != "" {
+		if prefix != nil {
+			out.Write(prefix)
+		}
+		fmt.Fprintf(out, "(reset at %s: %s)\n",
+			gh.resetTime.Format(time.RFC3339), gh.resetReason)
+	}
+
+	if opts != nil {
+		lows := make([]uint64, len(counts))
+		for i := range lows {
+			lows[i] = gh.rangeLabel(gh.Ranges[i])
 		}
+		emitBinsOpts(prefix, out, bins, counts, gh.TotCount, lows, opts)
+	} else {
+		emitBins(prefix, out, bins, counts, gh.TotCount)
+	}
+
+	if gh.slo != nil {
+		gh.slo.emitFooter(prefix, out, gh.TotCount)
+	}
+
+	return out
+}
+
+// binLabelsUNLOCKED returns the "low - high" range label of each bin.
+func (gh *Histogram) binLabelsUNLOCKED() []string {
+	ranges := gh.Ranges
+	countsN := len(gh.Counts)
+
+	bins := make([]string, 0, countsN)
 
+	for i := 0; i < countsN; i++ {
 		var temp string
 		if i < countsN-1 {
-			temp = fmt.Sprintf("%v - %v", ranges[i], ranges[i+1])
+			temp = gh.boundLabel(gh.rangeLabel(ranges[i])) + " - " +
+				gh.boundLabel(gh.rangeLabel(ranges[i+1]))
 		} else {
-			temp = fmt.Sprintf("%v - inf", ranges[i])
+			temp = gh.boundLabel(gh.rangeLabel(ranges[i])) + " - inf"
 		}
 
 		bins = append(bins, temp)
-		if c > 0 && longestRange < len(temp) {
-			longestRange = len(temp)
+	}
+
+	return bins
+}
+
+// emitBins emits a graph line for each non-empty bin, given the bin
+// labels, or an "(empty)" line when there are no counts.
+func emitBins(prefix []byte, out *bytes.Buffer,
+	bins []string, counts []uint64, totCount uint64) {
+	emitBinsOpts(prefix, out, bins, counts, totCount, nil, nil)
+}
+
+// emitBinsOpts is emitBins with optional bar options, where lows holds
+// the lower bound of each bin, for coloring.
+func emitBinsOpts(prefix []byte, out *bytes.Buffer,
+	bins []string, counts []uint64, totCount uint64,
+	lows []uint64, opts *GraphOptions) {
+	if totCount == 0 {
+		if prefix != nil {
+			out.Write(prefix)
 		}
+		out.Write([]byte("(empty)\n"))
+
+		return
 	}
 
-	maxCountF := float64(maxCount)
-	totCountF := float64(gh.TotCount)
+	var maxCount uint64
+	var longestRange int
 
-	var runCount uint64 // Running total while emitting lines.
+	for i, c := range counts {
+		if maxCount < c {
+			maxCount = c
+		}
+		if c > 0 && longestRange < utf8.RuneCountInString(bins[i]) {
+			longestRange = utf8.RuneCountInString(bins[i])
+		}
+	}
 
-	barLen := float64(len(bar))
+	var runCount uint64 // Running total while emitting lines.
 
-	fmt.Fprintf(out, "%s (%v Total)\n", gh.Name, gh.TotCount)
 	for i, c := range counts {
 		if c == 0 {
 			continue
 		}
 
-		padding := strings.Repeat(" ", (longestRange - len(bins[i])))
+		padding := strings.Repeat(" ",
+			longestRange-utf8.RuneCountInString(bins[i]))
 
 		if prefix != nil {
 			out.Write(prefix)
 		}
 
 		runCount += c
-		fmt.Fprintf(out, "[%s] %s%7.2f%% %7.2f%%",
-			bins[i], padding,
-			100.0*(float64(c)/totCountF),
-			100.0*(float64(runCount)/totCountF))
+		p := percentHundredths(c, totCount)
+		pRun := percentHundredths(runCount, totCount)
+		fmt.Fprintf(out, "[%s] %s%4d.%02d%% %4d.%02d%%",
+			bins[i], padding, p/100, p%100, pRun/100, pRun%100)
 
 		out.Write([]byte(" "))
-		barWant := int(math.Floor(barLen * (float64(c) / maxCountF)))
-		out.Write(bar[0:barWant])
+		if opts != nil {
+			opts.writeBar(out, c, maxCount, lows[i])
+		} else {
+			barWant := mulDiv(c, uint64(len(bar)), maxCount)
+			out.Write(bar[0:barWant])
+		}
 
 		fmt.Fprintf(out, " (%v)", c)
 		out.Write([]byte("\n"))
 	}
+}
 
-	gh.m.Unlock()
+var bar = []byte("##############################")
 
-	return out
+// percentHundredths returns 100*n/d in hundredths of a percent,
+// rounded half up, or 0 when d is 0.  The math is done in integers
+// so counts beyond 2^53 don't lose precision.
+func percentHundredths(n, d uint64) uint64 {
+	if d == 0 {
+		return 0
+	}
+
+	x := mulDiv(n, 20000, d)
+
+	return x/2 + x%2
 }
 
-var bar = []byte("##############################")
+// mulDiv returns a*b/d, using a 128-bit intermediate product so the
+// multiplication can't overflow.  A result that doesn't fit in a
+// uint64 saturates at math.MaxUint64.
+func mulDiv(a, b, d uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	if hi >= d {
+		return math.MaxUint64
+	}
+
+	q, _ := bits.Div64(hi, lo, d)
+
+	return q
+}
 
 // CallSync invokes the callback func while the histogram is locked.
 func (gh *Histogram) CallSync(f func()) {
@@ -277,6 +812,20 @@ func (gh *Histogram) CallSync(f func()) {
 // costs on each update.
 func (gh *Histogram) CallSyncEx(f func(HistogramMutator)) {
 	gh.m.Lock()
+	gh.audit.enter()
 	f(&histogramMutator{gh})
+	gh.audit.exit()
+	gh.m.Unlock()
+}
+
+// CallSyncReadEx invokes the callback func with a HistogramReader
+// while the histogram is locked.  This allows stats collectors to
+// read consistent values from a histogram without being able to
+// mutate it.
+func (gh *Histogram) CallSyncReadEx(f func(HistogramReader)) {
+	gh.m.Lock()
+	gh.audit.enter()
+	f(&histogramReader{gh})
+	gh.audit.exit()
 	gh.m.Unlock()
 }
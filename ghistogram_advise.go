@@ -0,0 +1,121 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// LayoutAdvice is the verdict of AdviseLayout() on how well the bins
+// of a histogram fit the shape of its data points.
+type LayoutAdvice struct {
+	// Ready is false until the histogram has seen the warm-up count
+	// of data points, and the other fields are then meaningless.
+	Ready bool
+
+	// Poor is true when the bins are a poor fit, with the reasons
+	// listed in Reasons.
+	Poor    bool
+	Reasons []string
+
+	// Suggested is a layout that better fits the data points seen, for
+	// the same number of bins, NumBins, when Poor.
+	Suggested Layout
+	NumBins   int
+}
+
+// String returns a human-readable form of the advice.
+func (a LayoutAdvice) String() string {
+	if !a.Ready {
+		return "layout advice: not enough data points yet"
+	}
+	if !a.Poor {
+		return "layout advice: ok"
+	}
+
+	return fmt.Sprintf("layout advice: poor fit, %s; suggested"+
+		" numBins: %d, binFirst: %d, binGrowthFactor: %v",
+		strings.Join(a.Reasons, ", "), a.NumBins,
+		a.Suggested.BinFirst, a.Suggested.BinGrowthFactor)
+}
+
+// AdviseLayout reports whether the bins of the histogram are a poor fit
+// for its data points, once the histogram has at least warmUpCount data
+// points.  The bins are considered a poor fit when more than 50% of
+// the data points fall into the two fullest bins, losing resolution,
+// or more than 5% fall into the catch-all last bin, losing the tail.
+// A poor fit comes with a suggested layout spanning the data points
+// seen, which AdviseLayout does not apply.
+func (gh *Histogram) AdviseLayout(warmUpCount uint64) LayoutAdvice {
+	gh.m.Lock()
+	defer gh.m.Unlock()
+
+	rv := LayoutAdvice{NumBins: len(gh.Counts)}
+
+	if gh.TotCount == 0 || gh.TotCount < warmUpCount {
+		return rv
+	}
+
+	rv.Ready = true
+
+	if len(gh.Counts) < 3 {
+		return rv
+	}
+
+	counts := append([]uint64(nil), gh.Counts...)
+	sort.Slice(counts, func(i, j int) bool { return counts[i] > counts[j] })
+
+	if p := percentHundredths(counts[0]+counts[1], gh.TotCount); p > 5000 {
+		rv.Reasons = append(rv.Reasons, fmt.Sprintf("%d.%02d%% of the"+
+			" data points are in two bins", p/100, p%100))
+	}
+
+	last := gh.Counts[len(gh.Counts)-1]
+	if p := percentHundredths(last, gh.TotCount); p > 500 {
+		rv.Reasons = append(rv.Reasons, fmt.Sprintf("%d.%02d%% of the"+
+			" data points are in the catch-all bin", p/100, p%100))
+	}
+
+	if len(rv.Reasons) > 0 {
+		rv.Poor = true
+		rv.Suggested = suggestLayout(gh.percentileUNLOCKED(1),
+			gh.MaxDataPoint, len(gh.Counts))
+	}
+
+	return rv
+}
+
+// suggestLayout returns a layout of numBins bins, numBins >= 3, whose
+// first bin ends at about lo, and whose last regular bin starts beyond
+// 2 * hi, leaving headroom for growth.
+func suggestLayout(lo, hi uint64, numBins int) Layout {
+	binFirst := lo
+	if binFirst < 1 {
+		binFirst = 1
+	}
+
+	target := 2 * float64(hi)
+	if target <= float64(binFirst) {
+		return Layout{Name: "suggested", BinFirst: binFirst}
+	}
+
+	growth := math.Pow(target/float64(binFirst), 1/float64(numBins-2))
+
+	// Round up to 2 decimals, for friendlier parameters.
+	growth = math.Ceil(growth*100) / 100
+	if growth <= 1.0 {
+		growth = 1.01
+	}
+
+	return Layout{Name: "suggested", BinFirst: binFirst,
+		BinGrowthFactor: growth}
+}
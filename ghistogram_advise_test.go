@@ -0,0 +1,59 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAdviseLayout(t *testing.T) {
+	gh := NewHistogram(10, 10, 2.0)
+
+	if a := gh.AdviseLayout(100); a.Ready || a.Poor {
+		t.Errorf("expected not ready, got: %+v", a)
+	}
+
+	// A good fit, as log-uniform data points spread over the bins.
+	addLogUniform := func(gh *Histogram) {
+		for i := 0; i < 2000; i++ {
+			gh.Add(uint64(math.Pow(2000, float64(i)/2000)), 1)
+		}
+	}
+	addLogUniform(gh)
+
+	a := gh.AdviseLayout(100)
+	if !a.Ready || a.Poor || a.String() != "layout advice: ok" {
+		t.Errorf("expected ok, got: %+v", a)
+	}
+
+	// Most data points are beyond the bins.
+	gh.Add(1000000, 200)
+
+	a = gh.AdviseLayout(100)
+	if !a.Ready || !a.Poor || len(a.Reasons) != 1 || a.NumBins != 10 {
+		t.Fatalf("expected poor fit, got: %+v", a)
+	}
+
+	s := a.Suggested.New("x", a.NumBins)
+	addLogUniform(s)
+	s.Add(1000000, 200)
+
+	if s.Counts[len(s.Counts)-1] != 0 {
+		t.Errorf("expected suggested layout to cover the max, got: %v,"+
+			" advice: %s", s.Ranges, a)
+	}
+
+	// All data points in a single bin.
+	gh = NewHistogram(10, 10, 2.0)
+	gh.Add(5, 1000)
+	if a = gh.AdviseLayout(100); !a.Poor || len(a.Reasons) != 1 {
+		t.Errorf("expected poor fit, got: %+v", a)
+	}
+}
@@ -0,0 +1,108 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// AggregateTimeout bounds the fetch from each node by Aggregate().
+var AggregateTimeout = 10 * time.Second
+
+// Aggregate fetches the histograms served by the Handler() of every
+// node url concurrently, and returns the sum of the histograms of the
+// same name across the nodes, see AddAll().  The failures are returned
+// per node url, such as an unreachable node, or a histogram whose bins
+// mismatch the ones of the same histogram from an earlier node url,
+// which is then left out of the sum.
+func Aggregate(urls []string) (Histograms, map[string]error) {
+	ctx, cancel := context.WithTimeout(context.Background(), AggregateTimeout)
+	defer cancel()
+
+	return AggregateContext(ctx, urls)
+}
+
+// AggregateContext is Aggregate() where the fetches are bound by the
+// context instead of AggregateTimeout.
+func AggregateContext(ctx context.Context,
+	urls []string) (Histograms, map[string]error) {
+	fetched := make([]Histograms, len(urls))
+	fetchErrs := make([]error, len(urls))
+
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		wg.Add(1)
+		go func(i int, url string) {
+			fetched[i], fetchErrs[i] = fetchHistograms(ctx, url)
+			wg.Done()
+		}(i, url)
+	}
+	wg.Wait()
+
+	rv := Histograms{}
+	errs := map[string]error{}
+
+	// Merged in the order of the urls, so the result is deterministic.
+	for i, url := range urls {
+		if fetchErrs[i] != nil {
+			errs[url] = fetchErrs[i]
+			continue
+		}
+
+		for _, name := range fetched[i].SortedNames(nil) {
+			src := fetched[i][name]
+			if rv[name] == nil {
+				rv[name] = src.CloneEmpty()
+			} else if !sameRanges(rv[name], src) {
+				if errs[url] == nil {
+					errs[url] = fmt.Errorf("ghistogram: Aggregate, url: %s,"+
+						" histogram %q has mismatching bins", url, name)
+				}
+				continue
+			}
+
+			rv[name].AddAll(src)
+		}
+	}
+
+	return rv, errs
+}
+
+func fetchHistograms(ctx context.Context, url string) (Histograms, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ghistogram: Aggregate, url: %s, status: %s",
+			url, resp.Status)
+	}
+
+	var hmap Histograms
+	if err = json.NewDecoder(resp.Body).Decode(&hmap); err != nil {
+		return nil, fmt.Errorf("ghistogram: Aggregate, url: %s, err: %v",
+			url, err)
+	}
+
+	return hmap, nil
+}
@@ -0,0 +1,80 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAggregate(t *testing.T) {
+	node := func(binFirst uint64, dataPoint uint64) *httptest.Server {
+		hmap := Histograms{
+			"get": NewNamedHistogram("get", 5, binFirst, 0.0),
+			"set": NewNamedHistogram("set", 5, 10, 0.0),
+		}
+		hmap["get"].Add(dataPoint, 1)
+		hmap["set"].Add(dataPoint, 2)
+		return httptest.NewServer(hmap.Handler())
+	}
+
+	n1, n2, n3 := node(10, 5), node(10, 25), node(20, 5)
+	defer n1.Close()
+	defer n2.Close()
+	defer n3.Close()
+
+	failing := httptest.NewServer(http.NotFoundHandler())
+	defer failing.Close()
+
+	urls := []string{n1.URL, n2.URL, n3.URL, failing.URL, "http://[::1"}
+
+	hmap, errs := Aggregate(urls)
+
+	get := hmap["get"]
+	if get == nil || get.TotCount != 2 || get.Counts[0] != 1 ||
+		get.Counts[2] != 1 {
+		t.Errorf("unexpected merged get: %v", get)
+	}
+
+	// The set histogram of the third node has matching bins.
+	if set := hmap["set"]; set == nil || set.TotCount != 6 {
+		t.Errorf("unexpected merged set: %v", set)
+	}
+
+	if len(errs) != 3 || errs[n1.URL] != nil || errs[n2.URL] != nil {
+		t.Fatalf("expected 3 node errs, got: %v", errs)
+	}
+	if !strings.Contains(errs[n3.URL].Error(), `"get" has mismatching bins`) {
+		t.Errorf("expected mismatch err, got: %v", errs[n3.URL])
+	}
+	if !strings.Contains(errs[failing.URL].Error(), "404") {
+		t.Errorf("expected status err, got: %v", errs[failing.URL])
+	}
+}
+
+func TestAggregateContext(t *testing.T) {
+	block := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) { <-block }))
+	defer slow.Close()
+	defer close(block)
+
+	ctx, cancel := context.WithTimeout(context.Background(),
+		10*time.Millisecond)
+	defer cancel()
+
+	hmap, errs := AggregateContext(ctx, []string{slow.URL})
+	if len(hmap) != 0 || errs[slow.URL] == nil {
+		t.Errorf("expected timeout err, got: %v, %v", hmap, errs)
+	}
+}
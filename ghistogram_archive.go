@@ -0,0 +1,264 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// An archive is an append-only file of successive interval snapshots
+// of a Histograms map.  It starts with the archiveMagic header, which
+// is followed by records of a 4 byte big-endian payload length and a
+// JSON payload holding the snapshot's timestamp and histograms.  The
+// histograms carry their bin ranges, so every record is self
+// describing and records from different archives can be merged.
+//
+// Appending never rewrites earlier records, so a crash can at most
+// leave a truncated final record, which readers ignore, and which
+// OpenArchiveFile() removes before appending again.
+
+const archiveMagic = "ghistogram-archive-v1\n"
+
+// maxArchiveRecord bounds the payload length a reader accepts, which
+// protects against corrupt length prefixes.
+const maxArchiveRecord = 1 << 30
+
+// archiveRecord is the JSON payload of an archive record.
+type archiveRecord struct {
+	Time       int64 // Unix nanoseconds.
+	Histograms Histograms
+}
+
+// ArchiveEntry is a snapshot read from an archive.
+type ArchiveEntry struct {
+	Time       time.Time
+	Histograms Histograms
+}
+
+// ArchiveWriter appends snapshots to an archive.
+type ArchiveWriter struct {
+	w io.Writer
+	c io.Closer
+}
+
+// NewArchiveWriter starts a new archive on w, writing its header.
+func NewArchiveWriter(w io.Writer) (*ArchiveWriter, error) {
+	if _, err := io.WriteString(w, archiveMagic); err != nil {
+		return nil, err
+	}
+
+	return &ArchiveWriter{w: w}, nil
+}
+
+// OpenArchiveFile opens the archive file at path for appending,
+// creating it if needed.  The header of an existing file is checked,
+// and a truncated final record, as left by a crash during Append(), is
+// removed, so the new records follow the last complete record.
+func OpenArchiveFile(path string) (*ArchiveWriter, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := archiveEnd(f)
+	if err == nil {
+		err = f.Truncate(end)
+	}
+	if err == nil && end == 0 {
+		_, err = io.WriteString(f, archiveMagic)
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("ghistogram: OpenArchiveFile,"+
+			" path: %s, err: %v", path, err)
+	}
+
+	return &ArchiveWriter{w: f, c: f}, nil
+}
+
+// archiveEnd returns the offset just past the last complete record of
+// the archive file, or 0 when the file is empty or holds only part of
+// the header, which are started over.
+func archiveEnd(f *os.File) (int64, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := fi.Size()
+
+	br := bufio.NewReader(io.NewSectionReader(f, 0, size))
+
+	magic := make([]byte, len(archiveMagic))
+	n, err := io.ReadFull(br, magic)
+	if string(magic[:n]) != archiveMagic[:n] {
+		return 0, errors.New("not an archive")
+	}
+	if err != nil {
+		return 0, nil // An empty file or a truncated header.
+	}
+
+	end := int64(len(archiveMagic))
+
+	var lenBuf [4]byte
+	for {
+		if _, err = io.ReadFull(br, lenBuf[:]); err != nil {
+			return end, nil // At the end, or a truncated length.
+		}
+
+		n := binary.BigEndian.Uint32(lenBuf[:])
+		if n > maxArchiveRecord {
+			return 0, fmt.Errorf("invalid record length: %d,"+
+				" at offset: %d", n, end)
+		}
+
+		if end+4+int64(n) > size {
+			return end, nil // A truncated payload.
+		}
+
+		if _, err = br.Discard(int(n)); err != nil {
+			return 0, err
+		}
+
+		end += 4 + int64(n)
+	}
+}
+
+// Append writes a snapshot of the histograms, taken at ts, as the
+// next record of the archive.
+func (aw *ArchiveWriter) Append(ts time.Time, hmap Histograms) error {
+	payload, err := json.Marshal(&archiveRecord{
+		Time:       ts.UnixNano(),
+		Histograms: hmap,
+	})
+	if err != nil {
+		return err
+	}
+
+	record := make([]byte, 4+len(payload))
+	binary.BigEndian.PutUint32(record, uint32(len(payload)))
+	copy(record[4:], payload)
+
+	// A single write, so O_APPEND files never interleave records.
+	_, err = aw.w.Write(record)
+
+	return err
+}
+
+// Close closes the underlying file of an archive opened with
+// OpenArchiveFile().
+func (aw *ArchiveWriter) Close() error {
+	if aw.c != nil {
+		return aw.c.Close()
+	}
+	return nil
+}
+
+// ReadArchive invokes f, until it returns false, for every snapshot
+// of the archive whose time is within [from, to), where a zero from
+// or to leaves that end of the time range unbounded.
+func ReadArchive(r io.Reader, from, to time.Time,
+	f func(ArchiveEntry) bool) error {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(archiveMagic))
+	if _, err := io.ReadFull(br, magic); err != nil ||
+		string(magic) != archiveMagic {
+		return errors.New("ghistogram: ReadArchive, not an archive")
+	}
+
+	var lenBuf [4]byte
+	for {
+		_, err := io.ReadFull(br, lenBuf[:])
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil // Possibly a truncated final record.
+		}
+		if err != nil {
+			return err
+		}
+
+		n := binary.BigEndian.Uint32(lenBuf[:])
+		if n > maxArchiveRecord {
+			return fmt.Errorf("ghistogram: ReadArchive, invalid record"+
+				" length: %d", n)
+		}
+
+		payload := make([]byte, n)
+		_, err = io.ReadFull(br, payload)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil // A truncated final record.
+		}
+		if err != nil {
+			return err
+		}
+
+		var rec archiveRecord
+		if err = json.Unmarshal(payload, &rec); err != nil {
+			return err
+		}
+
+		ts := time.Unix(0, rec.Time)
+		if (!from.IsZero() && ts.Before(from)) ||
+			(!to.IsZero() && !ts.Before(to)) {
+			continue
+		}
+
+		if rec.Histograms == nil {
+			rec.Histograms = make(Histograms)
+		}
+
+		if !f(ArchiveEntry{Time: ts, Histograms: rec.Histograms}) {
+			return nil
+		}
+	}
+}
+
+// ReadArchiveFile is ReadArchive() for the archive file at path.
+func ReadArchiveFile(path string, from, to time.Time,
+	f func(ArchiveEntry) bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return ReadArchive(file, from, to, f)
+}
+
+// MergeArchiveFiles sums all the snapshots within [from, to) of the
+// given archive files into a single Histograms map, see ReadArchive()
+// and Histograms.AddAll().
+func MergeArchiveFiles(paths []string, from, to time.Time) (
+	Histograms, error) {
+	merged := make(Histograms)
+
+	for _, path := range paths {
+		var errAddAll error
+
+		err := ReadArchiveFile(path, from, to, func(e ArchiveEntry) bool {
+			errAddAll = merged.AddAll(e.Histograms)
+			return errAddAll == nil
+		})
+		if err == nil {
+			err = errAddAll
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ghistogram: MergeArchiveFiles,"+
+				" path: %s, err: %v", path, err)
+		}
+	}
+
+	return merged, nil
+}
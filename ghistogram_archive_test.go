@@ -0,0 +1,195 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestArchive(t *testing.T) {
+	var buf bytes.Buffer
+
+	aw, err := NewArchiveWriter(&buf)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	histograms, _, _ := initAndFetchHistograms(t)
+
+	t0 := time.Unix(1000, 0)
+	for i := 0; i < 5; i++ {
+		err = aw.Append(t0.Add(time.Duration(i)*time.Minute), histograms)
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	// Simulate a crash in the middle of appending a record.
+	full := buf.Len()
+	aw.Append(t0.Add(5*time.Minute), histograms)
+	buf.Truncate(full + 10)
+
+	var times []time.Time
+	err = ReadArchive(bytes.NewReader(buf.Bytes()),
+		t0.Add(time.Minute), t0.Add(4*time.Minute),
+		func(e ArchiveEntry) bool {
+			times = append(times, e.Time)
+			if e.Histograms["test1"].TotCount != 6 {
+				t.Errorf("unexpected histograms in entry")
+			}
+			return true
+		})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(times) != 3 || !times[0].Equal(t0.Add(time.Minute)) {
+		t.Errorf("unexpected entries in range: %v", times)
+	}
+
+	if ReadArchive(bytes.NewReader([]byte("garbage")), time.Time{},
+		time.Time{}, func(ArchiveEntry) bool { return true }) == nil {
+		t.Errorf("expected err on a non-archive")
+	}
+}
+
+func TestMergeArchiveFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ghistogram")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	histograms, _, _ := initAndFetchHistograms(t)
+
+	t0 := time.Unix(1000, 0)
+
+	var paths []string
+	for i := 0; i < 2; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("node%d.archive", i))
+		paths = append(paths, path)
+
+		// Appending via reopened files must not repeat the header.
+		for j := 0; j < 2; j++ {
+			aw, err := OpenArchiveFile(path)
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			aw.Append(t0.Add(time.Duration(j)*time.Hour), histograms)
+			aw.Close()
+		}
+	}
+
+	merged, err := MergeArchiveFiles(paths, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if merged["test1"].TotCount != 24 || merged["test2"].TotCount != 16 {
+		t.Errorf("unexpected merged totals")
+	}
+
+	merged, err = MergeArchiveFiles(paths, t0.Add(time.Hour), time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if merged["test1"].TotCount != 12 {
+		t.Errorf("unexpected merged totals in range")
+	}
+}
+
+func TestOpenArchiveFileRepair(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ghistogram")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	histograms, _, _ := initAndFetchHistograms(t)
+
+	t0 := time.Unix(1000, 0)
+
+	path := filepath.Join(dir, "node.archive")
+
+	aw, err := OpenArchiveFile(path)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	aw.Append(t0, histograms)
+	aw.Close()
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	complete := fi.Size()
+
+	// Simulate crashes in the middle of appending a record, within its
+	// payload and within its length prefix.
+	for _, partial := range []int64{10, 2} {
+		aw, err = OpenArchiveFile(path)
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		aw.Append(t0.Add(time.Hour), histograms)
+		aw.Close()
+
+		if err = os.Truncate(path, complete+partial); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	aw, err = OpenArchiveFile(path)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	aw.Append(t0.Add(2*time.Hour), histograms)
+	aw.Close()
+
+	var times []time.Time
+	err = ReadArchiveFile(path, time.Time{}, time.Time{},
+		func(e ArchiveEntry) bool {
+			times = append(times, e.Time)
+			return true
+		})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(times) != 2 || !times[1].Equal(t0.Add(2*time.Hour)) {
+		t.Errorf("expected the record after the repair, got: %v", times)
+	}
+
+	// A truncated header is started over.
+	err = ioutil.WriteFile(path, []byte(archiveMagic[:5]), 0644)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	aw, err = OpenArchiveFile(path)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	aw.Close()
+	if b, _ := ioutil.ReadFile(path); string(b) != archiveMagic {
+		t.Errorf("expected a new header, got: %q", b)
+	}
+
+	if err = ioutil.WriteFile(path, []byte("garbage"), 0644); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if _, err = OpenArchiveFile(path); err == nil {
+		t.Errorf("expected err on a non-archive")
+	}
+	if b, _ := ioutil.ReadFile(path); string(b) != "garbage" {
+		t.Errorf("expected a non-archive to be left alone, got: %q", b)
+	}
+}
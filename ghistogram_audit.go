@@ -0,0 +1,43 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+//go:build ghistogram_audit
+// +build ghistogram_audit
+
+package ghistogram
+
+import (
+	"sync/atomic"
+)
+
+// Building with the ghistogram_audit tag enables thread-safety
+// assertions, which panic when the unsynced API is misused, such as
+// when a HistogramMutator or HistogramReader is used after its
+// CallSyncEx() or CallSyncReadEx() returned.
+
+// auditState tracks whether the histogram is currently locked for a
+// CallSyncEx() or CallSyncReadEx() callback.
+type auditState struct {
+	held int32
+}
+
+func (a *auditState) enter() {
+	if !atomic.CompareAndSwapInt32(&a.held, 0, 1) {
+		panic("ghistogram: audit: reentrant CallSyncEx/CallSyncReadEx")
+	}
+}
+
+func (a *auditState) exit() {
+	atomic.StoreInt32(&a.held, 0)
+}
+
+func (a *auditState) assertHeld(op string) {
+	if atomic.LoadInt32(&a.held) == 0 {
+		panic("ghistogram: audit: " + op + " called outside of its callback")
+	}
+}
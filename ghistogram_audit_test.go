@@ -0,0 +1,52 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+//go:build ghistogram_audit
+// +build ghistogram_audit
+
+package ghistogram
+
+import (
+	"testing"
+)
+
+func TestAuditMutatorOutsideCallSyncEx(t *testing.T) {
+	gh := NewHistogram(5, 10, 2.0)
+
+	var leaked HistogramMutator
+	gh.CallSyncEx(func(hm HistogramMutator) {
+		hm.Add(1, 1)
+		leaked = hm
+	})
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic on leaked HistogramMutator")
+		}
+	}()
+
+	leaked.Add(1, 1)
+}
+
+func TestAuditReaderOutsideCallSyncReadEx(t *testing.T) {
+	gh := NewHistogram(5, 10, 2.0)
+
+	var leaked HistogramReader
+	gh.CallSyncReadEx(func(hr HistogramReader) {
+		hr.Total()
+		leaked = hr
+	})
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic on leaked HistogramReader")
+		}
+	}()
+
+	leaked.Percentile(50)
+}
@@ -0,0 +1,131 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+)
+
+// SetAutoRange enables automatic rebinning of the histogram, for
+// users who can't know good numBins and binFirst values upfront.
+// Whenever the last, catch-all bin holds more than the given fraction
+// of the TotCount, once the TotCount reaches minCount, every two
+// adjacent bins are merged into one and the freed bins extend the
+// range upwards, continuing the progression of the bin widths.  A
+// fraction of 0 disables auto ranging.
+//
+// The counts of the previous catch-all bin stay within the bin that
+// holds its lower bound, as their actual data points are unknown.
+// Rebinning replaces the Ranges and Counts with new slices, so an auto
+// ranging histogram should not be combined via AddAll() with
+// histograms of other layouts.  Histograms.AddAll() and Merged()
+// check the layouts while the histograms are locked, and return an
+// error when a concurrent rebin mismatched them.
+func (gh *Histogram) SetAutoRange(fraction float64, minCount uint64) {
+	gh.m.Lock()
+	gh.autoRangeFraction = fraction
+	gh.autoRangeMinCount = minCount
+	gh.m.Unlock()
+}
+
+// maybeAutoRangeUNLOCKED rebins the histogram if the last bin holds
+// too large a fraction of the counts.
+func (gh *Histogram) maybeAutoRangeUNLOCKED() {
+	last := len(gh.Counts) - 1
+	if gh.autoRangeFraction <= 0 || last < 2 ||
+		gh.TotCount < gh.autoRangeMinCount ||
+		float64(gh.Counts[last]) <= gh.autoRangeFraction*float64(gh.TotCount) {
+		return
+	}
+
+	ranges, counts, ok := rebinDouble(gh.Ranges, gh.Counts)
+	if !ok {
+		gh.autoRangeFraction = 0 // The ranges can't grow any further.
+		return
+	}
+
+	// New slices, as readers like Histogram32 layouts or merges may
+	// still hold the previous ranges.
+	gh.Ranges, gh.Counts = ranges, counts
+
+	gh.delta.rebinDouble()
+
+	if gh.history != nil {
+		cur := gh.history.cur
+		cur.Ranges, cur.Counts, _ = rebinDouble(cur.Ranges, cur.Counts)
+	}
+}
+
+// rebinDouble returns new ranges and counts, where every two adjacent
+// bins are merged and the ranges are extended upwards in the freed
+// bins.  The given ranges and counts are never modified.  Returns
+// false if the extended ranges would overflow uint64.
+func rebinDouble(ranges, counts []uint64) (
+	newRanges, newCounts []uint64, ok bool) {
+	n := len(ranges)
+	h := (n + 1) / 2 // The number of merged bins.
+
+	// Check the extension fits before modifying anything.
+	prev, prevPrev := ranges[2*(h-1)], ranges[2*(h-2)]
+	var prevPrevPrev uint64
+	if h >= 3 {
+		prevPrevPrev = ranges[2*(h-3)]
+	}
+	for i := h; i < n; i++ {
+		next, ok := nextRange(prevPrevPrev, prevPrev, prev, h >= 3 || i > h)
+		if !ok {
+			return nil, nil, false
+		}
+		prevPrevPrev, prevPrev, prev = prevPrev, prev, next
+	}
+
+	newRanges = make([]uint64, n)
+	newCounts = make([]uint64, n)
+
+	for i := 0; i < h; i++ {
+		newRanges[i] = ranges[2*i]
+		newCounts[i] = counts[2*i]
+		if 2*i+1 < n {
+			newCounts[i] += counts[2*i+1]
+		}
+	}
+
+	for i := h; i < n; i++ {
+		var prevPrevPrev uint64
+		if i >= 3 {
+			prevPrevPrev = newRanges[i-3]
+		}
+		newRanges[i], _ = nextRange(prevPrevPrev,
+			newRanges[i-2], newRanges[i-1], i >= 3)
+	}
+
+	return newRanges, newCounts, true
+}
+
+// nextRange returns the range boundary after c, continuing the
+// progression of a, b, c, which is either constant width or growing
+// by a constant factor.  Without a known a, constant width is used.
+func nextRange(a, b, c uint64, haveA bool) (uint64, bool) {
+	var next uint64
+
+	if !haveA || c-b == b-a || b == 0 {
+		next = c + (c - b)
+	} else {
+		next = uint64(math.Ceil(float64(c) * (float64(c) / float64(b))))
+		if float64(c)*(float64(c)/float64(b)) >= math.MaxUint64 {
+			return 0, false
+		}
+	}
+
+	if next <= c {
+		return 0, false
+	}
+
+	return next, true
+}
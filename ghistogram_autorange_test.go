@@ -0,0 +1,135 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestRebinDouble(t *testing.T) {
+	tests := []struct {
+		ranges    []uint64
+		counts    []uint64
+		expRanges []uint64
+		expCounts []uint64
+		expOk     bool
+	}{
+		{[]uint64{0, 10, 20, 30, 40}, []uint64{1, 2, 3, 4, 5},
+			[]uint64{0, 20, 40, 60, 80}, []uint64{3, 7, 5, 0, 0}, true},
+		{[]uint64{0, 10, 20, 40, 80}, []uint64{1, 2, 3, 4, 5},
+			[]uint64{0, 20, 80, 320, 1280}, []uint64{3, 7, 5, 0, 0}, true},
+		{[]uint64{0, 10, 20, 30}, []uint64{1, 2, 3, 4},
+			[]uint64{0, 20, 40, 60}, []uint64{3, 7, 0, 0}, true},
+		{[]uint64{0, 10, 20}, []uint64{1, 2, 3},
+			[]uint64{0, 20, 40}, []uint64{3, 3, 0}, true},
+		{[]uint64{0, 1 << 62, 1 << 63}, []uint64{1, 2, 3},
+			[]uint64{0, 1 << 62, 1 << 63}, []uint64{1, 2, 3}, false},
+		{[]uint64{0, 10, 1 << 40, 1 << 62, math.MaxUint64},
+			[]uint64{1, 2, 3, 4, 5},
+			[]uint64{0, 10, 1 << 40, 1 << 62, math.MaxUint64},
+			[]uint64{1, 2, 3, 4, 5}, false},
+	}
+
+	for testi, test := range tests {
+		origRanges := append([]uint64(nil), test.ranges...)
+		origCounts := append([]uint64(nil), test.counts...)
+
+		ranges, counts, ok := rebinDouble(test.ranges, test.counts)
+		if ok != test.expOk {
+			t.Errorf("test #%d, expOk: %v, got: %v", testi, test.expOk, ok)
+		}
+		if !ok {
+			ranges, counts = test.ranges, test.counts
+		}
+		if !reflect.DeepEqual(ranges, test.expRanges) ||
+			!reflect.DeepEqual(counts, test.expCounts) {
+			t.Errorf("test #%d, ranges: %v, counts: %v,"+
+				" expRanges: %v, expCounts: %v", testi,
+				ranges, counts, test.expRanges, test.expCounts)
+		}
+		if !reflect.DeepEqual(test.ranges, origRanges) ||
+			!reflect.DeepEqual(test.counts, origCounts) {
+			t.Errorf("test #%d, expected the inputs to be unmodified",
+				testi)
+		}
+	}
+}
+
+func TestAutoRange(t *testing.T) {
+	gh := NewHistogram(5, 10, 0.0)
+	gh.SetAutoRange(0.5, 10)
+
+	for i := 0; i < 30; i++ {
+		gh.Add(100, 1)
+	}
+
+	exp := []uint64{0, 40, 80, 120, 160}
+	for i := range exp {
+		if gh.Ranges[i] != exp[i] {
+			t.Fatalf("unexpected ranges: %v", gh.Ranges)
+		}
+	}
+
+	if gh.Counts[1] != 10 || gh.Counts[2] != 20 || gh.TotCount != 30 {
+		t.Errorf("unexpected counts: %v", gh.Counts)
+	}
+
+	gh2 := NewHistogram(5, 10, 0.0)
+	gh2.SetAutoRange(0, 0)
+	gh2.Add(100, 30)
+	if gh2.Ranges[4] != 40 {
+		t.Errorf("expected no rebinning when disabled")
+	}
+}
+
+// TestAutoRangeConcurrentMerge is meant for go test -race, where
+// merges must not race with the rebinning of their sources.
+func TestAutoRangeConcurrentMerge(t *testing.T) {
+	src := NewNamedHistogram("get", 8, 10, 2.0)
+	src.SetAutoRange(0.1, 10)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := uint64(1); i < 4000; i++ {
+			src.Add(i*i, 1)
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		hmap := Histograms{"get": src}
+
+		if merged, err := hmap.Merged("all"); err == nil {
+			var sum uint64
+			for _, c := range merged.Counts {
+				sum += c
+			}
+			if sum != merged.TotCount {
+				t.Fatalf("inconsistent merge: %v", merged)
+			}
+		}
+
+		dst := make(Histograms)
+		dst.AddAll(hmap)
+		dst.AddAll(hmap) // Possibly mismatched after a rebin.
+
+		NewHistogram32("get", src)
+		src.SameLayout(dst["get"])
+	}
+
+	wg.Wait()
+
+	if src.Snapshot().Ranges[7] <= 1280 {
+		t.Errorf("expected the source to rebin")
+	}
+}
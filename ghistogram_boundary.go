@@ -0,0 +1,55 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+// BinBoundary selects which ends of the bin domains are inclusive.
+//
+// With either convention, the first bin also includes Ranges[0] and
+// the last bin extends to, and includes, math.MaxUint64.  Data points
+// below Ranges[0] don't belong to any bin.
+type BinBoundary int
+
+const (
+	// RightOpen bins have the domain "[Ranges[i], Ranges[i+1])".
+	// This is the default.
+	RightOpen BinBoundary = iota
+
+	// RightClosed bins have the domain "(Ranges[i], Ranges[i+1]]",
+	// as used by, for example, Prometheus "le" buckets.
+	RightClosed
+)
+
+// SetBinBoundary changes the bin boundary convention of the
+// histogram, which affects subsequently added data points.
+func (gh *Histogram) SetBinBoundary(boundary BinBoundary) {
+	gh.m.Lock()
+	gh.boundary = boundary
+	gh.m.Unlock()
+}
+
+// BinIndex returns the index of the bin that dataPoint belongs to,
+// or -1 if the dataPoint doesn't belong to any bin.
+func (gh *Histogram) BinIndex(dataPoint uint64) int {
+	gh.m.Lock()
+	idx := binIndex(gh.Ranges, gh.boundary, dataPoint)
+	gh.m.Unlock()
+	return idx
+}
+
+// binIndex is the single place that defines which bin of the ranges
+// a dataPoint belongs to, given the boundary convention.
+func binIndex(ranges []uint64, boundary BinBoundary, dataPoint uint64) int {
+	idx := search(ranges, dataPoint)
+	if boundary == RightClosed && idx > 0 && ranges[idx] == dataPoint {
+		// The dataPoint is the inclusive upper end of the previous bin.
+		return idx - 1
+	}
+
+	return idx
+}
@@ -0,0 +1,76 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBinIndex(t *testing.T) {
+	ranges := []uint64{0, 10, 20}
+	shifted := []uint64{5, 10, 20}
+
+	tests := []struct {
+		arr      []uint64
+		boundary BinBoundary
+		val      uint64
+		exp      int
+	}{
+		{ranges, RightOpen, 0, 0},
+		{ranges, RightOpen, 9, 0},
+		{ranges, RightOpen, 10, 1},
+		{ranges, RightOpen, 20, 2},
+		{ranges, RightOpen, math.MaxUint64, 2},
+
+		{ranges, RightClosed, 0, 0},
+		{ranges, RightClosed, 10, 0},
+		{ranges, RightClosed, 11, 1},
+		{ranges, RightClosed, 20, 1},
+		{ranges, RightClosed, 21, 2},
+		{ranges, RightClosed, math.MaxUint64, 2},
+
+		{shifted, RightOpen, 4, -1},
+		{shifted, RightOpen, 5, 0},
+		{shifted, RightClosed, 4, -1},
+		{shifted, RightClosed, 5, 0},
+
+		{[]uint64(nil), RightOpen, 0, -1},
+		{[]uint64(nil), RightClosed, 0, -1},
+	}
+
+	for testi, test := range tests {
+		got := binIndex(test.arr, test.boundary, test.val)
+		if got != test.exp {
+			t.Errorf("test #%d, arr: %v, boundary: %d, val: %d,"+
+				" exp: %d, got: %d", testi, test.arr, test.boundary,
+				test.val, test.exp, got)
+		}
+	}
+}
+
+func TestRightClosedAdd(t *testing.T) {
+	gh := NewHistogram(3, 10, 0.0)
+	gh.SetBinBoundary(RightClosed)
+
+	gh.Add(10, 1)
+	gh.Add(20, 1)
+	gh.Add(21, 1)
+
+	exp := []uint64{1, 1, 1}
+	for i := range exp {
+		if gh.Counts[i] != exp[i] {
+			t.Errorf("actual (%v) != exp (%v)", gh.Counts, exp)
+		}
+	}
+
+	if gh.CloneEmpty().BinIndex(10) != 0 {
+		t.Errorf("expected CloneEmpty to keep the bin boundary")
+	}
+}
@@ -0,0 +1,40 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+// WithoutCatchAll configures the histogram to reject data points that
+// exceed its range, instead of absorbing them into its last, catch-all
+// "N - inf" bin, which then stays empty, for example:
+//
+//    gh := ghistogram.NewNamedHistogram("get (µs)", 20, 10, 2.0).
+//        WithoutCatchAll()
+//
+// The rejected data points are only reflected by OverflowCount(), and
+// are reported by AddChecked().  As Ranges[0] is always 0, the first
+// bin is never a catch-all.  Returns the histogram.
+func (gh *Histogram) WithoutCatchAll() *Histogram {
+	gh.m.Lock()
+	gh.noCatchAll = true
+	gh.m.Unlock()
+
+	return gh
+}
+
+// AddChecked is like Add(), but returns false when the dataPoint
+// exceeds the range of the histogram, meaning it belongs to the last,
+// catch-all bin.  The dataPoint is then either still added to the
+// catch-all bin, or rejected when the histogram was configured
+// WithoutCatchAll().
+func (gh *Histogram) AddChecked(dataPoint uint64, count uint64) bool {
+	gh.m.Lock()
+	rv := gh.addUNLOCKED(dataPoint, count)
+	gh.m.Unlock()
+
+	return rv
+}
@@ -0,0 +1,55 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithoutCatchAll(t *testing.T) {
+	tests := []struct {
+		noCatchAll bool
+		expCounts  []uint64
+		expTot     uint64
+	}{
+		{false, []uint64{1, 1, 3}, 5},
+		{true, []uint64{1, 1, 0}, 2},
+	}
+
+	for testi, test := range tests {
+		gh := NewHistogram(3, 10, 2.0)
+		if test.noCatchAll {
+			gh = gh.WithoutCatchAll()
+		}
+
+		var checked []bool
+		for _, dp := range []uint64{5, 15, 20, 1000} {
+			count := uint64(1)
+			if dp == 1000 {
+				count = 2
+			}
+			checked = append(checked, gh.AddChecked(dp, count))
+		}
+
+		if !reflect.DeepEqual(checked, []bool{true, true, false, false}) {
+			t.Errorf("test #%d, unexpected AddChecked: %v", testi, checked)
+		}
+
+		if !reflect.DeepEqual(gh.Counts, test.expCounts) ||
+			gh.TotCount != test.expTot || gh.OverflowCount() != 3 {
+			t.Errorf("test #%d, unexpected histogram: %+v", testi, gh)
+		}
+
+		if test.noCatchAll && (gh.MaxDataPoint != 15 ||
+			gh.CloneEmpty().AddChecked(1000, 1)) {
+			t.Errorf("test #%d, expected rejected data points", testi)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"path/filepath"
+)
+
+// cbcollect_info gathers the plain files found in a service's log
+// directory into its support bundle.  The helpers below dump a map of
+// histograms as two such files named after a prefix, like "indexer":
+//
+//    <prefix>_histograms.log  - the ASCII graphs, in name order.
+//    <prefix>_histograms.json - the JSON encoding, see MarshalJSON().
+
+// collectFile is a file of a cbcollect_info dump.
+type collectFile struct {
+	name string
+	data []byte
+}
+
+// collectFiles returns the files of a cbcollect_info dump of hmap.
+func (hmap Histograms) collectFiles(prefix string) ([]collectFile, error) {
+	j, err := json.MarshalIndent(hmap, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return []collectFile{
+		{prefix + "_histograms.log", []byte(hmap.String())},
+		{prefix + "_histograms.json", append(j, '\n')},
+	}, nil
+}
+
+// WriteCollectDir writes the cbcollect_info dump of the histograms
+// into the directory dir, usually the service's log directory,
+// replacing any earlier dump with the same prefix.
+func (hmap Histograms) WriteCollectDir(dir, prefix string) error {
+	files, err := hmap.collectFiles(prefix)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		// Write then rename, so cbcollect_info never sees a partial file.
+		err = writeFileAtomic(filepath.Join(dir, f.name), f.data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteCollectZip adds the cbcollect_info dump of the histograms to a
+// zip being assembled by the caller, placing the files within the zip
+// directory dir, like "cbcollect_info_node1_20170101-000000".
+func (hmap Histograms) WriteCollectZip(zw *zip.Writer,
+	dir, prefix string) error {
+	files, err := hmap.collectFiles(prefix)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		w, err := zw.Create(dir + "/" + f.name)
+		if err != nil {
+			return err
+		}
+
+		if _, err = w.Write(f.data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteCollectDir(t *testing.T) {
+	hmap, _, _ := initAndFetchHistograms(t)
+
+	dir, err := ioutil.TempDir("", "ghistogram")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err = hmap.WriteCollectDir(dir, "svc"); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "svc_histograms.log"))
+	if err != nil || string(b) != hmap.String() {
+		t.Errorf("unexpected log file, err: %v, got: %s", err, b)
+	}
+
+	b, err = ioutil.ReadFile(filepath.Join(dir, "svc_histograms.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got Histograms
+	if err = json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["test1"].TotCount != 6 {
+		t.Errorf("unexpected json file, got: %s", b)
+	}
+
+	entries, _ := ioutil.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("expected no leftover temp files, got: %d", len(entries))
+	}
+}
+
+func TestWriteCollectZip(t *testing.T) {
+	hmap, _, _ := initAndFetchHistograms(t)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if err := hmap.WriteCollectZip(zw, "cbcollect_info_n1", "svc"); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(zr.File) != 2 ||
+		zr.File[0].Name != "cbcollect_info_n1/svc_histograms.log" ||
+		zr.File[1].Name != "cbcollect_info_n1/svc_histograms.json" {
+		t.Errorf("unexpected zip files: %v", zr.File)
+	}
+}
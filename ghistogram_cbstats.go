@@ -0,0 +1,123 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cbstatsBarWidth is the number of '#' of a bin holding every count.
+const cbstatsBarWidth = 75
+
+// emitCBStats renders the histogram like the "cbstats timings" output
+// of memcached and ep-engine, so tooling and support scripts built for
+// KV timings can consume it unchanged.  Only the non-empty bins are
+// shown, with time labels in the us, ms, s and m:s groupings of
+// cbstats, their cumulative percentage, count and a bar, followed by
+// the average, for example:
+//
+//     get_cmd (1164 total)
+//        0 - 1us       : ( 24.83%)  289 ##################
+//        1us - 2us     : ( 85.31%)  704 #############################################
+//        2us - 4us     : ( 95.10%)  114 #######
+//        Avg           : (    2us)
+//
+// The data points are converted from the time unit of the histogram,
+// see WithUnit(), where data points of histograms without a time unit
+// are taken as microseconds, like cbstats does.
+func emitCBStats(w io.Writer, gh *Histogram) error {
+	var out bytes.Buffer
+
+	fmt.Fprintf(&out, " %s (%d total)\n", gh.Name, gh.TotCount)
+
+	unit := gh.durationUnit()
+
+	var labels []string
+	var counts []uint64
+	var widest, widestCount int
+
+	last := len(gh.Counts) - 1
+	for i, c := range gh.Counts {
+		if c == 0 {
+			continue
+		}
+
+		upper := "inf"
+		if i < last {
+			upper = cbstatsTimeLabel(gh.rangeLabel(gh.Ranges[i+1]), unit)
+		}
+
+		label := cbstatsTimeLabel(gh.rangeLabel(gh.Ranges[i]), unit) +
+			" - " + upper
+		labels = append(labels, label)
+		counts = append(counts, c)
+
+		if widest < len(label) {
+			widest = len(label)
+		}
+		if n := len(strconv.FormatUint(c, 10)); widestCount < n {
+			widestCount = n
+		}
+	}
+
+	if widest < len("Avg") {
+		widest = len("Avg")
+	}
+
+	var runCount uint64
+	for i, label := range labels {
+		runCount += counts[i]
+		pRun := percentHundredths(runCount, gh.TotCount)
+
+		fmt.Fprintf(&out, "    %-*s : (%3d.%02d%%) %*d %s\n",
+			widest, label, pRun/100, pRun%100, widestCount, counts[i],
+			strings.Repeat("#",
+				int(mulDiv(counts[i], cbstatsBarWidth, gh.TotCount))))
+	}
+
+	if gh.TotCount > 0 {
+		fmt.Fprintf(&out, "    %-*s : (%7s)\n", widest, "Avg",
+			cbstatsTimeLabel(uint64(gh.meanUNLOCKED()), unit))
+	}
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// cbstatsTimeLabel renders a data point of the unit like the time
+// labels of cbstats, which truncate to whole microseconds,
+// milliseconds, seconds, or minutes and seconds.  Values below a
+// microsecond, which cbstats never shows, are labeled in nanoseconds.
+func cbstatsTimeLabel(v uint64, unit time.Duration) string {
+	if v == 0 {
+		return "0"
+	}
+
+	ns := mulDiv(v, uint64(unit), 1)
+	us := ns / 1000
+
+	switch {
+	case us == 0:
+		return strconv.FormatUint(ns, 10) + "ns"
+	case us < 1000:
+		return strconv.FormatUint(us, 10) + "us"
+	case us < 1000*1000:
+		return strconv.FormatUint(us/1000, 10) + "ms"
+	case us < 60*1000*1000:
+		return strconv.FormatUint(us/(1000*1000), 10) + "s"
+	}
+
+	secs := us / (1000 * 1000)
+	return fmt.Sprintf("%dm:%02ds", secs/60, secs%60)
+}
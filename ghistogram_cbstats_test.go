@@ -0,0 +1,70 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestEmitCBStats(t *testing.T) {
+	gh := NewNamedHistogram("get_cmd", 5, 1, 2.0)
+	gh.Add(0, 289)
+	gh.Add(1, 704)
+	gh.Add(3, 114)
+	gh.Add(50, 57)
+
+	var buf bytes.Buffer
+	if err := gh.Emit(FormatCBStats, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := ` get_cmd (1164 total)
+    0 - 1us   : ( 24.83%) 289 ##################
+    1us - 2us : ( 85.31%) 704 #############################################
+    2us - 4us : ( 95.10%) 114 #######
+    8us - inf : (100.00%)  57 ###
+    Avg       : (    2us)
+`
+	if buf.String() != exp {
+		t.Errorf("got:\n%s\nexp:\n%s", buf.String(), exp)
+	}
+
+	buf.Reset()
+	NewNamedHistogram("empty", 5, 1, 2.0).Emit(FormatCBStats, &buf)
+	if buf.String() != " empty (0 total)\n" {
+		t.Errorf("unexpected empty output: %q", buf.String())
+	}
+}
+
+func TestCBStatsTimeLabel(t *testing.T) {
+	tests := []struct {
+		v    uint64
+		unit time.Duration
+		exp  string
+	}{
+		{0, time.Microsecond, "0"},
+		{4, time.Microsecond, "4us"},
+		{838384, time.Microsecond, "838ms"},
+		{8283852, time.Microsecond, "8s"},
+		{83838520, time.Microsecond, "1m:23s"},
+		{500, time.Nanosecond, "500ns"},
+		{2500, time.Nanosecond, "2us"},
+		{3, time.Second, "3s"},
+		{1 << 63, time.Second, "307445734m:33s"}, // Saturated.
+	}
+
+	for _, test := range tests {
+		if got := cbstatsTimeLabel(test.v, test.unit); got != test.exp {
+			t.Errorf("v: %d, unit: %v, expected: %s, got: %s",
+				test.v, test.unit, test.exp, got)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// NewHistogramChecked is like NewHistogram(), but validates the
+// parameters, see NewNamedHistogramChecked().
+func NewHistogramChecked(
+	numBins int,
+	binFirst uint64,
+	binGrowthFactor float64) (*Histogram, error) {
+	return NewNamedHistogramChecked("histogram",
+		numBins, binFirst, binGrowthFactor)
+}
+
+// NewNamedHistogramChecked is like NewNamedHistogram(), but returns
+// an error instead of panicking or silently creating surprising bins.
+// The numBins must be >= 1, where a single bin holds all data points,
+// and, like the first bin of any histogram, is not a catch-all, see
+// WithoutCatchAll(), so its data points are not clamped.  The binFirst must be > 0 and the binGrowthFactor must be 0.0 or
+// > 1.0.  The bin boundaries must also strictly increase without
+// overflowing a uint64, see Validate().
+func NewNamedHistogramChecked(
+	name string,
+	numBins int,
+	binFirst uint64,
+	binGrowthFactor float64) (*Histogram, error) {
+	if numBins < 1 {
+		return nil, fmt.Errorf("ghistogram: invalid numBins: %d", numBins)
+	}
+	if binFirst == 0 {
+		return nil, fmt.Errorf("ghistogram: invalid binFirst: 0")
+	}
+	if binGrowthFactor != 0.0 &&
+		(!(binGrowthFactor > 1.0) || math.IsInf(binGrowthFactor, 0)) {
+		return nil, fmt.Errorf("ghistogram: invalid binGrowthFactor: %v",
+			binGrowthFactor)
+	}
+
+	gh := &Histogram{
+		Name:         name,
+		Ranges:       make([]uint64, numBins),
+		Counts:       make([]uint64, numBins),
+		TotCount:     0,
+		MinDataPoint: math.MaxUint64,
+		MaxDataPoint: 0,
+		start:        time.Now(),
+	}
+
+	if numBins == 1 {
+		return gh, nil
+	}
+
+	gh.Ranges[1] = binFirst
+
+	for i := 2; i < len(gh.Ranges); i++ {
+		next, ok := nextBinRange(gh.Ranges[i-1], binFirst, binGrowthFactor)
+		if !ok {
+			return nil, fmt.Errorf("ghistogram: bin %d overflows", i)
+		}
+		gh.Ranges[i] = next
+	}
+
+	if err := gh.Validate(); err != nil {
+		return nil, err
+	}
+
+	return gh, nil
+}
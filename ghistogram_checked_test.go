@@ -0,0 +1,84 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNewHistogramChecked(t *testing.T) {
+	tests := []struct {
+		numBins         int
+		binFirst        uint64
+		binGrowthFactor float64
+		expErr          bool
+	}{
+		{0, 10, 0.0, true},
+		{-1, 10, 0.0, true},
+		{1, 10, 0.0, false},
+		{2, 0, 0.0, true},
+		{5, 10, 0.5, true},
+		{5, 10, 1.0, true},
+		{5, 10, -2.0, true},
+		{5, 10, math.NaN(), true},
+		{5, 10, math.Inf(1), true},
+		{5, 10, 2.0, false},
+		{5, 10, 0.0, false},
+		{5, math.MaxUint64 / 3, 0.0, true},
+		{100, 10, 2.0, true},
+	}
+
+	for testi, test := range tests {
+		gh, err := NewHistogramChecked(test.numBins,
+			test.binFirst, test.binGrowthFactor)
+		if (err != nil) != test.expErr {
+			t.Errorf("test #%d, %+v, expErr: %v, got: %v",
+				testi, test, test.expErr, err)
+		}
+		if err != nil || test.numBins < 2 {
+			continue
+		}
+
+		exp := NewHistogram(test.numBins, test.binFirst, test.binGrowthFactor)
+		if !reflect.DeepEqual(gh.Ranges, exp.Ranges) {
+			t.Errorf("test #%d, expected ranges: %v, got: %v",
+				testi, exp.Ranges, gh.Ranges)
+		}
+	}
+
+	gh, _ := NewHistogramChecked(1, 10, 0.0)
+	gh.Add(100, 2)
+	if gh.Counts[0] != 2 {
+		t.Errorf("expected a single bin to hold all data points")
+	}
+	if d := gh.Diagnostics(); d.Clamped != 0 || gh.OverflowCount() != 0 {
+		t.Errorf("expected a single bin not to clamp, got: %+v", d)
+	}
+
+	gh, _ = NewHistogramChecked(1, 10, 0.0)
+	gh.WithoutCatchAll()
+	if !gh.AddChecked(100, 3) || gh.Counts[0] != 3 {
+		t.Errorf("expected a single bin not to reject data points")
+	}
+}
+
+func TestNewLogLinearHistogramChecked(t *testing.T) {
+	if _, err := NewLogLinearHistogramChecked("x", 0, 100, 2); err == nil {
+		t.Errorf("expected error for lowest of 0")
+	}
+
+	gh, err := NewLogLinearHistogramChecked("x", 1, 1000, 2)
+	if err != nil || !reflect.DeepEqual(gh.Ranges,
+		NewLogLinearHistogram("x", 1, 1000, 2).Ranges) {
+		t.Errorf("expected same ranges as NewLogLinearHistogram, err: %v",
+			err)
+	}
+}
@@ -0,0 +1,173 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of time of the time based features, like the
+// reset and sample times, the rate-limiting of hooks, the Timer rates
+// and the tickers of the Sampler, LocalRecorder and Reporter, so tests
+// can drive time deterministically, see ManualClock, and embedders may
+// supply their own time source.
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks of a Clock, like a time.Ticker.
+type Ticker interface {
+	Chan() <-chan time.Time
+	Stop()
+}
+
+// SystemClock is the Clock of the time package, which is the default.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTicker struct{ t *time.Ticker }
+
+func (t systemTicker) Chan() <-chan time.Time { return t.t.C }
+
+func (t systemTicker) Stop() { t.t.Stop() }
+
+// SetClock changes the Clock of the histogram, which is also used by
+// the Sampler and LocalRecorder started on it afterwards.  A nil
+// clock restores the SystemClock.  The Window() of the histogram
+// restarts at the time of the new clock.
+func (gh *Histogram) SetClock(clock Clock) {
+	gh.m.Lock()
+	gh.clock = clock
+	if c, _ := gh.summary.Load().(*summaryCache); c != nil {
+		// Also drops any cached Summary, which is of the old clock.
+		gh.summary.Store(&summaryCache{
+			maxStaleness: c.maxStaleness,
+			clock:        clock,
+		})
+	}
+	if !gh.start.IsZero() {
+		gh.start, gh.end = gh.now(), time.Time{}
+	}
+	gh.m.Unlock()
+}
+
+// now returns the time of the histogram's clock.
+func (gh *Histogram) now() time.Time {
+	return clockNow(gh.clock)
+}
+
+// clockNow returns the time of the clock, where nil is the
+// SystemClock.
+func clockNow(clock Clock) time.Time {
+	if clock == nil {
+		return time.Now()
+	}
+	return clock.Now()
+}
+
+// clockTicker returns a ticker of the clock, where nil is the
+// SystemClock.
+func clockTicker(clock Clock, d time.Duration) Ticker {
+	if clock == nil {
+		clock = SystemClock
+	}
+	return clock.NewTicker(d)
+}
+
+// ManualClock is a Clock whose time only moves when advanced, which
+// fires its tickers, for deterministic tests, for example:
+//
+//    clock := ghistogram.NewManualClock(time.Unix(0, 0))
+//    r := ghistogram.StartReportingWithClock(ctx, clock, hmap,
+//        time.Minute, true, sink)
+//    clock.Advance(time.Minute) // Triggers a report.
+//
+// The ManualClock is concurrent safe.
+type ManualClock struct {
+	m       sync.Mutex
+	now     time.Time
+	tickers map[*manualTicker]struct{}
+}
+
+// NewManualClock returns a ManualClock whose time starts at now.
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{
+		now:     now,
+		tickers: make(map[*manualTicker]struct{}),
+	}
+}
+
+// Now returns the current time of the clock.
+func (c *ManualClock) Now() time.Time {
+	c.m.Lock()
+	rv := c.now
+	c.m.Unlock()
+	return rv
+}
+
+// NewTicker returns a ticker that ticks every d of advanced time.
+func (c *ManualClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("ghistogram: ManualClock, non-positive ticker interval")
+	}
+
+	c.m.Lock()
+	t := &manualTicker{
+		c:     c,
+		ch:    make(chan time.Time, 1),
+		every: d,
+		next:  c.now.Add(d),
+	}
+	c.tickers[t] = struct{}{}
+	c.m.Unlock()
+
+	return t
+}
+
+// Advance moves the time of the clock forward by d, firing the
+// tickers that became due.  Like a time.Ticker, a ticker drops the
+// ticks its receiver is not ready for.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.m.Lock()
+	c.now = c.now.Add(d)
+	for t := range c.tickers {
+		for !t.next.After(c.now) {
+			select {
+			case t.ch <- t.next:
+			default:
+			}
+			t.next = t.next.Add(t.every)
+		}
+	}
+	c.m.Unlock()
+}
+
+type manualTicker struct {
+	c     *ManualClock
+	ch    chan time.Time
+	every time.Duration
+	next  time.Time
+}
+
+func (t *manualTicker) Chan() <-chan time.Time { return t.ch }
+
+func (t *manualTicker) Stop() {
+	t.c.m.Lock()
+	delete(t.c.tickers, t)
+	t.c.m.Unlock()
+}
@@ -0,0 +1,141 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestManualClock(t *testing.T) {
+	start := time.Unix(1000, 0)
+	clock := NewManualClock(start)
+
+	ticker := clock.NewTicker(time.Second)
+
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case <-ticker.Chan():
+		t.Errorf("expected no tick before the interval")
+	default:
+	}
+
+	// Ticks the receiver isn't ready for are dropped.
+	clock.Advance(3 * time.Second)
+	if tick := <-ticker.Chan(); !tick.Equal(start.Add(time.Second)) {
+		t.Errorf("unexpected tick: %v", tick)
+	}
+	select {
+	case <-ticker.Chan():
+		t.Errorf("expected dropped ticks")
+	default:
+	}
+
+	if !clock.Now().Equal(start.Add(3500 * time.Millisecond)) {
+		t.Errorf("unexpected now: %v", clock.Now())
+	}
+
+	ticker.Stop()
+	clock.Advance(time.Hour)
+	select {
+	case <-ticker.Chan():
+		t.Errorf("expected no tick after Stop")
+	default:
+	}
+}
+
+func TestHistogramClock(t *testing.T) {
+	clock := NewManualClock(time.Unix(1000, 0))
+
+	gh := NewNamedHistogram("get", 5, 10, 2.0)
+	gh.SetClock(clock)
+	gh.SetTrackSampleTimes(true)
+
+	var hooked int
+	gh.SlowOpHook(100, func(dataPoint uint64) { hooked++ })
+
+	gh.Add(500, 1)
+	gh.Add(500, 1) // Rate-limited.
+	clock.Advance(SlowOpHookInterval)
+	gh.Add(500, 1)
+	if hooked != 2 {
+		t.Errorf("expected 2 hook invocations, got: %d", hooked)
+	}
+
+	if gh.firstSample != time.Unix(1000, 0).UnixNano() ||
+		gh.lastSample != clock.Now().UnixNano() {
+		t.Errorf("unexpected sample times: %d, %d",
+			gh.firstSample, gh.lastSample)
+	}
+
+	gh.ResetWithReason("test")
+	if !gh.CloneEmpty().now().Equal(clock.Now()) ||
+		!gh.resetTime.Equal(clock.Now()) {
+		t.Errorf("unexpected reset time: %v", gh.resetTime)
+	}
+
+	// The Sampler ticks on the histogram's clock.
+	s := StartSampler(gh, time.Second, func() uint64 { return 7 })
+	clock.Advance(time.Second)
+	for gh.Total() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	s.Stop()
+
+	gh.SetClock(nil)
+	if time.Since(gh.now()) > time.Minute {
+		t.Errorf("expected the system clock")
+	}
+}
+
+func TestReportingWithClock(t *testing.T) {
+	clock := NewManualClock(time.Unix(1000, 0))
+
+	hmap := Histograms{"get": NewNamedHistogram("get", 5, 10, 2.0)}
+
+	reports := make(chan uint64, 10)
+	r := StartReportingWithClock(context.Background(), clock, hmap,
+		time.Minute, true, func(snaps Histograms) {
+			reports <- snaps["get"].TotCount
+		})
+
+	hmap["get"].Add(5, 3)
+	clock.Advance(time.Minute)
+	if got := <-reports; got != 3 {
+		t.Errorf("expected a report of 3, got: %d", got)
+	}
+
+	hmap["get"].Add(5, 1)
+	r.Stop()
+	if got := <-reports; got != 1 {
+		t.Errorf("expected a final report of 1, got: %d", got)
+	}
+}
+
+func TestTimerWithClock(t *testing.T) {
+	clock := NewManualClock(time.Unix(1000, 0))
+
+	tm := NewTimerWithClock("get", time.Microsecond, 10, 10, 2.0, clock)
+
+	start := clock.Now()
+	clock.Advance(25 * time.Microsecond)
+	tm.UpdateSince(start)
+
+	clock.Advance(time.Second - 25*time.Microsecond)
+
+	s := tm.Snapshot()
+	if s.Count != 1 || s.Max != 25 || s.MeanRate != 1 {
+		t.Errorf("unexpected snapshot: %+v", s)
+	}
+
+	if !tm.Histogram().now().Equal(clock.Now()) {
+		t.Errorf("expected the Timer's histogram to use the clock")
+	}
+}
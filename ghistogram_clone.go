@@ -0,0 +1,94 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Clone returns a deep copy of the histogram, including its counts,
+// data point statistics, SLO violations and reset reason, captured
+// under a single hold of the lock.
+func (gh *Histogram) Clone() *Histogram {
+	return gh.capture(false)
+}
+
+// CopyInto copies the counts and data point statistics of the
+// histogram, like Clone(), into the preallocated dst histogram, which
+// must have the same number of bins, without any heap allocations.
+// This allows reporting loops to snapshot histograms every tick
+// without creating garbage.  The dst histogram takes on the name and
+// bin ranges of the histogram, but keeps its own settings, like its
+// Rounding.  Its SLO violations are copied when it has the same SLO
+// thresholds, and are zeroed otherwise.
+func (gh *Histogram) CopyInto(dst *Histogram) error {
+	if dst == gh {
+		return nil
+	}
+
+	gh.m.Lock()
+	dst.m.Lock()
+
+	var err error
+	if len(dst.Counts) != len(gh.Counts) ||
+		len(dst.Ranges) != len(gh.Ranges) {
+		err = fmt.Errorf("ghistogram: CopyInto, histogram %q has %d bins,"+
+			" but dst %q has %d bins",
+			gh.Name, len(gh.Counts), dst.Name, len(dst.Counts))
+	} else {
+		gh.copyIntoUNLOCKED(dst)
+		dst.invalidateSummaryUNLOCKED()
+	}
+
+	dst.m.Unlock()
+	gh.m.Unlock()
+
+	return err
+}
+
+// cloneEmptyLocked is CloneEmpty() while the histogram is locked, for
+// histograms that may be auto ranging concurrently.
+func (gh *Histogram) cloneEmptyLocked() *Histogram {
+	gh.m.Lock()
+	rv := gh.CloneEmpty()
+	gh.m.Unlock()
+	return rv
+}
+
+// copyIntoUNLOCKED copies the histogram into dst, which must have the
+// same number of bins, while both are locked or dst isn't shared yet.
+func (gh *Histogram) copyIntoUNLOCKED(dst *Histogram) {
+	dst.Name = gh.Name
+	copy(dst.Ranges, gh.Ranges)
+	copy(dst.Counts, gh.Counts)
+	dst.TotCount = gh.TotCount
+	atomic.StoreUint64(&dst.total, gh.TotCount)
+	dst.TotDataPoint = gh.TotDataPoint
+	dst.MinDataPoint = gh.MinDataPoint
+	dst.MaxDataPoint = gh.MaxDataPoint
+
+	dst.overflow = gh.overflow
+	dst.saturated = gh.saturated
+
+	gh.captured = gh.TotCount
+	dst.captured = gh.TotCount
+	dst.diag = gh.diag
+
+	dst.firstSample = gh.firstSample
+	dst.lastSample = gh.lastSample
+
+	dst.resetTime = gh.resetTime
+	dst.resetReason = gh.resetReason
+
+	dst.start, dst.end = gh.windowUNLOCKED()
+
+	dst.slo.reset()
+	dst.slo.addAll(gh.slo, nil)
+}
@@ -0,0 +1,71 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	gh := NewNamedHistogram("get", 4, 10, 2.0).WithSLOThresholds(10)
+	gh.ResetWithReason("warmup")
+	gh.Add(5, 2)
+	gh.Add(50, 3)
+
+	exp := gh.EmitGraph(nil, nil).String()
+
+	c := gh.Clone()
+	if c.EmitGraph(nil, nil).String() != exp || c.Total() != 5 {
+		t.Errorf("unexpected clone, got:\n%s\nexp:\n%s",
+			c.EmitGraph(nil, nil).String(), exp)
+	}
+
+	c.Add(5, 1)
+	if gh.TotCount != 5 || gh.Counts[0] != 2 {
+		t.Errorf("expected the clone to be independent")
+	}
+}
+
+func TestCopyInto(t *testing.T) {
+	gh := NewNamedHistogram("get", 4, 10, 2.0).WithSLOThresholds(10)
+	gh.Add(5, 2)
+	gh.Add(50, 3)
+
+	dst := NewNamedHistogram("other", 4, 1, 0).WithSLOThresholds(10)
+
+	if err := gh.CopyInto(dst); err != nil {
+		t.Fatal(err)
+	}
+
+	if dst.EmitGraph(nil, nil).String() != gh.EmitGraph(nil, nil).String() {
+		t.Errorf("unexpected copy, got:\n%s", dst.EmitGraph(nil, nil))
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		gh.CopyInto(dst)
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got: %v", allocs)
+	}
+
+	other := NewHistogram(4, 1, 0).WithSLOThresholds(20)
+	other.Add(50, 1)
+	gh.CopyInto(other)
+	if other.TotCount != 5 || other.SLOReport()[0].Violations != 0 {
+		t.Errorf("expected SLO violations of other thresholds to be zeroed")
+	}
+
+	if err := gh.CopyInto(NewHistogram(5, 10, 2.0)); err == nil {
+		t.Errorf("expected error for mismatched bins")
+	}
+
+	if err := gh.CopyInto(gh); err != nil {
+		t.Errorf("expected copying into itself to be a no-op, got: %v", err)
+	}
+}
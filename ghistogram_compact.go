@@ -0,0 +1,53 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+// Compact returns a new, coarser histogram where every factor adjacent
+// bins are merged into one, which is useful for long-term storage or
+// compact display.  The counts, totals, min and max remain exact; only
+// the bin resolution is lost.  A factor <= 1 returns a plain copy.
+func (gh *Histogram) Compact(factor int) *Histogram {
+	if factor < 1 {
+		factor = 1
+	}
+
+	gh.m.Lock()
+	defer gh.m.Unlock()
+
+	rv := gh.CloneEmpty()
+
+	numBins := (len(gh.Counts) + factor - 1) / factor
+	if len(gh.Ranges) < len(gh.Counts) {
+		numBins = 0
+	}
+
+	rv.Ranges = make([]uint64, numBins)
+	rv.Counts = make([]uint64, numBins)
+
+	for i := range gh.Counts {
+		if numBins == 0 {
+			break
+		}
+		if i%factor == 0 {
+			rv.Ranges[i/factor] = gh.Ranges[i]
+		}
+		rv.Counts[i/factor] += gh.Counts[i]
+	}
+
+	rv.TotCount = gh.TotCount
+	rv.total = rv.TotCount
+	rv.TotDataPoint = gh.TotDataPoint
+	rv.MinDataPoint = gh.MinDataPoint
+	rv.MaxDataPoint = gh.MaxDataPoint
+
+	rv.firstSample = gh.firstSample
+	rv.lastSample = gh.lastSample
+
+	return rv
+}
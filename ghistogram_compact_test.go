@@ -0,0 +1,52 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompact(t *testing.T) {
+	gh := NewNamedHistogram("test", 5, 10, 0.0)
+	for i := uint64(0); i < 60; i += 5 {
+		gh.Add(i, 1)
+	}
+
+	tests := []struct {
+		factor    int
+		expRanges []uint64
+		expCounts []uint64
+	}{
+		{0, []uint64{0, 10, 20, 30, 40}, []uint64{2, 2, 2, 2, 4}},
+		{1, []uint64{0, 10, 20, 30, 40}, []uint64{2, 2, 2, 2, 4}},
+		{2, []uint64{0, 20, 40}, []uint64{4, 4, 4}},
+		{3, []uint64{0, 30}, []uint64{6, 6}},
+		{5, []uint64{0}, []uint64{12}},
+		{10, []uint64{0}, []uint64{12}},
+	}
+
+	for testi, test := range tests {
+		c := gh.Compact(test.factor)
+		if !reflect.DeepEqual(c.Ranges, test.expRanges) ||
+			!reflect.DeepEqual(c.Counts, test.expCounts) {
+			t.Errorf("test #%d, factor: %d, got ranges: %v, counts: %v",
+				testi, test.factor, c.Ranges, c.Counts)
+		}
+		if c.Name != "test" || c.TotCount != 12 ||
+			c.TotDataPoint != gh.TotDataPoint ||
+			c.MinDataPoint != 0 || c.MaxDataPoint != 55 {
+			t.Errorf("test #%d, totals not kept, got: %+v", testi, c)
+		}
+	}
+
+	if &gh.Counts[0] == &gh.Compact(1).Counts[0] {
+		t.Errorf("expected Compact to copy the counts")
+	}
+}
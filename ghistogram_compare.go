@@ -0,0 +1,120 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// CompareThresholds configures the verdicts of CompareReport().
+type CompareThresholds struct {
+	// Percentiles to compare, defaults to 50, 90, 99 and 99.9.
+	Percentiles []float64
+
+	// Regressed is the percentage increase of a percentile that's
+	// considered a regression, for example 5.0 for 5%.
+	Regressed float64
+
+	// Improved is the percentage decrease of a percentile that's
+	// considered an improvement.
+	Improved float64
+}
+
+// Verdicts of CompareReport().
+const (
+	VerdictImproved  = "improved"
+	VerdictRegressed = "regressed"
+	VerdictUnchanged = "unchanged"
+)
+
+// CompareReport compares the percentiles of two captures of a
+// histogram, such as benchmark runs before and after a change, and
+// returns a human-readable report with a verdict per percentile, for
+// example:
+//
+//    get (1000 -> 1200 Total)
+//    p50           100 ->        120    +20.00% regressed
+//    p99           900 ->        880     -2.22% unchanged
+//    verdict: regressed
+//
+// The overall verdict is regressed if any percentile regressed, else
+// improved if any percentile improved, else unchanged.  The returned
+// regressed bool allows CI performance gates to fail a build.
+func CompareReport(before, after *Histogram,
+	th CompareThresholds) (report string, regressed bool) {
+	percentiles := th.Percentiles
+	if len(percentiles) == 0 {
+		percentiles = []float64{50, 90, 99, 99.9}
+	}
+
+	before.m.Lock()
+	name, beforeTotCount := before.Name, before.TotCount
+	beforeVals := make([]uint64, len(percentiles))
+	for i, p := range percentiles {
+		beforeVals[i] = before.percentileUNLOCKED(p)
+	}
+	before.m.Unlock()
+
+	after.m.Lock()
+	afterTotCount := after.TotCount
+	afterVals := make([]uint64, len(percentiles))
+	for i, p := range percentiles {
+		afterVals[i] = after.percentileUNLOCKED(p)
+	}
+	after.m.Unlock()
+
+	var out bytes.Buffer
+	var improved bool
+
+	fmt.Fprintf(&out, "%s (%v -> %v Total)\n",
+		name, beforeTotCount, afterTotCount)
+
+	for i, p := range percentiles {
+		change := percentChange(beforeVals[i], afterVals[i])
+
+		verdict := VerdictUnchanged
+		if change >= th.Regressed && change > 0 {
+			verdict = VerdictRegressed
+			regressed = true
+		} else if -change >= th.Improved && change < 0 {
+			verdict = VerdictImproved
+			improved = true
+		}
+
+		fmt.Fprintf(&out, "%-6s %10d -> %10d %+9.2f%% %s\n",
+			"p"+strconv.FormatFloat(p, 'f', -1, 64),
+			beforeVals[i], afterVals[i], change, verdict)
+	}
+
+	verdict := VerdictUnchanged
+	if regressed {
+		verdict = VerdictRegressed
+	} else if improved {
+		verdict = VerdictImproved
+	}
+
+	fmt.Fprintf(&out, "verdict: %s\n", verdict)
+
+	return out.String(), regressed
+}
+
+// percentChange returns the percentage change from before to after,
+// which is +Inf when before is 0 and after isn't.
+func percentChange(before, after uint64) float64 {
+	if before == after {
+		return 0
+	}
+	if before == 0 {
+		return math.Inf(1)
+	}
+	return 100 * (float64(after) - float64(before)) / float64(before)
+}
@@ -0,0 +1,52 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"testing"
+)
+
+func TestCompareReport(t *testing.T) {
+	before := NewNamedHistogram("get", 10, 100, 0.0)
+	after := before.CloneEmpty()
+
+	for i := uint64(0); i < 100; i++ {
+		before.Add(i*10, 1)
+		after.Add(i*10, 1)
+	}
+	// The tail gets slower.
+	after.Add(990, 100)
+
+	th := CompareThresholds{
+		Percentiles: []float64{10, 99.9},
+		Regressed:   5,
+		Improved:    5,
+	}
+
+	report, regressed := CompareReport(before, after, th)
+
+	exp := `get (100 -> 200 Total)
+p10           100 ->        200   +100.00% regressed
+p99.9         989 ->        989     +0.00% unchanged
+verdict: regressed
+`
+	if report != exp || !regressed {
+		t.Errorf("unexpected report,\ngot: %s\nexp: %s", report, exp)
+	}
+
+	report, regressed = CompareReport(after, before, th)
+	if regressed || report[len(report)-18:] != "verdict: improved\n" {
+		t.Errorf("expected improved, got: %s", report)
+	}
+
+	report, regressed = CompareReport(before, before, CompareThresholds{})
+	if regressed || report[len(report)-19:] != "verdict: unchanged\n" {
+		t.Errorf("expected unchanged, got: %s", report)
+	}
+}
@@ -0,0 +1,38 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+// AddCorrected is like Add(), but corrects for coordinated omission,
+// following the semantics of HdrHistogram's RecordCorrectedValue.
+// When a load generator expects to issue a request every
+// expectedInterval, a request that took longer delayed the requests
+// that should have been issued meanwhile, and their latencies would go
+// unrecorded.  So when the dataPoint exceeds the expectedInterval,
+// synthetic data points of dataPoint - expectedInterval,
+// dataPoint - 2*expectedInterval, and so on while >= expectedInterval,
+// are also added, each with the given count.
+//
+// The cost is proportional to dataPoint / expectedInterval, and an
+// expectedInterval of 0 disables the correction.
+func (gh *Histogram) AddCorrected(dataPoint uint64, count uint64,
+	expectedInterval uint64) {
+	gh.m.Lock()
+
+	gh.addUNLOCKED(dataPoint, count)
+
+	if expectedInterval > 0 && dataPoint > expectedInterval {
+		missing := dataPoint - expectedInterval
+		for missing >= expectedInterval {
+			gh.addUNLOCKED(missing, count)
+			missing -= expectedInterval
+		}
+	}
+
+	gh.m.Unlock()
+}
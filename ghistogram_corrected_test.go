@@ -0,0 +1,45 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAddCorrected(t *testing.T) {
+	tests := []struct {
+		dataPoint        uint64
+		count            uint64
+		expectedInterval uint64
+		expCounts        []uint64
+		expTotDataPoint  uint64
+	}{
+		{5, 1, 0, []uint64{1, 0, 0, 0, 0}, 5},
+		{5, 1, 10, []uint64{1, 0, 0, 0, 0}, 5},
+		{10, 1, 10, []uint64{0, 1, 0, 0, 0}, 10},
+		// Adds 45, 35, 25, 15.
+		{45, 2, 10, []uint64{0, 2, 2, 2, 2}, 120},
+		// Adds 40, 30, 20, 10, but not 0.
+		{40, 1, 10, []uint64{0, 1, 1, 1, 1}, 100},
+		{1000, 1, 0, []uint64{0, 0, 0, 0, 1}, 1000},
+	}
+
+	for testi, test := range tests {
+		gh := NewHistogram(5, 10, 0.0)
+		gh.AddCorrected(test.dataPoint, test.count, test.expectedInterval)
+
+		if !reflect.DeepEqual(gh.Counts, test.expCounts) ||
+			gh.TotDataPoint != test.expTotDataPoint {
+			t.Errorf("test #%d, expected counts: %v, tot: %d,"+
+				" got: %v, tot: %d", testi, test.expCounts,
+				test.expTotDataPoint, gh.Counts, gh.TotDataPoint)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+var csvHeader = []string{
+	"start", "end", "count", "percent", "cumulative_percent",
+}
+
+// WriteCSV writes the bins of the histogram as CSV, with a header row
+// and then a "start,end,count,percent,cumulative_percent" row for
+// every bin, including empty bins, for example:
+//
+//    start,end,count,percent,cumulative_percent
+//    0,10,1,25.00,25.00
+//    10,20,0,0.00,25.00
+//    20,inf,3,75.00,100.00
+//
+// The end of the last bin is "inf", which parses as a float.  When the
+// histogram has a unit, see WithUnit(), a trailing "unit" column holds
+// it, while the start and end stay plain numbers.
+func (gh *Histogram) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	gh.m.Lock()
+	withUnit := gh.unit != ""
+	cw.Write(csvHeaderRow(nil, withUnit))
+	gh.writeCSVRowsUNLOCKED(cw, nil, withUnit)
+	gh.m.Unlock()
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteCSV writes the bins of all the histograms of the map, in name
+// order, as CSV with an additional leading name column, see
+// Histogram.WriteCSV().  The unit column is present when any of the
+// histograms has a unit.
+func (hmap Histograms) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	names := hmap.SortedNames(nil)
+
+	var withUnit bool
+	for _, name := range names {
+		withUnit = withUnit || hmap[name].Unit() != ""
+	}
+
+	cw.Write(csvHeaderRow([]string{"name"}, withUnit))
+
+	for _, name := range names {
+		gh := hmap[name]
+
+		gh.m.Lock()
+		gh.writeCSVRowsUNLOCKED(cw, []string{name}, withUnit)
+		gh.m.Unlock()
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvHeaderRow(leading []string, withUnit bool) []string {
+	row := append(append([]string(nil), leading...), csvHeader...)
+	if withUnit {
+		row = append(row, "unit")
+	}
+	return row
+}
+
+// writeCSVRowsUNLOCKED writes a row per bin, each starting with the
+// optional leading columns and optionally ending with the unit.
+func (gh *Histogram) writeCSVRowsUNLOCKED(cw *csv.Writer, leading []string,
+	withUnit bool) {
+	percent := func(n uint64) string {
+		p := percentHundredths(n, gh.TotCount)
+		return fmt.Sprintf("%d.%02d", p/100, p%100)
+	}
+
+	row := make([]string, len(leading)+len(csvHeader), len(leading)+
+		len(csvHeader)+1)
+	copy(row, leading)
+	cols := row[len(leading):]
+	if withUnit {
+		row = append(row, gh.unit)
+	}
+
+	var runCount uint64
+	for i, c := range gh.Counts {
+		runCount += c
+
+		cols[0] = strconv.FormatUint(gh.rangeLabel(gh.Ranges[i]), 10)
+		if i < len(gh.Counts)-1 {
+			cols[1] = strconv.FormatUint(gh.rangeLabel(gh.Ranges[i+1]), 10)
+		} else {
+			cols[1] = "inf"
+		}
+		cols[2] = strconv.FormatUint(c, 10)
+		cols[3] = percent(c)
+		cols[4] = percent(runCount)
+
+		cw.Write(row)
+	}
+}
@@ -0,0 +1,62 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteCSV(t *testing.T) {
+	gh := NewNamedHistogram("get", 3, 10, 0.0)
+	gh.Add(5, 1)
+	gh.Add(25, 3)
+
+	var buf bytes.Buffer
+	if err := gh.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := `start,end,count,percent,cumulative_percent
+0,10,1,25.00,25.00
+10,20,0,0.00,25.00
+20,inf,3,75.00,100.00
+`
+	if buf.String() != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, buf.String())
+	}
+
+	buf.Reset()
+	if err := gh.Emit(FormatCSV, &buf); err != nil || buf.String() != exp {
+		t.Errorf("expected csv Emit to match, got:\n%s", buf.String())
+	}
+}
+
+func TestHistogramsWriteCSV(t *testing.T) {
+	hmap := Histograms{
+		"a,b": NewNamedHistogram("x", 2, 10, 0.0),
+		"c":   NewNamedHistogram("y", 2, 10, 0.0),
+	}
+	hmap["c"].Add(10, 2)
+
+	var buf bytes.Buffer
+	if err := hmap.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := `name,start,end,count,percent,cumulative_percent
+"a,b",0,10,0,0.00,0.00
+"a,b",10,inf,0,0.00,0.00
+c,0,10,0,0.00,0.00
+c,10,inf,2,100.00,100.00
+`
+	if buf.String() != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, buf.String())
+	}
+}
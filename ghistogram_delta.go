@@ -0,0 +1,192 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"unicode/utf8"
+)
+
+// deltaState is the shadow copy of the counts last returned by
+// Delta().
+type deltaState struct {
+	counts       []uint64
+	totCount     uint64
+	totDataPoint uint64
+}
+
+// Delta returns a new histogram that holds only the counts added since
+// the previous call to Delta(), or since the histogram's creation or
+// reset, which makes pull-based exporters of interval counts correct
+// without their own bookkeeping.  The min and max data points of the
+// returned histogram are those since creation or reset, as the ones of
+// the interval are unknown.
+func (gh *Histogram) Delta() *Histogram {
+	gh.m.Lock()
+	defer gh.m.Unlock()
+
+	if gh.delta == nil {
+		gh.delta = &deltaState{counts: make([]uint64, len(gh.Counts))}
+	}
+	d := gh.delta
+
+	rv := gh.CloneEmpty()
+	for i, c := range gh.Counts {
+		rv.Counts[i] = c - d.counts[i]
+		d.counts[i] = c
+	}
+	rv.TotCount = gh.TotCount - d.totCount
+	rv.total = rv.TotCount
+	rv.TotDataPoint = gh.TotDataPoint - d.totDataPoint
+	if rv.TotCount > 0 {
+		rv.MinDataPoint = gh.MinDataPoint
+		rv.MaxDataPoint = gh.MaxDataPoint
+	}
+
+	d.totCount = gh.TotCount
+	d.totDataPoint = gh.TotDataPoint
+
+	return rv
+}
+
+func (d *deltaState) reset() {
+	if d != nil {
+		for i := range d.counts {
+			d.counts[i] = 0
+		}
+		d.totCount = 0
+		d.totDataPoint = 0
+	}
+}
+
+// rebinDouble merges the shadow counts like the rebinDouble() of the
+// histogram's bins, see SetAutoRange().
+func (d *deltaState) rebinDouble() {
+	if d == nil {
+		return
+	}
+
+	n := len(d.counts)
+	h := (n + 1) / 2
+
+	for i := 0; i < n; i++ {
+		c := uint64(0)
+		if i < h {
+			c = d.counts[2*i]
+			if 2*i+1 < n {
+				c += d.counts[2*i+1]
+			}
+		}
+		d.counts[i] = c
+	}
+}
+
+// DeltaReporter emits reports of histograms that show each bin's
+// cumulative count alongside its change since the previous report,
+// which is what an operator watching a live console wants, for
+// example:
+//
+//    get (1000 Total, +120)
+//    [0 - 10]          500        +20
+//    [10 - 20]         500       +100
+//
+// A histogram that was reset, or whose bins changed, since the
+// previous report has its deltas computed from zero.
+type DeltaReporter struct {
+	m    sync.Mutex
+	prev map[string]*Histogram // Keyed by histogram map name.
+}
+
+// NewDeltaReporter returns a DeltaReporter with no previous report.
+func NewDeltaReporter() *DeltaReporter {
+	return &DeltaReporter{prev: make(Histograms)}
+}
+
+// Fprint emits the delta report of the histograms, in name order,
+// through the provided writer, and remembers their counts for the
+// next report.
+func (r *DeltaReporter) Fprint(w io.Writer, hmap Histograms) (int, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	var out bytes.Buffer
+
+	for _, name := range hmap.SortedNames(nil) {
+		cur := hmap[name].capture(false)
+
+		r.emitUNLOCKED(&out, cur, r.prev[name])
+
+		r.prev[name] = cur
+	}
+
+	for name := range r.prev {
+		if hmap[name] == nil {
+			delete(r.prev, name)
+		}
+	}
+
+	return w.Write(out.Bytes())
+}
+
+// emitUNLOCKED emits the delta report of cur, a captured histogram,
+// against its previous capture, which may be nil.
+func (r *DeltaReporter) emitUNLOCKED(out *bytes.Buffer,
+	cur, prev *Histogram) {
+	if prev != nil && (cur.TotCount < prev.TotCount ||
+		!sameRanges(cur, prev)) {
+		prev = nil
+	}
+
+	prevCount := func(i int) uint64 {
+		if prev == nil {
+			return 0
+		}
+		return prev.Counts[i]
+	}
+
+	var prevTot uint64
+	if prev != nil {
+		prevTot = prev.TotCount
+	}
+
+	fmt.Fprintf(out, "%s (%v Total, +%v)\n",
+		cur.Name, cur.TotCount, cur.TotCount-prevTot)
+
+	if cur.TotCount == 0 {
+		out.WriteString("(empty)\n")
+		return
+	}
+
+	bins := cur.binLabelsUNLOCKED()
+
+	var longestRange int
+	for i, c := range cur.Counts {
+		if c > 0 && utf8.RuneCountInString(bins[i]) > longestRange {
+			longestRange = utf8.RuneCountInString(bins[i])
+		}
+	}
+
+	for i, c := range cur.Counts {
+		if c == 0 {
+			continue
+		}
+
+		delta := c - prevCount(i)
+		if delta > c { // A bin shrank, so the histogram was reset.
+			delta = c
+		}
+
+		fmt.Fprintf(out, "[%s]%*s %12d %10s\n", bins[i],
+			longestRange-utf8.RuneCountInString(bins[i]), "",
+			c, fmt.Sprintf("+%d", delta))
+	}
+}
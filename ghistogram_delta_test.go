@@ -0,0 +1,115 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestDeltaReporter(t *testing.T) {
+	gh := NewNamedHistogram("get", 3, 10, 0.0)
+	empty := NewNamedHistogram("set", 3, 10, 0.0)
+	hmap := Histograms{"get": gh, "set": empty}
+
+	r := NewDeltaReporter()
+
+	report := func() string {
+		var buf bytes.Buffer
+		if _, err := r.Fprint(&buf, hmap); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	gh.Add(5, 2)
+	gh.Add(15, 1)
+
+	exp := `get (3 Total, +3)
+[0 - 10]             2         +2
+[10 - 20]            1         +1
+set (0 Total, +0)
+(empty)
+`
+	if got := report(); got != exp {
+		t.Errorf("first report, expected:\n%s\ngot:\n%s", exp, got)
+	}
+
+	gh.Add(15, 4)
+	gh.Add(25, 1)
+
+	exp = `get (8 Total, +5)
+[0 - 10]              2         +0
+[10 - 20]             5         +4
+[20 - inf]            1         +1
+set (0 Total, +0)
+(empty)
+`
+	if got := report(); got != exp {
+		t.Errorf("second report, expected:\n%s\ngot:\n%s", exp, got)
+	}
+
+	gh.Reset()
+	gh.Add(5, 1)
+
+	exp = `get (1 Total, +1)
+[0 - 10]            1         +1
+set (0 Total, +0)
+(empty)
+`
+	if got := report(); got != exp {
+		t.Errorf("after reset, expected:\n%s\ngot:\n%s", exp, got)
+	}
+}
+
+func TestDelta(t *testing.T) {
+	gh := NewNamedHistogram("get", 4, 10, 0.0)
+
+	gh.Add(5, 2)
+	gh.Add(15, 1)
+
+	d := gh.Delta()
+	if d.Name != "get" || d.TotCount != 3 || d.Total() != 3 ||
+		!reflect.DeepEqual(d.Counts, []uint64{2, 1, 0, 0}) {
+		t.Errorf("unexpected first delta: %+v", d)
+	}
+
+	d = gh.Delta()
+	if d.TotCount != 0 || d.TotDataPoint != 0 ||
+		!reflect.DeepEqual(d.Counts, []uint64{0, 0, 0, 0}) {
+		t.Errorf("expected an empty delta, got: %+v", d)
+	}
+
+	gh.Add(25, 4)
+	d = gh.Delta()
+	if d.TotCount != 4 || d.TotDataPoint != 25 ||
+		d.MinDataPoint != 5 || d.MaxDataPoint != 25 ||
+		!reflect.DeepEqual(d.Counts, []uint64{0, 0, 4, 0}) {
+		t.Errorf("unexpected delta: %+v", d)
+	}
+
+	gh.Reset()
+	gh.Add(5, 1)
+	d = gh.Delta()
+	if d.TotCount != 1 ||
+		!reflect.DeepEqual(d.Counts, []uint64{1, 0, 0, 0}) {
+		t.Errorf("expected a delta since the reset, got: %+v", d)
+	}
+
+	// Auto ranging merges the bins, which the delta follows.
+	gh.SetAutoRange(0.5, 0)
+	gh.Add(100, 3)
+	d = gh.Delta()
+	if d.TotCount != 3 || !reflect.DeepEqual(gh.Ranges, d.Ranges) ||
+		!reflect.DeepEqual(d.Counts, []uint64{0, 3, 0, 0}) {
+		t.Errorf("unexpected delta after auto ranging, got: %+v, %+v",
+			d, gh)
+	}
+}
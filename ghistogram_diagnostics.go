@@ -0,0 +1,48 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+// Diagnostics holds counts of the events that make the numbers of a
+// histogram less trustworthy, since its creation, see Diagnostics().
+type Diagnostics struct {
+	// Resets is the number of resets of the histogram.
+	Resets uint64
+
+	// DiscardedByReset is the count of data points cleared by resets
+	// before they were copied out by Snapshot(), Clone(), CopyInto()
+	// or SnapshotAndReset(), like those added between a reporter's
+	// Snapshot() and Reset(), which SnapshotAndReset() avoids.
+	DiscardedByReset uint64
+
+	// Clamped is the count of data points beyond the intended range
+	// of the histogram, which were clamped into its catch-all bin.
+	Clamped uint64
+
+	// Dropped is the count of data points that were not recorded,
+	// because they were below the first bin of hand-built Ranges or
+	// beyond the last bin of a histogram without a catch-all bin,
+	// see WithoutCatchAll().
+	Dropped uint64
+
+	// Saturations is the number of times a count, the TotCount or the
+	// TotDataPoint reached math.MaxUint64, see Saturated().
+	Saturations uint64
+}
+
+// Diagnostics returns the counts of dropped, clamped and discarded
+// data points and other events of the histogram since its creation,
+// so operators can tell whether to trust its numbers.  Unlike
+// OverflowCount() and Saturated(), the diagnostics are not cleared by
+// resets, and copies of the histogram, like by Snapshot(), carry them.
+func (gh *Histogram) Diagnostics() Diagnostics {
+	gh.m.Lock()
+	rv := gh.diag
+	gh.m.Unlock()
+	return rv
+}
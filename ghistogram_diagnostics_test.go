@@ -0,0 +1,58 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDiagnostics(t *testing.T) {
+	gh := NewNamedHistogram("get", 3, 10, 2.0)
+
+	gh.Add(5, 2)
+	gh.Add(25, 3) // Clamped into the catch-all bin.
+
+	snapshot := gh.Snapshot()
+	gh.Add(5, 4) // Added between the snapshot and the reset.
+	gh.Reset()
+
+	gh.Add(5, 1)
+	gh.SnapshotAndReset("")
+
+	gh.Add(5, math.MaxUint64) // Saturates the bin and the TotCount.
+
+	exp := Diagnostics{
+		Resets:           2,
+		DiscardedByReset: 4,
+		Clamped:          3,
+		Saturations:      2,
+	}
+	if got := gh.Diagnostics(); got != exp {
+		t.Errorf("got: %+v, exp: %+v", got, exp)
+	}
+
+	if got := snapshot.Diagnostics(); got != (Diagnostics{Clamped: 3}) {
+		t.Errorf("expected the snapshot to carry the diagnostics,"+
+			" got: %+v", got)
+	}
+
+	noCatchAll := NewNamedHistogram("get", 3, 10, 2.0).WithoutCatchAll()
+	noCatchAll.Add(25, 2)
+
+	handBuilt := &Histogram{Ranges: []uint64{10, 20}, Counts: []uint64{0, 0}}
+	handBuilt.Add(5, 3)
+
+	if noCatchAll.Diagnostics().Dropped != 2 ||
+		handBuilt.Diagnostics().Dropped != 3 {
+		t.Errorf("unexpected dropped, without catch-all: %+v,"+
+			" hand-built: %+v",
+			noCatchAll.Diagnostics(), handBuilt.Diagnostics())
+	}
+}
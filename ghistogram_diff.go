@@ -0,0 +1,151 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+// HistogramDiff holds the differences between two captures of a
+// histogram, such as before and after a config change, see Diff().
+type HistogramDiff struct {
+	Name string
+
+	BeforeTotCount uint64
+	AfterTotCount  uint64
+
+	// Bins holds the per-bin counts, when both captures have the same
+	// bins, otherwise it's nil.
+	Bins []BinDiff
+
+	// Percentiles holds the shifts of the 50th, 90th, 99th and 99.9th
+	// percentiles.
+	Percentiles []PercentileShift
+}
+
+// BinDiff holds the counts of a bin in both captures.
+type BinDiff struct {
+	Start, End uint64 // End is math.MaxUint64 for the last bin.
+	Label      string // The bin's EmitGraph() label, like "10 - 20".
+
+	Before, After uint64
+}
+
+// PercentileShift holds a percentile of both captures.
+type PercentileShift struct {
+	Percentile    float64
+	Before, After uint64
+}
+
+// diffPercentiles are the percentiles compared by Diff().
+var diffPercentiles = []float64{50, 90, 99, 99.9}
+
+// Diff compares two captures of a histogram, such as snapshots taken
+// around a config change, bin by bin and by their percentiles.  The
+// name of the diff is the name of the after histogram.
+func Diff(before, after *Histogram) *HistogramDiff {
+	b := before.capture(false)
+	a := after.capture(false)
+
+	d := &HistogramDiff{
+		Name:           a.Name,
+		BeforeTotCount: b.TotCount,
+		AfterTotCount:  a.TotCount,
+	}
+
+	for _, p := range diffPercentiles {
+		d.Percentiles = append(d.Percentiles, PercentileShift{
+			Percentile: p,
+			Before:     b.percentileUNLOCKED(p),
+			After:      a.percentileUNLOCKED(p),
+		})
+	}
+
+	if !sameRanges(b, a) {
+		return d
+	}
+
+	labels := a.binLabelsUNLOCKED()
+	ranges := a.BinRanges()
+
+	d.Bins = make([]BinDiff, len(a.Counts))
+	for i := range a.Counts {
+		d.Bins[i] = BinDiff{
+			Start:  ranges[i][0],
+			End:    ranges[i][1],
+			Label:  labels[i],
+			Before: b.Counts[i],
+			After:  a.Counts[i],
+		}
+	}
+
+	return d
+}
+
+// String returns the diff as text, see Fprint().
+func (d *HistogramDiff) String() string {
+	var out bytes.Buffer
+	d.Fprint(&out)
+	return out.String()
+}
+
+// Fprint emits the diff, showing the count deltas of the bins that
+// are non-empty in either capture and the percentile shifts, through
+// the provided writer, for example:
+//
+//    get (1000 -> 1200 Total, +200)
+//    [0 - 10]          500 ->        520        +20
+//    [10 - 20]         500 ->        680       +180
+//    p50                10 ->         11    +10.00%
+//    p99                19 ->         19     +0.00%
+func (d *HistogramDiff) Fprint(w io.Writer) (int, error) {
+	var out bytes.Buffer
+
+	fmt.Fprintf(&out, "%s (%v -> %v Total, %s)\n", d.Name,
+		d.BeforeTotCount, d.AfterTotCount,
+		countDelta(d.BeforeTotCount, d.AfterTotCount))
+
+	var longestRange int
+	for _, bin := range d.Bins {
+		if (bin.Before > 0 || bin.After > 0) &&
+			utf8.RuneCountInString(bin.Label) > longestRange {
+			longestRange = utf8.RuneCountInString(bin.Label)
+		}
+	}
+
+	for _, bin := range d.Bins {
+		if bin.Before == 0 && bin.After == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&out, "[%s]%*s %12d -> %10d %10s\n", bin.Label,
+			longestRange-utf8.RuneCountInString(bin.Label), "",
+			bin.Before, bin.After, countDelta(bin.Before, bin.After))
+	}
+
+	for _, ps := range d.Percentiles {
+		fmt.Fprintf(&out, "%-*s %12d -> %10d %+9.2f%%\n", longestRange+2,
+			"p"+strconv.FormatFloat(ps.Percentile, 'f', -1, 64),
+			ps.Before, ps.After, percentChange(ps.Before, ps.After))
+	}
+
+	return w.Write(out.Bytes())
+}
+
+// countDelta formats the signed difference of two counts.
+func countDelta(before, after uint64) string {
+	if after < before {
+		return fmt.Sprintf("-%d", before-after)
+	}
+	return fmt.Sprintf("+%d", after-before)
+}
@@ -0,0 +1,54 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	before := NewNamedHistogram("get", 4, 10, 2.0)
+	before.Add(5, 500)
+	before.Add(15, 500)
+
+	after := before.CloneEmpty()
+	after.Add(5, 300)
+	after.Add(15, 600)
+	after.Add(100, 100)
+
+	d := Diff(before, after)
+
+	exp := `get (1000 -> 1000 Total, +0)
+[0 - 10]            500 ->        300       -200
+[10 - 20]           500 ->        600       +100
+[40 - inf]            0 ->        100       +100
+p50                  10 ->         13    +30.00%
+p90                  14 ->         20    +42.86%
+p99                  14 ->         94   +571.43%
+p99.9                14 ->         99   +607.14%
+`
+	if d.String() != exp {
+		t.Errorf("unexpected diff, got:\n%s\nexp:\n%s", d.String(), exp)
+	}
+
+	if len(d.Bins) != 4 || d.Bins[3].End != math.MaxUint64 ||
+		d.Bins[1].Start != 10 || d.Bins[1].Before != 500 {
+		t.Errorf("unexpected bins: %+v", d.Bins)
+	}
+
+	other := NewNamedHistogram("get", 8, 10, 2.0)
+	other.Add(15, 10)
+
+	d = Diff(before, other)
+	if d.Bins != nil || len(d.Percentiles) != 4 ||
+		d.AfterTotCount != 10 {
+		t.Errorf("expected only percentiles for different bins, got: %+v", d)
+	}
+}
@@ -0,0 +1,156 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Emitter renders a histogram in some output format.  The histogram
+// passed to an Emitter is a private snapshot, see Histogram.Snapshot(),
+// so the Emitter may read its fields directly.
+type Emitter interface {
+	Emit(w io.Writer, gh *Histogram) error
+}
+
+// EmitterFunc adapts a function to the Emitter interface.
+type EmitterFunc func(w io.Writer, gh *Histogram) error
+
+// Emit invokes the function.
+func (f EmitterFunc) Emit(w io.Writer, gh *Histogram) error {
+	return f(w, gh)
+}
+
+// The built-in output formats.
+const (
+	FormatASCII    = "ascii"    // The EmitGraph() ASCII graph.
+	FormatCSV      = "csv"      // A row per bin, see WriteCSV().
+	FormatJSON     = "json"     // The JSON encoding, see MarshalJSON().
+	FormatMarkdown = "markdown" // A Markdown table of the non-empty bins.
+	FormatHTML     = "html"     // An HTML table, see emitHTML().
+	FormatCBStats  = "cbstats"  // The "cbstats timings" layout.
+)
+
+var emittersM sync.RWMutex
+
+var emitters = map[string]Emitter{
+	FormatASCII:    EmitterFunc(emitASCII),
+	FormatCSV:      EmitterFunc(emitCSV),
+	FormatJSON:     EmitterFunc(emitJSON),
+	FormatMarkdown: EmitterFunc(emitMarkdown),
+	FormatHTML:     EmitterFunc(emitHTML),
+	FormatCBStats:  EmitterFunc(emitCBStats),
+}
+
+// RegisterEmitter makes an Emitter available under the format name,
+// replacing any Emitter already registered under that name.
+func RegisterEmitter(format string, e Emitter) {
+	emittersM.Lock()
+	emitters[format] = e
+	emittersM.Unlock()
+}
+
+// Emit renders the histogram through the writer in the given format,
+// which is one of the Format constants or a registered format, see
+// RegisterEmitter().
+func (gh *Histogram) Emit(format string, w io.Writer) error {
+	emittersM.RLock()
+	e := emitters[format]
+	emittersM.RUnlock()
+
+	if e == nil {
+		return fmt.Errorf("ghistogram: Emit, unknown format: %q", format)
+	}
+
+	return e.Emit(w, gh.Snapshot())
+}
+
+// Emit renders every histogram of the map, in name order, through the
+// writer in the given format, see Histogram.Emit().  The renderings
+// are simply concatenated, so JSON is emitted as a JSON object per
+// line.
+func (hmap Histograms) Emit(format string, w io.Writer) error {
+	for _, name := range hmap.SortedNames(nil) {
+		if err := hmap[name].Emit(format, w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func emitASCII(w io.Writer, gh *Histogram) error {
+	_, err := w.Write(gh.EmitGraph(nil, nil).Bytes())
+	return err
+}
+
+func emitJSON(w io.Writer, gh *Histogram) error {
+	b, err := json.Marshal(gh)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+func emitCSV(w io.Writer, gh *Histogram) error {
+	return gh.WriteCSV(w)
+}
+
+// markdownBarWidth is the number of block characters of the bar of
+// the fullest bin in the Markdown table.
+const markdownBarWidth = 20
+
+func emitMarkdown(w io.Writer, gh *Histogram) error {
+	_, err := fmt.Fprintf(w, "**%s** (%v Total)\n\n"+
+		"| Range | Count | Percent | Cumulative | |\n"+
+		"|-------|------:|--------:|-----------:|-|\n", gh.Name, gh.TotCount)
+	if err != nil {
+		return err
+	}
+
+	bins := gh.binLabelsUNLOCKED()
+	maxCount := maxBinCount(gh.Counts)
+
+	var runCount uint64
+	for i, c := range gh.Counts {
+		if c == 0 {
+			continue
+		}
+
+		runCount += c
+		p := percentHundredths(c, gh.TotCount)
+		pRun := percentHundredths(runCount, gh.TotCount)
+		barWant := int(mulDiv(c, markdownBarWidth, maxCount))
+
+		_, err = fmt.Fprintf(w, "| %s | %d | %d.%02d%% | %d.%02d%% | %s |\n",
+			bins[i], c, p/100, p%100, pRun/100, pRun%100,
+			strings.Repeat("█", barWant))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func maxBinCount(counts []uint64) uint64 {
+	var rv uint64
+	for _, c := range counts {
+		if rv < c {
+			rv = c
+		}
+	}
+	return rv
+}
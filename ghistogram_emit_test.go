@@ -0,0 +1,76 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func TestEmit(t *testing.T) {
+	gh := NewNamedHistogram("get", 3, 10, 0.0)
+	gh.Add(5, 1)
+	gh.Add(25, 3)
+
+	tests := []struct {
+		format string
+		exp    string
+	}{
+		{FormatASCII, gh.EmitGraph(nil, nil).String()},
+		{FormatJSON, `{"Name":"get","Ranges":[0,10,20],"Counts":[1,0,3],` +
+			`"TotCount":4,"TotDataPoint":30,"MinDataPoint":5,` +
+			`"MaxDataPoint":25}
+`},
+		{FormatMarkdown, `**get** (4 Total)
+
+| Range | Count | Percent | Cumulative | |
+|-------|------:|--------:|-----------:|-|
+| 0 - 10 | 1 | 25.00% | 25.00% | ██████ |
+| 20 - inf | 3 | 75.00% | 100.00% | ████████████████████ |
+`},
+	}
+
+	for testi, test := range tests {
+		var buf bytes.Buffer
+		if err := gh.Emit(test.format, &buf); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != test.exp {
+			t.Errorf("test #%d, format: %s, expected:\n%s\ngot:\n%s",
+				testi, test.format, test.exp, buf.String())
+		}
+	}
+
+	if err := gh.Emit("nope", ioutil.Discard); err == nil {
+		t.Errorf("expected error for unknown format")
+	}
+}
+
+func TestRegisterEmitter(t *testing.T) {
+	RegisterEmitter("total", EmitterFunc(func(w io.Writer,
+		gh *Histogram) error {
+		_, err := fmt.Fprintf(w, "%s=%d\n", gh.Name, gh.TotCount)
+		return err
+	}))
+
+	hmap, _, _ := initAndFetchHistograms(t)
+
+	var buf bytes.Buffer
+	if err := hmap.Emit("total", &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "test1 (µs)=6\ntest2 (µs)=4\n"
+	if buf.String() != exp {
+		t.Errorf("expected: %q, got: %q", exp, buf.String())
+	}
+}
@@ -0,0 +1,60 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// epEngineBin is a bin of the ep-engine and memcached histogram stats.
+type epEngineBin struct {
+	Lower uint64 `json:"lower"`
+	Upper uint64 `json:"upper"`
+	Count uint64 `json:"count"`
+}
+
+// ParseEPEngineJSON decodes the JSON of an ep-engine or memcached
+// histogram stat, which is an array of bins, into a new histogram of
+// the given name, so KV-engine timings may be merged and graphed
+// alongside Go-side histograms, for example:
+//
+//    [{"lower":0,"upper":10,"count":3},{"lower":10,"upper":20,"count":5}]
+//
+// The bins may be in any order, but must not overlap.  Like
+// ParseGraph(), a gap between two bins becomes a single empty bin, a
+// catch-all bin is appended unless the last bin ends at
+// math.MaxUint64, TotDataPoint is 0, and the min and max data points
+// are estimated from the bounds of the first and last non-empty bins.
+func ParseEPEngineJSON(name string, r io.Reader) (*Histogram, error) {
+	var bins []epEngineBin
+	if err := json.NewDecoder(r).Decode(&bins); err != nil {
+		return nil, fmt.Errorf("ghistogram: ParseEPEngineJSON, err: %v", err)
+	}
+
+	sort.SliceStable(bins, func(i, j int) bool {
+		return bins[i].Lower < bins[j].Lower
+	})
+
+	p := &graphParser{name: name}
+
+	for _, b := range bins {
+		inf := b.Upper == math.MaxUint64
+		if err := p.add(b.Lower, b.Upper, inf, b.Count); err != nil {
+			return nil, fmt.Errorf("ghistogram: ParseEPEngineJSON, %v", err)
+		}
+
+		p.totCount += b.Count
+	}
+
+	return p.histogram()
+}
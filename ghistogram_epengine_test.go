@@ -0,0 +1,73 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseEPEngineJSON(t *testing.T) {
+	tests := []struct {
+		in        string
+		expRanges []uint64
+		expCounts []uint64
+		expMin    uint64
+		expMax    uint64
+		expErr    string
+	}{
+		{in: `[{"lower":10,"upper":20,"count":5},` +
+			`{"lower":0,"upper":10,"count":3},` +
+			`{"lower":40,"upper":80,"count":1}]`,
+			expRanges: []uint64{0, 10, 20, 40, 80},
+			expCounts: []uint64{3, 5, 0, 1, 0},
+			expMin:    0, expMax: 79},
+		{in: `[{"lower":5,"upper":10,"count":2},` +
+			`{"lower":10,"upper":18446744073709551615,"count":1}]`,
+			expRanges: []uint64{0, 5, 10},
+			expCounts: []uint64{0, 2, 1},
+			expMin:    5, expMax: 10},
+		{in: `[]`,
+			expRanges: []uint64{0},
+			expCounts: []uint64{0},
+			expMin:    18446744073709551615},
+		{in: `[{"lower":0,"upper":10,"count":1},{"lower":5,"upper":20,"count":1}]`,
+			expErr: "out of order"},
+		{in: `[{"lower":10,"upper":10,"count":1}]`,
+			expErr: "invalid end"},
+		{in: `{"lower":0}`,
+			expErr: "cannot unmarshal"},
+	}
+
+	for testi, test := range tests {
+		gh, err := ParseEPEngineJSON("get_cmd", strings.NewReader(test.in))
+		if test.expErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.expErr) {
+				t.Errorf("test #%d, expected err %q, got: %v",
+					testi, test.expErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test #%d, err: %v", testi, err)
+		}
+
+		if gh.Name != "get_cmd" ||
+			!reflect.DeepEqual(gh.Ranges, test.expRanges) ||
+			!reflect.DeepEqual(gh.Counts, test.expCounts) ||
+			gh.MinDataPoint != test.expMin || gh.MaxDataPoint != test.expMax {
+			t.Errorf("test #%d, unexpected histogram: %+v", testi, gh)
+		}
+
+		if err = gh.Validate(); err != nil {
+			t.Errorf("test #%d, err: %v", testi, err)
+		}
+	}
+}
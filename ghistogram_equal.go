@@ -0,0 +1,62 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+// SameLayout returns true when the other histogram has the same bins,
+// meaning the same Ranges and bin boundary convention, so it may be
+// combined with this histogram via AddAll().
+func (gh *Histogram) SameLayout(other *Histogram) bool {
+	rv := false
+	gh.withBothLocked(other, func() {
+		rv = sameRanges(gh, other)
+	})
+	return rv
+}
+
+// Equal returns true when the other histogram has the same layout,
+// see SameLayout(), and the same counts and data point statistics.
+// The names and settings of the histograms, such as their Rounding,
+// are not compared.
+func (gh *Histogram) Equal(other *Histogram) bool {
+	rv := false
+	gh.withBothLocked(other, func() {
+		if !sameRanges(gh, other) ||
+			gh.TotCount != other.TotCount ||
+			gh.TotDataPoint != other.TotDataPoint ||
+			gh.MinDataPoint != other.MinDataPoint ||
+			gh.MaxDataPoint != other.MaxDataPoint {
+			return
+		}
+
+		for i, c := range gh.Counts {
+			if other.Counts[i] != c {
+				return
+			}
+		}
+
+		rv = true
+	})
+	return rv
+}
+
+// withBothLocked invokes f while holding the locks of both histograms,
+// which may be the same histogram.
+func (gh *Histogram) withBothLocked(other *Histogram, f func()) {
+	gh.m.Lock()
+	if other != gh {
+		other.m.Lock()
+	}
+
+	f()
+
+	if other != gh {
+		other.m.Unlock()
+	}
+	gh.m.Unlock()
+}
@@ -0,0 +1,56 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"testing"
+)
+
+func TestEqualAndSameLayout(t *testing.T) {
+	gh := NewNamedHistogram("a", 4, 10, 2.0)
+	gh.Add(5, 2)
+	gh.Add(50, 3)
+
+	clone := gh.Clone()
+	clone.Name = "b"
+
+	closed := gh.Clone()
+	closed.SetBinBoundary(RightClosed)
+
+	moreAdded := gh.Clone()
+	moreAdded.Add(5, 1)
+
+	tests := []struct {
+		other         *Histogram
+		expSameLayout bool
+		expEqual      bool
+	}{
+		{gh, true, true},
+		{clone, true, true},
+		{gh.CloneEmpty(), true, false},
+		{moreAdded, true, false},
+		{closed, false, false},
+		{NewNamedHistogram("a", 4, 10, 0), false, false},
+		{NewNamedHistogram("a", 5, 10, 2.0), false, false},
+	}
+
+	for testi, test := range tests {
+		if gh.SameLayout(test.other) != test.expSameLayout {
+			t.Errorf("test #%d, expected SameLayout %v",
+				testi, test.expSameLayout)
+		}
+		if gh.Equal(test.other) != test.expEqual {
+			t.Errorf("test #%d, expected Equal %v", testi, test.expEqual)
+		}
+		if test.other.Equal(gh) != test.expEqual {
+			t.Errorf("test #%d, expected symmetric Equal %v",
+				testi, test.expEqual)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A saved histograms file starts with the savedFileMagic header line,
+// which carries the version of the format, followed by the JSON
+// encoding of the Histograms map, see MarshalJSON().
+
+const savedFileMagic = "ghistogram-file-v1\n"
+
+// savedFilePrefix is the version independent start of the header.
+const savedFilePrefix = "ghistogram-file-v"
+
+// SaveFile saves the histograms to the file at path, so they survive
+// process restarts, see LoadFile(), or can be shipped in support
+// bundles.  The file is written to a temporary file that's then
+// renamed, so readers and crashes never see a partially written file.
+func (hmap Histograms) SaveFile(path string) error {
+	j, err := json.Marshal(hmap)
+	if err != nil {
+		return err
+	}
+
+	data := make([]byte, 0, len(savedFileMagic)+len(j)+1)
+	data = append(data, savedFileMagic...)
+	data = append(data, j...)
+	data = append(data, '\n')
+
+	return writeFileAtomic(path, data)
+}
+
+// LoadFile loads the histograms of a file saved by SaveFile().
+func LoadFile(path string) (Histograms, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(data, []byte(savedFileMagic)) {
+		if bytes.HasPrefix(data, []byte(savedFilePrefix)) {
+			version := strings.SplitN(string(data), "\n", 2)[0]
+			return nil, fmt.Errorf("ghistogram: LoadFile, path: %s,"+
+				" unsupported version: %q", path, version)
+		}
+		return nil, fmt.Errorf("ghistogram: LoadFile, path: %s,"+
+			" not a saved histograms file", path)
+	}
+
+	var hmap Histograms
+	err = json.Unmarshal(data[len(savedFileMagic):], &hmap)
+	if err != nil {
+		return nil, fmt.Errorf("ghistogram: LoadFile, path: %s, err: %v",
+			path, err)
+	}
+	return hmap, nil
+}
+
+// writeFileAtomic writes the data to a temporary file next to path,
+// syncs it and then renames it to path.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path),
+		"."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+
+	return err
+}
@@ -0,0 +1,78 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveLoadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ghistogram")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	hmap, _, _ := initAndFetchHistograms(t)
+
+	path := filepath.Join(dir, "stats.ghist")
+
+	// Saving twice replaces the file without leaving temporary files.
+	for i := 0; i < 2; i++ {
+		if err = hmap.SaveFile(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, _ := ioutil.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected only the saved file, got: %d", len(entries))
+	}
+
+	loaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.String() != hmap.String() {
+		t.Errorf("unexpected loaded histograms, got:\n%s\nexp:\n%s",
+			loaded, hmap)
+	}
+
+	tests := []struct {
+		data   string
+		expErr string
+	}{
+		{"{}", "not a saved histograms file"},
+		{"ghistogram-file-v2\n{}", `unsupported version: "ghistogram-file-v2"`},
+		{"ghistogram-file-v1\n{bad", "err: "},
+	}
+
+	for testi, test := range tests {
+		ioutil.WriteFile(path, []byte(test.data), 0644)
+
+		_, err = LoadFile(path)
+		if err == nil || !strings.Contains(err.Error(), test.expErr) {
+			t.Errorf("test #%d, expected err %q, got: %v",
+				testi, test.expErr, err)
+		}
+	}
+
+	if _, err = LoadFile(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("expected err for a missing file")
+	}
+
+	if err = hmap.SaveFile(filepath.Join(dir, "no", "dir")); err == nil {
+		t.Errorf("expected err for a missing directory")
+	}
+}
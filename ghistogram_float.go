@@ -0,0 +1,228 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// FloatHistogram is the float64 counterpart of Histogram, for data
+// points such as ratios or sub-microsecond timings where uint64 data
+// points lose all resolution.  Like Histogram, it avoids heap
+// allocations when adding data points and is concurrent safe.
+type FloatHistogram struct {
+	// Histogram name.
+	Name string
+
+	// Ranges holds the lower domain bounds of bins, so bin i has data
+	// point domain of "[Ranges[i], Ranges[i+1])".  Related,
+	// Ranges[0] == 0.0 and Ranges[1] == binFirst.
+	Ranges []float64
+
+	// Counts holds the event counts for bins.
+	Counts []uint64
+
+	// TotCount is the sum of all counts.
+	TotCount uint64
+
+	TotDataPoint float64 // TotDataPoint is the sum of all data points.
+	MinDataPoint float64 // MinDataPoint is the smallest data point seen.
+	MaxDataPoint float64 // MaxDataPoint is the largest data point seen.
+
+	m sync.Mutex
+}
+
+// NewFloatHistogram creates a new, ready to use FloatHistogram.  The
+// numBins must be >= 2.  The binFirst is the width of the first bin.
+// The binGrowthFactor must be > 1.0 or 0.0, where 0.0 means constant
+// bin widths.  Unlike NewNamedHistogram(), the bin boundaries are not
+// rounded up to integers.
+func NewFloatHistogram(
+	name string,
+	numBins int,
+	binFirst float64,
+	binGrowthFactor float64) *FloatHistogram {
+	fh := &FloatHistogram{
+		Name:         name,
+		Ranges:       make([]float64, numBins),
+		Counts:       make([]uint64, numBins),
+		MinDataPoint: math.Inf(1),
+		MaxDataPoint: math.Inf(-1),
+	}
+
+	fh.Ranges[0] = 0
+	fh.Ranges[1] = binFirst
+
+	for i := 2; i < len(fh.Ranges); i++ {
+		if binGrowthFactor == 0.0 {
+			fh.Ranges[i] = fh.Ranges[i-1] + binFirst
+		} else {
+			fh.Ranges[i] = binGrowthFactor * fh.Ranges[i-1]
+		}
+	}
+
+	return fh
+}
+
+// Add increases the count in the bin for the given dataPoint in a
+// concurrent-safe manner.  Data points below Ranges[0] and NaN's are
+// ignored.
+func (fh *FloatHistogram) Add(dataPoint float64, count uint64) {
+	fh.m.Lock()
+	fh.addUNLOCKED(dataPoint, count)
+	fh.m.Unlock()
+}
+
+func (fh *FloatHistogram) addUNLOCKED(dataPoint float64, count uint64) {
+	idx := searchFloat(fh.Ranges, dataPoint)
+	if idx >= 0 {
+		fh.Counts[idx] += count
+		fh.TotCount += count
+
+		fh.TotDataPoint += dataPoint
+		if fh.MinDataPoint > dataPoint {
+			fh.MinDataPoint = dataPoint
+		}
+		if fh.MaxDataPoint < dataPoint {
+			fh.MaxDataPoint = dataPoint
+		}
+	}
+}
+
+// Finds the last arr index where the arr entry <= dataPoint.
+func searchFloat(arr []float64, dataPoint float64) int {
+	i, j := 0, len(arr)
+
+	for i < j {
+		h := i + (j-i)/2 // Avoids h overflow, where i <= h < j.
+		if dataPoint >= arr[h] {
+			i = h + 1
+		} else {
+			j = h
+		}
+	}
+
+	return i - 1
+}
+
+// AddAll adds all the Counts from the src histogram into this
+// histogram.  The src and this histogram must have the same exact
+// creation parameters.
+func (fh *FloatHistogram) AddAll(src *FloatHistogram) {
+	src.m.Lock()
+	fh.m.Lock()
+
+	for i := 0; i < len(src.Counts); i++ {
+		fh.Counts[i] += src.Counts[i]
+	}
+	fh.TotCount += src.TotCount
+
+	fh.TotDataPoint += src.TotDataPoint
+	if fh.MinDataPoint > src.MinDataPoint {
+		fh.MinDataPoint = src.MinDataPoint
+	}
+	if fh.MaxDataPoint < src.MaxDataPoint {
+		fh.MaxDataPoint = src.MaxDataPoint
+	}
+
+	fh.m.Unlock()
+	src.m.Unlock()
+}
+
+// Reset clears all the counts and data point statistics of the
+// histogram, keeping its name and bin ranges.
+func (fh *FloatHistogram) Reset() {
+	fh.m.Lock()
+	for i := range fh.Counts {
+		fh.Counts[i] = 0
+	}
+	fh.TotCount = 0
+
+	fh.TotDataPoint = 0
+	fh.MinDataPoint = math.Inf(1)
+	fh.MaxDataPoint = math.Inf(-1)
+	fh.m.Unlock()
+}
+
+// Percentile returns an estimate of the data point at the given
+// percentile, see Histogram.Percentile().
+func (fh *FloatHistogram) Percentile(p float64) float64 {
+	fh.m.Lock()
+	defer fh.m.Unlock()
+
+	if fh.TotCount == 0 {
+		return 0
+	}
+
+	if p <= 0 {
+		return fh.MinDataPoint
+	}
+	if p >= 100 {
+		return fh.MaxDataPoint
+	}
+
+	rank := p / 100 * float64(fh.TotCount)
+
+	var runCount uint64
+	for i, c := range fh.Counts {
+		if c == 0 || float64(runCount+c) < rank {
+			runCount += c
+			continue
+		}
+
+		lower := math.Max(fh.Ranges[i], fh.MinDataPoint)
+
+		upper := fh.MaxDataPoint
+		if i < len(fh.Ranges)-1 {
+			upper = math.Min(fh.Ranges[i+1], upper)
+		}
+
+		if upper <= lower {
+			return lower
+		}
+
+		frac := (rank - float64(runCount)) / float64(c)
+
+		return lower + frac*(upper-lower)
+	}
+
+	return fh.MaxDataPoint
+}
+
+// EmitGraph emits an ascii graph to the optional out buffer, see
+// Histogram.EmitGraph().
+func (fh *FloatHistogram) EmitGraph(prefix []byte,
+	out *bytes.Buffer) *bytes.Buffer {
+	fh.m.Lock()
+	defer fh.m.Unlock()
+
+	countsN := len(fh.Counts)
+
+	if out == nil {
+		out = bytes.NewBuffer(make([]byte, 0, 80*countsN))
+	}
+
+	bins := make([]string, countsN)
+	for i := range bins {
+		if i < countsN-1 {
+			bins[i] = fmt.Sprintf("%v - %v", fh.Ranges[i], fh.Ranges[i+1])
+		} else {
+			bins[i] = fmt.Sprintf("%v - inf", fh.Ranges[i])
+		}
+	}
+
+	fmt.Fprintf(out, "%s (%v Total)\n", fh.Name, fh.TotCount)
+
+	emitBins(prefix, out, bins, fh.Counts, fh.TotCount)
+
+	return out
+}
@@ -0,0 +1,64 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFloatHistogram(t *testing.T) {
+	// Bins will look like: {0, 0.25, 0.5, 1, 2}.
+	fh := NewFloatHistogram("TestFloatHistogram", 5, 0.25, 2.0)
+
+	fh.Add(0.1, 1)
+	fh.Add(0.3, 2)
+	fh.Add(0.75, 4)
+	fh.Add(5, 1)
+	fh.Add(-1, 1)
+	fh.Add(math.NaN(), 1)
+
+	exp := []uint64{1, 2, 4, 0, 1}
+	for i := range exp {
+		if fh.Counts[i] != exp[i] {
+			t.Errorf("actual (%v) != exp (%v)", fh.Counts, exp)
+		}
+	}
+
+	if fh.MinDataPoint != 0.1 || fh.MaxDataPoint != 5 || fh.TotCount != 8 {
+		t.Errorf("unexpected data point stats")
+	}
+
+	if p := fh.Percentile(50); p != 0.625 {
+		t.Errorf("Percentile(50) wrong, got: %v", p)
+	}
+
+	expGraph := `TestFloatHistogram (8 Total)
+[0 - 0.25]     12.50%   12.50% ####### (1)
+[0.25 - 0.5]   25.00%   37.50% ############### (2)
+[0.5 - 1]      50.00%   87.50% ############################## (4)
+[2 - inf]      12.50%  100.00% ####### (1)
+`
+	if got := fh.EmitGraph(nil, nil).String(); got != expGraph {
+		t.Errorf("didn't get expected graph,\ngot: %s\nexp: %s",
+			got, expGraph)
+	}
+
+	fh2 := NewFloatHistogram("fh2", 5, 0.25, 2.0)
+	fh2.AddAll(fh)
+	fh2.AddAll(fh)
+	if fh2.TotCount != 16 || fh2.Counts[2] != 8 || fh2.MinDataPoint != 0.1 {
+		t.Errorf("AddAll wrong")
+	}
+
+	fh2.Reset()
+	if fh2.TotCount != 0 || fh2.Counts[2] != 0 || fh2.Percentile(50) != 0 {
+		t.Errorf("Reset wrong")
+	}
+}
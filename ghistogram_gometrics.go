@@ -0,0 +1,163 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+)
+
+// MetricsHistogram adapts a Histogram to the method set of the
+// github.com/rcrowley/go-metrics Histogram interface, so code that's
+// standardized on go-metrics can record into a fixed-size, allocation
+// free histogram instead of a sample.  The go-metrics Sample() method,
+// and the go-metrics return type of Snapshot(), require importing
+// go-metrics, so a wrapper in the importing code completes the
+// interface, for example:
+//
+//    type metricsHistogram struct{ *ghistogram.MetricsHistogram }
+//
+//    func (h metricsHistogram) Sample() metrics.Sample {
+//        return metrics.NilSample{}
+//    }
+//
+//    func (h metricsHistogram) Snapshot() metrics.Histogram {
+//        return metricsHistogram{h.MetricsHistogram.Snapshot()}
+//    }
+//
+// Sum() and Mean() are exact as long as the histogram is only updated
+// through Update(), while the percentiles, StdDev() and Variance() are
+// estimated from the bins.  The MetricsHistogram is concurrent safe.
+type MetricsHistogram struct {
+	gh       *Histogram
+	snapshot bool
+}
+
+// NewMetricsHistogram returns a MetricsHistogram that records into
+// the histogram.
+func NewMetricsHistogram(gh *Histogram) *MetricsHistogram {
+	return &MetricsHistogram{gh: gh}
+}
+
+// Histogram returns the histogram of the MetricsHistogram.
+func (h *MetricsHistogram) Histogram() *Histogram {
+	return h.gh
+}
+
+// Clear resets the histogram.
+func (h *MetricsHistogram) Clear() {
+	if h.snapshot {
+		panic("ghistogram: Clear called on a MetricsHistogram snapshot")
+	}
+	h.gh.Reset()
+}
+
+// Update records a value, where negative values are recorded as 0.
+func (h *MetricsHistogram) Update(v int64) {
+	if h.snapshot {
+		panic("ghistogram: Update called on a MetricsHistogram snapshot")
+	}
+	if v < 0 {
+		v = 0
+	}
+	h.gh.Add(uint64(v), 1)
+}
+
+// Snapshot returns a read-only copy of the MetricsHistogram.
+func (h *MetricsHistogram) Snapshot() *MetricsHistogram {
+	return &MetricsHistogram{gh: h.gh.Snapshot(), snapshot: true}
+}
+
+// Count returns the number of recorded values.
+func (h *MetricsHistogram) Count() int64 {
+	return toInt64(h.gh.Total())
+}
+
+// Min returns the smallest recorded value, or 0 when empty.
+func (h *MetricsHistogram) Min() int64 {
+	h.gh.m.Lock()
+	defer h.gh.m.Unlock()
+
+	if h.gh.TotCount == 0 {
+		return 0
+	}
+	return toInt64(h.gh.MinDataPoint)
+}
+
+// Max returns the largest recorded value.
+func (h *MetricsHistogram) Max() int64 {
+	h.gh.m.Lock()
+	rv := toInt64(h.gh.MaxDataPoint)
+	h.gh.m.Unlock()
+	return rv
+}
+
+// Sum returns the sum of the recorded values.
+func (h *MetricsHistogram) Sum() int64 {
+	h.gh.m.Lock()
+	rv := toInt64(h.gh.TotDataPoint)
+	h.gh.m.Unlock()
+	return rv
+}
+
+// Mean returns the mean of the recorded values, or 0 when empty.
+func (h *MetricsHistogram) Mean() float64 {
+	h.gh.m.Lock()
+	rv := h.meanUNLOCKED()
+	h.gh.m.Unlock()
+	return rv
+}
+
+func (h *MetricsHistogram) meanUNLOCKED() float64 {
+	if h.gh.TotCount == 0 {
+		return 0
+	}
+	return float64(h.gh.TotDataPoint) / float64(h.gh.TotCount)
+}
+
+// Variance returns an estimate of the variance of the values.
+func (h *MetricsHistogram) Variance() float64 {
+	h.gh.m.Lock()
+	rv := h.gh.varianceUNLOCKED(h.meanUNLOCKED())
+	h.gh.m.Unlock()
+	return rv
+}
+
+// StdDev returns an estimate of the standard deviation of the values.
+func (h *MetricsHistogram) StdDev() float64 {
+	return math.Sqrt(h.Variance())
+}
+
+// Percentile returns an estimate of the value at the percentile p,
+// which is a fraction in the range of [0.0, 1.0] as in go-metrics,
+// rather than the [0.0, 100.0] of Histogram.Percentile().
+func (h *MetricsHistogram) Percentile(p float64) float64 {
+	return float64(h.gh.Percentile(p * 100))
+}
+
+// Percentiles returns the estimates of the values at the percentiles,
+// see Percentile(), from a consistent view of the histogram.
+func (h *MetricsHistogram) Percentiles(ps []float64) []float64 {
+	rv := make([]float64, len(ps))
+
+	h.gh.m.Lock()
+	for i, p := range ps {
+		rv[i] = float64(h.gh.percentileUNLOCKED(p * 100))
+	}
+	h.gh.m.Unlock()
+
+	return rv
+}
+
+// toInt64 converts v, saturating at math.MaxInt64.
+func toInt64(v uint64) int64 {
+	if v > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(v)
+}
@@ -0,0 +1,82 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMetricsHistogram(t *testing.T) {
+	h := NewMetricsHistogram(NewNamedHistogram("get", 20, 1, 2.0))
+
+	if h.Count() != 0 || h.Min() != 0 || h.Max() != 0 || h.Mean() != 0 ||
+		h.StdDev() != 0 || h.Percentile(0.5) != 0 {
+		t.Errorf("expected zero stats when empty")
+	}
+
+	for _, v := range []int64{-5, 10, 20, 30, 40} {
+		h.Update(v)
+	}
+
+	snap := h.Snapshot()
+	h.Update(1000)
+
+	tests := []struct {
+		name     string
+		got, exp interface{}
+	}{
+		{"count", snap.Count(), int64(5)},
+		{"min", snap.Min(), int64(0)},
+		{"max", snap.Max(), int64(40)},
+		{"sum", snap.Sum(), int64(100)},
+		{"mean", snap.Mean(), 20.0},
+		{"p0", snap.Percentile(0), 0.0},
+		{"p100", snap.Percentile(1), 40.0},
+		{"percentiles", snap.Percentiles([]float64{0, 1}), []float64{0, 40}},
+		{"live count", h.Count(), int64(6)},
+		{"live max", h.Max(), int64(1000)},
+	}
+
+	for _, test := range tests {
+		if !reflect.DeepEqual(test.got, test.exp) {
+			t.Errorf("%s, expected: %v, got: %v", test.name, test.exp, test.got)
+		}
+	}
+
+	if v := snap.Variance(); v <= 0 || math.Abs(math.Sqrt(v)-snap.StdDev()) > 1e-9 {
+		t.Errorf("unexpected variance: %v, stddev: %v", v, snap.StdDev())
+	}
+
+	if p50 := snap.Percentile(0.5); p50 < 10 || p50 > 30 {
+		t.Errorf("unexpected p50: %v", p50)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic on Update of a snapshot")
+		}
+	}()
+	snap.Update(1)
+}
+
+func TestMetricsHistogramClear(t *testing.T) {
+	h := NewMetricsHistogram(NewNamedHistogram("get", 20, 1, 2.0))
+	h.Update(math.MaxInt64)
+	h.Update(math.MaxInt64)
+	if h.Sum() != math.MaxInt64 {
+		t.Errorf("expected saturated sum, got: %d", h.Sum())
+	}
+
+	h.Clear()
+	if h.Count() != 0 || h.Histogram().TotCount != 0 {
+		t.Errorf("expected cleared histogram")
+	}
+}
@@ -0,0 +1,78 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// grafanaSeries is a time series in the JSON datasource format, where
+// each datapoint is a [value, unix milliseconds] pair.
+type grafanaSeries struct {
+	Target     string      `json:"target"`
+	Datapoints [][2]uint64 `json:"datapoints"`
+}
+
+// WriteGrafanaHeatmap writes a time ordered series of windows, such
+// as a history of interval snapshots, as the JSON consumed by
+// Grafana's heatmap panel with the "time series buckets" data format,
+// for example:
+//
+//    [{"target":"10","datapoints":[[3,1483272060000],[0,1483272120000]]},
+//     {"target":"20","datapoints":[[5,1483272060000],[2,1483272120000]]},
+//     {"target":"+Inf","datapoints":[[1,1483272060000],[4,1483272120000]]}]
+//
+// There's a series per bin, whose target is the bin's upper bound, or
+// "le", with "+Inf" for the last bin, and a datapoint per window with
+// the bin's count, which is not cumulative, at the window's end time.
+// The windows' histograms must have the same bins.
+func WriteGrafanaHeatmap(w io.Writer, windows []Window) error {
+	series := []grafanaSeries{}
+
+	var first *Histogram
+
+	for j, win := range windows {
+		s := win.Histogram.Snapshot()
+
+		if first == nil {
+			first = s
+			for i := range s.Counts {
+				target := "+Inf"
+				if i < len(s.Counts)-1 {
+					target = strconv.FormatUint(
+						s.rangeLabel(s.Ranges[i+1]), 10)
+				}
+				series = append(series, grafanaSeries{
+					Target:     target,
+					Datapoints: make([][2]uint64, 0, len(windows)),
+				})
+			}
+		} else if !sameRanges(first, s) {
+			return fmt.Errorf("ghistogram: WriteGrafanaHeatmap,"+
+				" window %d has different bins", j)
+		}
+
+		ts := uint64(win.End.UnixNano() / 1e6)
+		for i, c := range s.Counts {
+			series[i].Datapoints = append(series[i].Datapoints,
+				[2]uint64{c, ts})
+		}
+	}
+
+	b, err := json.Marshal(series)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,57 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestWriteGrafanaHeatmap(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteGrafanaHeatmap(&buf, nil); err != nil ||
+		buf.String() != "[]\n" {
+		t.Errorf("expected empty array, got: %q, err: %v", buf.String(), err)
+	}
+
+	start := time.Unix(1483272000, 0)
+
+	var windows []Window
+	for i := 0; i < 2; i++ {
+		gh := NewHistogram(3, 10, 0.0)
+		gh.Add(5, uint64(3-3*i))
+		gh.Add(15, uint64(5-3*i))
+		gh.Add(25, uint64(1+3*i))
+
+		windows = append(windows, Window{
+			Start:     start.Add(time.Duration(i) * time.Minute),
+			End:       start.Add(time.Duration(i+1) * time.Minute),
+			Histogram: gh,
+		})
+	}
+
+	buf.Reset()
+	if err := WriteGrafanaHeatmap(&buf, windows); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := `[{"target":"10","datapoints":[[3,1483272060000],[0,1483272120000]]},` +
+		`{"target":"20","datapoints":[[5,1483272060000],[2,1483272120000]]},` +
+		`{"target":"+Inf","datapoints":[[1,1483272060000],[4,1483272120000]]}]
+`
+	if buf.String() != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, buf.String())
+	}
+
+	windows[1].Histogram = NewHistogram(4, 10, 0.0)
+	if err := WriteGrafanaHeatmap(&buf, windows); err == nil {
+		t.Errorf("expected error for mismatched bins")
+	}
+}
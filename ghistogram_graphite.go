@@ -0,0 +1,71 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// EmitGraphite writes the histogram in the graphite plaintext
+// protocol, as a "<prefix>.bin_<start> <count> <timestamp>" line for
+// every bin followed by the summary stats of Histogram.Stats().  An
+// empty prefix gives the bare names, like "bin_<start>".
+func (gh *Histogram) EmitGraphite(w io.Writer, prefix string,
+	ts time.Time) error {
+	bw := bufio.NewWriter(w)
+
+	gh.m.Lock()
+	gh.emitGraphiteUNLOCKED(bw, metricName(prefix), ts.Unix())
+	gh.m.Unlock()
+
+	return bw.Flush()
+}
+
+// EmitGraphite writes all the histograms of the map in the graphite
+// plaintext protocol, using "<prefix>.<name>" as the prefix of each
+// histogram, see Histogram.EmitGraphite().
+func (hmap Histograms) EmitGraphite(w io.Writer, prefix string,
+	ts time.Time) error {
+	bw := bufio.NewWriter(w)
+
+	for _, k := range hmap.SortedNames(nil) {
+		v := hmap[k]
+		v.m.Lock()
+		v.emitGraphiteUNLOCKED(bw,
+			metricName(statKey(prefix, k)), ts.Unix())
+		v.m.Unlock()
+	}
+
+	return bw.Flush()
+}
+
+func (gh *Histogram) emitGraphiteUNLOCKED(w io.Writer, prefix string,
+	ts int64) {
+	for i, c := range gh.Counts {
+		fmt.Fprintf(w, "%s %d %d\n", statKey(prefix,
+			fmt.Sprintf("bin_%d", gh.rangeLabel(gh.Ranges[i]))), c, ts)
+	}
+
+	stats := make(map[string]uint64, 3+len(summaryPercentiles))
+	gh.statsUNLOCKED(prefix, stats)
+
+	keys := make([]string, 0, len(stats))
+	for k := range stats {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s %d %d\n", k, stats[k], ts)
+	}
+}
@@ -0,0 +1,55 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmitGraphite(t *testing.T) {
+	gh := NewHistogram(3, 10, 0.0)
+	gh.Add(5, 2)
+	gh.Add(25, 1)
+
+	ts := time.Unix(1500000000, 0)
+
+	var buf bytes.Buffer
+	if err := gh.EmitGraphite(&buf, "kv.get", ts); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	exp := `kv.get.bin_0 2 1500000000
+kv.get.bin_10 0 1500000000
+kv.get.bin_20 1 1500000000
+kv.get.count 3 1500000000
+kv.get.max 25 1500000000
+kv.get.min 5 1500000000
+kv.get.p50 8 1500000000
+kv.get.p90 23 1500000000
+kv.get.p99 24 1500000000
+kv.get.p999 24 1500000000
+`
+	if buf.String() != exp {
+		t.Errorf("unexpected graphite output, got: %s", buf.String())
+	}
+
+	histograms, _, _ := initAndFetchHistograms(t)
+
+	buf.Reset()
+	if err := histograms.EmitGraphite(&buf, "kv", ts); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "kv.test1.bin_0 2 1500000000\n") ||
+		!strings.Contains(buf.String(), "kv.test2.count 4 1500000000\n") {
+		t.Errorf("unexpected graphite output, got: %s", buf.String())
+	}
+}
@@ -0,0 +1,110 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// GraphOptions changes how the bars of the ASCII graph are drawn, see
+// EmitGraphWithOptions().
+type GraphOptions struct {
+	// Unicode draws bars with Unicode block characters, in eighths
+	// of a character, for a finer resolution than '#'.
+	Unicode bool
+
+	// Color colors the bars with ANSI escape codes: red for bins
+	// starting at or beyond RedAt, yellow for bins starting at or
+	// beyond YellowAt, and green otherwise.  A threshold of 0 is
+	// unused.
+	Color    bool
+	YellowAt uint64
+	RedAt    uint64
+}
+
+const (
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiRed    = "\x1b[31m"
+	ansiReset  = "\x1b[0m"
+)
+
+// eighthBlocks holds the partial blocks of 1/8 to 7/8 width.
+var eighthBlocks = []string{"▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+// EmitGraphWithOptions is like EmitGraph(), but draws the bars
+// according to the options.
+func (gh *Histogram) EmitGraphWithOptions(prefix []byte,
+	out *bytes.Buffer, opts GraphOptions) *bytes.Buffer {
+	gh.m.Lock()
+	out = gh.emitGraphOptsUNLOCKED(prefix, out, 0, &opts)
+	gh.m.Unlock()
+
+	return out
+}
+
+// FprintGraph emits the graph through the provided writer with the
+// options, except that Color is disabled when the writer is not a
+// terminal or when the NO_COLOR environment variable is set.
+func (gh *Histogram) FprintGraph(w io.Writer, opts GraphOptions) (int, error) {
+	if opts.Color && (!isTerminal(w) || os.Getenv("NO_COLOR") != "") {
+		opts.Color = false
+	}
+
+	return w.Write(gh.EmitGraphWithOptions(nil, nil, opts).Bytes())
+}
+
+// isTerminal returns true when w is a character device, like a TTY.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// writeBar writes the bar of a bin with count c, relative to the
+// fullest bin's maxCount, where low is the lower bound of the bin.
+func (o *GraphOptions) writeBar(out *bytes.Buffer,
+	c, maxCount uint64, low uint64) {
+	if o.Color {
+		switch {
+		case o.RedAt > 0 && low >= o.RedAt:
+			out.WriteString(ansiRed)
+		case o.YellowAt > 0 && low >= o.YellowAt:
+			out.WriteString(ansiYellow)
+		default:
+			out.WriteString(ansiGreen)
+		}
+	}
+
+	if o.Unicode {
+		eighths := mulDiv(c, uint64(len(bar))*8, maxCount)
+		for i := uint64(0); i < eighths/8; i++ {
+			out.WriteString("█")
+		}
+		if eighths%8 > 0 {
+			out.WriteString(eighthBlocks[eighths%8-1])
+		}
+	} else {
+		out.Write(bar[0:mulDiv(c, uint64(len(bar)), maxCount)])
+	}
+
+	if o.Color {
+		out.WriteString(ansiReset)
+	}
+}
@@ -0,0 +1,61 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEmitGraphWithOptions(t *testing.T) {
+	gh := NewNamedHistogram("get", 3, 10, 0.0)
+	gh.Add(5, 9)
+	gh.Add(15, 100)
+	gh.Add(25, 50)
+
+	tests := []struct {
+		opts GraphOptions
+		exp  string
+	}{
+		{GraphOptions{}, gh.EmitGraph(nil, nil).String()},
+		{GraphOptions{Unicode: true}, `get (159 Total)
+[0 - 10]      5.66%    5.66% ██▋ (9)
+[10 - 20]    62.89%   68.55% ██████████████████████████████ (100)
+[20 - inf]   31.45%  100.00% ███████████████ (50)
+`},
+		{GraphOptions{Color: true, YellowAt: 10, RedAt: 20}, "get (159 Total)\n" +
+			"[0 - 10]      5.66%    5.66% \x1b[32m##\x1b[0m (9)\n" +
+			"[10 - 20]    62.89%   68.55% \x1b[33m" +
+			"##############################\x1b[0m (100)\n" +
+			"[20 - inf]   31.45%  100.00% \x1b[31m" +
+			"###############\x1b[0m (50)\n"},
+	}
+
+	for testi, test := range tests {
+		got := gh.EmitGraphWithOptions(nil, nil, test.opts).String()
+		if got != test.exp {
+			t.Errorf("test #%d, expected:\n%s\ngot:\n%s",
+				testi, test.exp, got)
+		}
+	}
+}
+
+func TestFprintGraphNoTTY(t *testing.T) {
+	gh := NewNamedHistogram("get", 3, 10, 0.0)
+	gh.Add(5, 7)
+
+	var buf bytes.Buffer
+	gh.FprintGraph(&buf, GraphOptions{Color: true, Unicode: true})
+	if bytes.Contains(buf.Bytes(), []byte("\x1b[")) {
+		t.Errorf("expected no color for a non-terminal, got: %q", buf.String())
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("█")) {
+		t.Errorf("expected unicode bars, got: %q", buf.String())
+	}
+}
@@ -0,0 +1,187 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// HdrSnapshot holds the state of an HdrHistogram.  It has the same
+// fields as the Snapshot of github.com/codahale/hdrhistogram, so it
+// converts to and from that type without this package depending on
+// it, for example:
+//
+//    hdr := hdrhistogram.Import((*hdrhistogram.Snapshot)(snap))
+//    snap := (*ghistogram.HdrSnapshot)(hdr.Export())
+type HdrSnapshot struct {
+	LowestTrackableValue  int64
+	HighestTrackableValue int64
+	SignificantFigures    int64
+	Counts                []int64
+}
+
+// hdrLayout holds the bucketing parameters of an HdrHistogram.
+type hdrLayout struct {
+	unitMagnitude               uint
+	subBucketHalfCountMagnitude uint
+	subBucketHalfCount          int64
+	subBucketMask               int64
+	countsLen                   int
+}
+
+func newHdrLayout(lowest, highest, sigFigs int64) (*hdrLayout, error) {
+	if lowest < 1 || highest < 2*lowest || sigFigs < 1 || sigFigs > 5 {
+		return nil, fmt.Errorf("ghistogram: invalid HdrHistogram parameters,"+
+			" lowest: %d, highest: %d, sigFigs: %d", lowest, highest, sigFigs)
+	}
+
+	largestValueWithSingleUnitResolution := 2 * math.Pow10(int(sigFigs))
+
+	subBucketCountMagnitude :=
+		int(math.Ceil(math.Log2(largestValueWithSingleUnitResolution)))
+	subBucketHalfCountMagnitude := subBucketCountMagnitude
+	if subBucketHalfCountMagnitude < 1 {
+		subBucketHalfCountMagnitude = 1
+	}
+	subBucketHalfCountMagnitude--
+
+	unitMagnitude := uint(bits.Len64(uint64(lowest)) - 1)
+
+	subBucketCount := int64(1) << uint(subBucketHalfCountMagnitude+1)
+
+	smallestUntrackableValue := subBucketCount << unitMagnitude
+	bucketCount := 1
+	for smallestUntrackableValue < highest {
+		bucketCount++
+		if smallestUntrackableValue > math.MaxInt64/2 {
+			break // The next shift would overflow.
+		}
+		smallestUntrackableValue <<= 1
+	}
+
+	return &hdrLayout{
+		unitMagnitude:               unitMagnitude,
+		subBucketHalfCountMagnitude: uint(subBucketHalfCountMagnitude),
+		subBucketHalfCount:          subBucketCount / 2,
+		subBucketMask:               (subBucketCount - 1) << unitMagnitude,
+		countsLen:                   (bucketCount + 1) * int(subBucketCount/2),
+	}, nil
+}
+
+// valueFromIndex returns the lowest value of the counts index.
+func (l *hdrLayout) valueFromIndex(i int) uint64 {
+	bucketIdx := (int64(i) >> l.subBucketHalfCountMagnitude) - 1
+	subBucketIdx := (int64(i) & (l.subBucketHalfCount - 1)) +
+		l.subBucketHalfCount
+	if bucketIdx < 0 {
+		subBucketIdx -= l.subBucketHalfCount
+		bucketIdx = 0
+	}
+
+	return uint64(subBucketIdx) << (uint(bucketIdx) + l.unitMagnitude)
+}
+
+// countsIndex returns the counts index of the value.
+func (l *hdrLayout) countsIndex(v uint64) int {
+	pow2Ceiling := uint(bits.Len64(v | uint64(l.subBucketMask)))
+	bucketIdx := pow2Ceiling - l.unitMagnitude -
+		(l.subBucketHalfCountMagnitude + 1)
+	subBucketIdx := int64(v >> (bucketIdx + l.unitMagnitude))
+
+	return int((int64(bucketIdx)+1)<<l.subBucketHalfCountMagnitude +
+		subBucketIdx - l.subBucketHalfCount)
+}
+
+// FromHdr creates a new histogram whose bins are exactly the buckets
+// of the HdrHistogram snapshot, so no counts are lost or re-sampled.
+// As HdrHistograms don't track the sum of the recorded values, the
+// TotDataPoint is estimated from the lowest values of the buckets.
+func FromHdr(name string, snap *HdrSnapshot) (*Histogram, error) {
+	l, err := newHdrLayout(snap.LowestTrackableValue,
+		snap.HighestTrackableValue, snap.SignificantFigures)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(snap.Counts) != l.countsLen {
+		return nil, fmt.Errorf("ghistogram: FromHdr, expected %d counts,"+
+			" got %d", l.countsLen, len(snap.Counts))
+	}
+
+	gh := &Histogram{
+		Name:         name,
+		Ranges:       make([]uint64, l.countsLen),
+		Counts:       make([]uint64, l.countsLen),
+		MinDataPoint: math.MaxUint64,
+	}
+
+	for i := range gh.Ranges {
+		gh.Ranges[i] = l.valueFromIndex(i)
+	}
+
+	for i, c := range snap.Counts {
+		if c < 0 {
+			return nil, fmt.Errorf("ghistogram: FromHdr, negative count"+
+				" %d at index %d", c, i)
+		}
+
+		if c > 0 {
+			gh.addUNLOCKED(gh.Ranges[i], uint64(c))
+		}
+	}
+
+	return gh, nil
+}
+
+// ToHdr converts the histogram into an HdrHistogram snapshot with the
+// given parameters.  The count of each bin is recorded at the lowest
+// value of the bin, in data point units for histograms with a
+// transform, see SetTransform(), so a histogram created by FromHdr()
+// converts back without any loss.  Non-empty bins starting beyond the
+// values the HdrHistogram can track, or counts that overflow its int64
+// counts, result in an error.
+func (gh *Histogram) ToHdr(lowest, highest int64,
+	sigFigs int) (*HdrSnapshot, error) {
+	l, err := newHdrLayout(lowest, highest, int64(sigFigs))
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &HdrSnapshot{
+		LowestTrackableValue:  lowest,
+		HighestTrackableValue: highest,
+		SignificantFigures:    int64(sigFigs),
+		Counts:                make([]int64, l.countsLen),
+	}
+
+	gh.m.Lock()
+	defer gh.m.Unlock()
+
+	for i, c := range gh.Counts {
+		if c == 0 {
+			continue
+		}
+
+		// In data point units, for histograms with a transform.
+		lo := gh.rangeLabel(gh.Ranges[i])
+
+		idx := l.countsIndex(lo)
+		if idx >= l.countsLen ||
+			c > uint64(math.MaxInt64-snap.Counts[idx]) {
+			return nil, fmt.Errorf("ghistogram: ToHdr, bin %d (%d) with"+
+				" count %d is not trackable", i, lo, c)
+		}
+
+		snap.Counts[idx] += int64(c)
+	}
+
+	return snap, nil
+}
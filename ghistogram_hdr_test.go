@@ -0,0 +1,147 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestHdrLayout(t *testing.T) {
+	l, err := newHdrLayout(1, 1000, 2)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if l.countsLen != 512 {
+		t.Errorf("expected 512 counts, got: %d", l.countsLen)
+	}
+
+	tests := []struct {
+		index int
+		value uint64
+	}{
+		{0, 0},
+		{1, 1},
+		{255, 255},
+		{256, 256},
+		{278, 300},
+		{383, 510},
+		{384, 512},
+		{511, 1020},
+	}
+
+	for testi, test := range tests {
+		if got := l.valueFromIndex(test.index); got != test.value {
+			t.Errorf("test #%d, valueFromIndex(%d), exp: %d, got: %d",
+				testi, test.index, test.value, got)
+		}
+		if got := l.countsIndex(test.value); got != test.index {
+			t.Errorf("test #%d, countsIndex(%d), exp: %d, got: %d",
+				testi, test.value, test.index, got)
+		}
+	}
+
+	if _, err = newHdrLayout(0, 1000, 2); err == nil {
+		t.Errorf("expected err on lowest of 0")
+	}
+}
+
+func TestHdrRoundTrip(t *testing.T) {
+	l, _ := newHdrLayout(1, 1000, 2)
+
+	snap := &HdrSnapshot{
+		LowestTrackableValue:  1,
+		HighestTrackableValue: 1000,
+		SignificantFigures:    2,
+		Counts:                make([]int64, l.countsLen),
+	}
+	snap.Counts[3] = 2
+	snap.Counts[278] = 5
+	snap.Counts[511] = 1
+
+	gh, err := FromHdr("hdr", snap)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if gh.TotCount != 8 || gh.MinDataPoint != 3 || gh.MaxDataPoint != 1020 {
+		t.Errorf("unexpected histogram, TotCount: %d, Min: %d, Max: %d",
+			gh.TotCount, gh.MinDataPoint, gh.MaxDataPoint)
+	}
+
+	snap2, err := gh.ToHdr(1, 1000, 2)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	for i := range snap.Counts {
+		if snap.Counts[i] != snap2.Counts[i] {
+			t.Errorf("mismatch at index %d, exp: %d, got: %d",
+				i, snap.Counts[i], snap2.Counts[i])
+		}
+	}
+
+	snap.Counts = snap.Counts[1:]
+	if _, err = FromHdr("hdr", snap); err == nil {
+		t.Errorf("expected err on wrong counts length")
+	}
+
+	if _, err = gh.ToHdr(1, 100, 2); err == nil {
+		t.Errorf("expected err on untrackable bins")
+	}
+
+	// Bins 0 and 10 both map to the first HdrHistogram count, whose
+	// int64 must not overflow.
+	gh2 := NewHistogram(3, 10, 0.0)
+	gh2.Add(0, math.MaxInt64)
+	if _, err = gh2.ToHdr(1000, 100000, 2); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	gh2.Add(10, 1)
+	if _, err = gh2.ToHdr(1000, 100000, 2); err == nil {
+		t.Errorf("expected err on an overflowing count")
+	}
+}
+
+func TestHdrRoundTripTransform(t *testing.T) {
+	gh := NewNamedHistogram("log2", 22, 1, 0)
+	gh.SetTransform(Log2Transform{})
+
+	gh.Add(5, 2)    // [4 - 8)
+	gh.Add(1000, 3) // [512 - 1024)
+	gh.Add(1<<20, 1)
+
+	snap, err := gh.ToHdr(1, 1<<21, 3)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	back, err := FromHdr("hdr", snap)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	got := map[uint64]uint64{}
+	back.VisitBins(func(start, end, count uint64) bool {
+		if count > 0 {
+			got[start] = count
+		}
+		return true
+	})
+
+	exp := map[uint64]uint64{4: 2, 512: 3, 1 << 20: 1}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("expected the bins in data point units, got: %v", got)
+	}
+
+	snap2, err := back.ToHdr(1, 1<<21, 3)
+	if err != nil || !reflect.DeepEqual(snap.Counts, snap2.Counts) {
+		t.Errorf("expected a lossless round trip, err: %v", err)
+	}
+}
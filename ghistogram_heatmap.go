@@ -0,0 +1,120 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// heatShades holds the cell shades of increasing density.
+var heatShades = []string{" ", "░", "▒", "▓", "█"}
+
+// EmitHeatmap writes a heatmap of a time ordered series of windows,
+// such as a history of interval snapshots, with time on the X axis and
+// bins on the Y axis, so drift of the distribution over time is
+// visible at a glance, for example:
+//
+//    get (480 Total, 4 windows)
+//    [40 - 80]  ░██
+//    [20 - 40] ░█▒░
+//    [10 - 20] █▒░
+//              12:00:00 - 12:04:00
+//
+// Each cell is shaded by the bin's share of its window's fullest bin,
+// so every column shows the shape of its window's distribution.  Only
+// the bins from the lowest to the highest non-empty bin are shown.
+// The windows' histograms must have the same bins.
+func EmitHeatmap(w io.Writer, windows []Window) error {
+	if len(windows) == 0 {
+		_, err := io.WriteString(w, "(empty)\n")
+		return err
+	}
+
+	snaps := make([]*Histogram, len(windows))
+	for i, win := range windows {
+		snaps[i] = win.Histogram.Snapshot()
+		if !sameRanges(snaps[0], snaps[i]) {
+			return fmt.Errorf("ghistogram: EmitHeatmap,"+
+				" window %d has different bins", i)
+		}
+	}
+
+	var totCount uint64
+	lo, hi := -1, -1
+	for _, s := range snaps {
+		totCount += s.TotCount
+		for i, c := range s.Counts {
+			if c > 0 {
+				if lo < 0 || i < lo {
+					lo = i
+				}
+				if i > hi {
+					hi = i
+				}
+			}
+		}
+	}
+
+	var out bytes.Buffer
+
+	fmt.Fprintf(&out, "%s (%v Total, %d windows)\n",
+		snaps[0].Name, totCount, len(windows))
+
+	if lo < 0 {
+		out.WriteString("(empty)\n")
+		_, err := w.Write(out.Bytes())
+		return err
+	}
+
+	maxCounts := make([]uint64, len(snaps))
+	for j, s := range snaps {
+		maxCounts[j] = maxBinCount(s.Counts)
+	}
+
+	bins := snaps[0].binLabelsUNLOCKED()
+
+	var longest int
+	for i := lo; i <= hi; i++ {
+		if longest < len(bins[i]) {
+			longest = len(bins[i])
+		}
+	}
+
+	for i := hi; i >= lo; i-- {
+		fmt.Fprintf(&out, "[%s]%s ", bins[i],
+			strings.Repeat(" ", longest-len(bins[i])))
+
+		for j, s := range snaps {
+			c := s.Counts[i]
+			shade := 0
+			if c > 0 {
+				// Rounds up, so any non-empty cell is shaded.
+				n := uint64(len(heatShades) - 1)
+				level := mulDiv(c, n, maxCounts[j])
+				if mulDiv(level, maxCounts[j], n) < c {
+					level++
+				}
+				shade = int(level)
+			}
+			out.WriteString(heatShades[shade])
+		}
+
+		out.WriteByte('\n')
+	}
+
+	fmt.Fprintf(&out, "%s %s - %s\n", strings.Repeat(" ", longest+2),
+		windows[0].Start.Format("15:04:05"),
+		windows[len(windows)-1].End.Format("15:04:05"))
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
@@ -0,0 +1,69 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestEmitHeatmap(t *testing.T) {
+	var buf bytes.Buffer
+	err := EmitHeatmap(&buf, nil)
+	if err != nil || buf.String() != "(empty)\n" {
+		t.Errorf("expected empty, got: %q, err: %v", buf.String(), err)
+	}
+
+	start := time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	data := [][]uint64{
+		// Counts of bins 0 to 4, for each window.
+		{0, 100, 10, 0, 0},
+		{0, 50, 100, 10, 0},
+		{0, 10, 40, 100, 0},
+		{0, 0, 10, 50, 0},
+	}
+
+	var windows []Window
+	for i, counts := range data {
+		gh := NewNamedHistogram("get", 5, 10, 2.0)
+		for bin, c := range counts {
+			if c > 0 {
+				gh.Add(gh.Ranges[bin], c)
+			}
+		}
+
+		windows = append(windows, Window{
+			Start:     start.Add(time.Duration(i) * time.Minute),
+			End:       start.Add(time.Duration(i+1) * time.Minute),
+			Histogram: gh,
+		})
+	}
+
+	buf.Reset()
+	if err = EmitHeatmap(&buf, windows); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := `get (480 Total, 4 windows)
+[40 - 80]  ░██
+[20 - 40] ░█▒░
+[10 - 20] █▒░ ` + `
+          12:00:00 - 12:04:00
+`
+	if buf.String() != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, buf.String())
+	}
+
+	windows[1].Histogram = NewHistogram(3, 10, 2.0)
+	if err = EmitHeatmap(&buf, windows); err == nil {
+		t.Errorf("expected error for mismatched bins")
+	}
+}
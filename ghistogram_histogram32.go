@@ -0,0 +1,230 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"sync"
+)
+
+// Histogram32 is a memory compact variant of Histogram for
+// deployments with very many histograms, such as one per collection.
+// It keeps uint32 bin counters, halving the memory of the bins, and
+// switches to uint64 counters once a bin would saturate, so counts are
+// never lost.  The bin ranges are shared by all the Histogram32's
+// created from the same layout, see NewHistogram32().
+//
+// A Histogram32 is converted to a regular Histogram for graphs,
+// percentiles and the like, see Histogram().  The Histogram32 is
+// concurrent safe.
+type Histogram32 struct {
+	// Histogram name.
+	Name string
+
+	// TotCount is the sum of all counts.
+	TotCount uint64
+
+	TotDataPoint uint64 // TotDataPoint is the sum of all data points.
+	MinDataPoint uint64 // MinDataPoint is the smallest data point seen.
+	MaxDataPoint uint64 // MaxDataPoint is the largest data point seen.
+
+	layout *layout32 // Immutable and shared, see NewHistogram32().
+
+	counts32 []uint32
+	counts64 []uint64 // Non-nil once a uint32 counter saturated.
+
+	saturated bool // See Histogram.Saturated().
+
+	m sync.Mutex
+}
+
+// layout32 holds the bin ranges and settings that are shared by the
+// Histogram32's created from the same layout histogram.  It is never
+// modified once created.
+type layout32 struct {
+	ranges      []uint64
+	boundary    BinBoundary
+	transform   Transform
+	rounding    Rounding
+	unit        string
+	rangeFormat RangeFormat
+}
+
+// NewHistogram32 creates a new, ready to use Histogram32 with the bin
+// ranges, bin boundary and transform of the layout histogram.  The
+// Histogram32's created from the same, unchanged layout histogram
+// share a single copy of its bin ranges.
+func NewHistogram32(name string, layout *Histogram) *Histogram32 {
+	layout.m.Lock()
+	l := layout.layout32UNLOCKED()
+	layout.m.Unlock()
+
+	return newHistogram32(name, l)
+}
+
+// layout32UNLOCKED returns the shared layout32 of the histogram,
+// creating it when the histogram has none yet or its bins or settings
+// changed since.
+func (gh *Histogram) layout32UNLOCKED() *layout32 {
+	l := gh.layout32
+	if l != nil && l.boundary == gh.boundary &&
+		l.rounding == gh.rounding && l.unit == gh.unit &&
+		l.rangeFormat == gh.rangeFormat &&
+		equalUint64s(l.ranges, gh.Ranges) {
+		return l
+	}
+
+	l = &layout32{
+		ranges:      append([]uint64(nil), gh.Ranges...),
+		boundary:    gh.boundary,
+		transform:   gh.transform,
+		rounding:    gh.rounding,
+		unit:        gh.unit,
+		rangeFormat: gh.rangeFormat,
+	}
+	gh.layout32 = l
+
+	return l
+}
+
+func equalUint64s(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func newHistogram32(name string, layout *layout32) *Histogram32 {
+	return &Histogram32{
+		Name:         name,
+		MinDataPoint: math.MaxUint64,
+		layout:       layout,
+		counts32:     make([]uint32, len(layout.ranges)),
+	}
+}
+
+// CloneEmpty creates a new, empty Histogram32 which shares the bin
+// ranges of this Histogram32.
+func (h *Histogram32) CloneEmpty() *Histogram32 {
+	return newHistogram32(h.Name, h.layout)
+}
+
+// Add increases the count in the bin for the given dataPoint
+// in a concurrent-safe manner.
+func (h *Histogram32) Add(dataPoint uint64, count uint64) {
+	l := h.layout
+
+	binValue := dataPoint
+	if l.transform != nil {
+		binValue = l.transform.Forward(dataPoint)
+	}
+
+	idx := binIndex(l.ranges, l.boundary, binValue)
+	if idx < 0 {
+		return
+	}
+
+	h.m.Lock()
+
+	if h.counts64 == nil &&
+		count > math.MaxUint32-uint64(h.counts32[idx]) {
+		h.counts64 = make([]uint64, len(h.counts32))
+		for i, c := range h.counts32 {
+			h.counts64[i] = uint64(c)
+		}
+		h.counts32 = nil
+	}
+
+	if h.counts64 != nil {
+		h.counts64[idx] = h.satAddUNLOCKED(h.counts64[idx], count)
+	} else {
+		h.counts32[idx] += uint32(count)
+	}
+
+	h.TotCount = h.satAddUNLOCKED(h.TotCount, count)
+	h.TotDataPoint = h.satAddUNLOCKED(h.TotDataPoint, dataPoint)
+	if h.MinDataPoint > dataPoint {
+		h.MinDataPoint = dataPoint
+	}
+	if h.MaxDataPoint < dataPoint {
+		h.MaxDataPoint = dataPoint
+	}
+
+	h.m.Unlock()
+}
+
+// satAddUNLOCKED returns a + b, saturated at math.MaxUint64, like
+// Histogram's, where the saturation carries over to Histogram().
+func (h *Histogram32) satAddUNLOCKED(a, b uint64) uint64 {
+	sum, saturated := satAdd(a, b)
+	h.saturated = h.saturated || saturated
+	return sum
+}
+
+// Saturated returns true when the Histogram32 switched to uint64
+// counters, after a bin count exceeded math.MaxUint32.
+func (h *Histogram32) Saturated() bool {
+	h.m.Lock()
+	rv := h.counts64 != nil
+	h.m.Unlock()
+	return rv
+}
+
+// Reset clears all the counts and data point statistics, returning
+// to uint32 counters.
+func (h *Histogram32) Reset() {
+	h.m.Lock()
+	h.counts32 = make([]uint32, len(h.layout.ranges))
+	h.counts64 = nil
+	h.TotCount = 0
+	h.TotDataPoint = 0
+	h.MinDataPoint = math.MaxUint64
+	h.MaxDataPoint = 0
+	h.saturated = false
+	h.m.Unlock()
+}
+
+// Histogram returns a regular Histogram copy of the Histogram32.
+func (h *Histogram32) Histogram() *Histogram {
+	l := h.layout
+
+	rv := &Histogram{
+		Ranges:      append([]uint64(nil), l.ranges...),
+		Counts:      make([]uint64, len(l.ranges)),
+		boundary:    l.boundary,
+		transform:   l.transform,
+		rounding:    l.rounding,
+		unit:        l.unit,
+		rangeFormat: l.rangeFormat,
+	}
+
+	h.m.Lock()
+	rv.Name = h.Name
+	if h.counts64 != nil {
+		copy(rv.Counts, h.counts64)
+	} else {
+		for i, c := range h.counts32 {
+			rv.Counts[i] = uint64(c)
+		}
+	}
+	rv.TotCount = h.TotCount
+	rv.total = h.TotCount
+	rv.TotDataPoint = h.TotDataPoint
+	rv.MinDataPoint = h.MinDataPoint
+	rv.MaxDataPoint = h.MaxDataPoint
+	rv.saturated = h.saturated
+	h.m.Unlock()
+
+	return rv
+}
@@ -0,0 +1,121 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestHistogram32(t *testing.T) {
+	layout := NewHistogram(4, 10, 2.0)
+	h := NewHistogram32("h32", layout)
+
+	gh := NewNamedHistogram("h32", 4, 10, 2.0)
+	for i := uint64(0); i < 100; i += 3 {
+		h.Add(i, i)
+		gh.Add(i, i)
+	}
+
+	got := h.Histogram()
+	if !reflect.DeepEqual(got.Counts, gh.Counts) ||
+		got.EmitGraph(nil, nil).String() != gh.EmitGraph(nil, nil).String() ||
+		got.Total() != gh.TotCount || h.Saturated() {
+		t.Errorf("expected same as Histogram, got: %s",
+			got.EmitGraph(nil, nil))
+	}
+
+	c := h.CloneEmpty()
+	if c.layout != h.layout || c.TotCount != 0 {
+		t.Errorf("expected CloneEmpty to share the ranges")
+	}
+
+	h.Reset()
+	if h.TotCount != 0 || h.Histogram().Counts[0] != 0 {
+		t.Errorf("expected Reset to clear counts")
+	}
+}
+
+func TestHistogram32SharedLayout(t *testing.T) {
+	layout := NewHistogram(1000, 10, 0)
+
+	a := NewHistogram32("a", layout)
+	b := NewHistogram32("b", layout)
+	if a.layout != b.layout {
+		t.Errorf("expected instances of the same layout to share it")
+	}
+
+	layout.SetTransform(Log2Transform{})
+	c := NewHistogram32("c", layout)
+	if c.layout == a.layout || c.layout.transform == nil {
+		t.Errorf("expected a new layout after the layout histogram changed")
+	}
+
+	// Each instance should be little more than its uint32 counters.
+	const n = 100
+
+	hs := make([]*Histogram32, n)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	for i := range hs {
+		hs[i] = NewHistogram32("h32", layout)
+	}
+	runtime.ReadMemStats(&after)
+
+	perInstance := (after.TotalAlloc - before.TotalAlloc) / n
+	if perInstance > 1000*4+512 {
+		t.Errorf("expected about 4000 bytes per instance, got: %d",
+			perInstance)
+	}
+
+	runtime.KeepAlive(hs)
+}
+
+func TestHistogram32Saturation(t *testing.T) {
+	h := NewHistogram32("h32", NewHistogram(4, 10, 2.0))
+
+	h.Add(5, math.MaxUint32)
+	h.Add(15, 7)
+	if h.Saturated() {
+		t.Errorf("expected no saturation at MaxUint32")
+	}
+
+	h.Add(5, 1)
+	if !h.Saturated() {
+		t.Errorf("expected saturation beyond MaxUint32")
+	}
+
+	h.Add(5, 2)
+
+	exp := []uint64{math.MaxUint32 + 3, 7, 0, 0}
+	if got := h.Histogram().Counts; !reflect.DeepEqual(got, exp) {
+		t.Errorf("expected counts: %v, got: %v", exp, got)
+	}
+
+	h.Reset()
+	if h.Saturated() {
+		t.Errorf("expected Reset to return to uint32 counters")
+	}
+
+	// A huge count must not wrap the uint32 check and be truncated.
+	h.Add(5, math.MaxUint64-1)
+	if !h.Saturated() || h.Histogram().Counts[0] != math.MaxUint64-1 {
+		t.Errorf("expected uint64 counters, got: %v", h.Histogram().Counts)
+	}
+
+	h.Add(5, 10)
+	gh := h.Histogram()
+	if gh.Counts[0] != math.MaxUint64 || gh.TotCount != math.MaxUint64 ||
+		!gh.Saturated() {
+		t.Errorf("expected saturated counts, got: %+v", gh)
+	}
+}
@@ -0,0 +1,187 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// historyState is the ring of the last interval windows, see
+// EnableHistory(), which is only accessed while the histogram is
+// locked.
+type historyState struct {
+	interval time.Duration
+
+	windows []Window // A ring of up to cap(windows) windows.
+	next    int      // The index of the oldest window once full.
+
+	start time.Time  // The start of the current interval.
+	cur   *Histogram // The data points of the current interval.
+}
+
+// EnableHistory retains the data points of each of the last n
+// intervals of the histogram's clock, see SetClock(), as windows, so
+// questions like "when did p99 start degrading?" can be answered
+// without an external time series database, see History().  The
+// intervals are not affected by resets of the histogram.  Enabling
+// the history again starts over, and an n or interval <= 0 disables
+// it.
+//
+// The intervals are rotated as data points are added or the history
+// is read, without a goroutine, and intervals without data points
+// become empty windows.  While the history is enabled, every Add()
+// reads the clock and also adds to the current interval.  Auto ranging
+// rebins the current interval along with the histogram, so earlier
+// windows keep the previous bins, see SetAutoRange().
+func (gh *Histogram) EnableHistory(n int, interval time.Duration) {
+	gh.m.Lock()
+	defer gh.m.Unlock()
+
+	if n <= 0 || interval <= 0 {
+		gh.history = nil
+		return
+	}
+
+	gh.history = &historyState{
+		interval: interval,
+		windows:  make([]Window, 0, n),
+		start:    gh.now(),
+		cur:      gh.historyIntervalUNLOCKED(),
+	}
+}
+
+// historyIntervalUNLOCKED returns an empty histogram for the data
+// points of an interval.
+func (gh *Histogram) historyIntervalUNLOCKED() *Histogram {
+	rv := gh.CloneEmpty()
+	rv.reservoir = nil
+	rv.autoRangeFraction = 0
+	return rv
+}
+
+// rotate retires the intervals that ended by now into windows, where
+// only the last cap(windows) windows are kept.
+func (h *historyState) rotate(now time.Time) {
+	k := int64(now.Sub(h.start) / h.interval)
+	if k <= 0 {
+		return
+	}
+
+	n := int64(cap(h.windows))
+
+	for ; k > 0; k-- {
+		end := h.start.Add(h.interval)
+
+		h.push(Window{Start: h.start, End: end, Histogram: h.cur})
+
+		h.cur = h.cur.CloneEmpty()
+		h.start = end
+
+		if k-1 > n {
+			// Skip the empty intervals that would be dropped anyway.
+			h.start = h.start.Add(time.Duration(k-1-n) * h.interval)
+			k = n + 1
+		}
+	}
+}
+
+func (h *historyState) push(w Window) {
+	if len(h.windows) < cap(h.windows) {
+		h.windows = append(h.windows, w)
+		return
+	}
+
+	h.windows[h.next] = w
+	h.next = (h.next + 1) % len(h.windows)
+}
+
+// History returns the retained windows of the completed intervals,
+// oldest first, see EnableHistory(), or nil when the history is not
+// enabled.  The histograms of the windows are copies.
+func (gh *Histogram) History() []Window {
+	gh.m.Lock()
+	defer gh.m.Unlock()
+
+	return gh.historyUNLOCKED()
+}
+
+func (gh *Histogram) historyUNLOCKED() []Window {
+	h := gh.history
+	if h == nil {
+		return nil
+	}
+
+	h.rotate(gh.now())
+
+	rv := make([]Window, 0, len(h.windows))
+	for i := range h.windows {
+		w := h.windows[(h.next+i)%len(h.windows)]
+		w.Histogram = w.Histogram.Snapshot()
+		rv = append(rv, w)
+	}
+
+	return rv
+}
+
+var historyCSVHeader = []string{
+	"start", "end", "count", "min", "max", "p50", "p90", "p99", "p99.9",
+}
+
+// WriteHistoryCSV writes the retained windows of the history, see
+// History(), as a time series CSV, with a header row and then a row
+// per window, oldest first, with its RFC 3339 start and end times, its
+// count, min and max data points and key percentiles, for example:
+//
+//    start,end,count,min,max,p50,p90,p99,p99.9
+//    2017-01-01T12:00:00Z,2017-01-01T12:01:00Z,120,3,95,12,40,88,95
+//    2017-01-01T12:01:00Z,2017-01-01T12:02:00Z,0,0,0,0,0,0,0
+//
+// When the histogram has a unit, see WithUnit(), a trailing "unit"
+// column holds it, like WriteCSV().
+func (gh *Histogram) WriteHistoryCSV(w io.Writer) error {
+	gh.m.Lock()
+	windows := gh.historyUNLOCKED()
+	unit := gh.unit
+	gh.m.Unlock()
+
+	cw := csv.NewWriter(w)
+
+	header := historyCSVHeader
+	if unit != "" {
+		header = append(append([]string(nil), header...), "unit")
+	}
+	cw.Write(header)
+
+	for _, win := range windows {
+		s := win.Histogram.Summary()
+
+		row := []string{
+			win.Start.UTC().Format(time.RFC3339Nano),
+			win.End.UTC().Format(time.RFC3339Nano),
+			strconv.FormatUint(s.Count, 10),
+			strconv.FormatUint(s.Min, 10),
+			strconv.FormatUint(s.Max, 10),
+			strconv.FormatUint(s.P50, 10),
+			strconv.FormatUint(s.P90, 10),
+			strconv.FormatUint(s.P99, 10),
+			strconv.FormatUint(s.P999, 10),
+		}
+		if unit != "" {
+			row = append(row, unit)
+		}
+
+		cw.Write(row)
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,134 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	start := time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+
+	gh := NewNamedHistogram("get", 5, 10, 2.0)
+	gh.SetClock(clock)
+
+	if gh.History() != nil {
+		t.Errorf("expected no history when not enabled")
+	}
+
+	gh.EnableHistory(3, time.Minute)
+
+	gh.Add(5, 2)
+	clock.Advance(30 * time.Second)
+	gh.Add(15, 1)
+
+	if h := gh.History(); len(h) != 0 {
+		t.Errorf("expected no completed windows, got: %d", len(h))
+	}
+
+	clock.Advance(30 * time.Second)
+	gh.Add(50, 4)
+	gh.Reset() // The history is not affected by resets.
+
+	src := NewNamedHistogram("get", 5, 10, 2.0)
+	src.Add(5, 1)
+	gh.AddAll(src)
+
+	clock.Advance(2 * time.Minute)
+
+	h := gh.History()
+	if len(h) != 3 {
+		t.Fatalf("expected 3 windows, got: %d", len(h))
+	}
+
+	exp := []struct {
+		start time.Time
+		count uint64
+	}{
+		{start, 3},
+		{start.Add(time.Minute), 5},
+		{start.Add(2 * time.Minute), 0},
+	}
+	for i, e := range exp {
+		if !h[i].Start.Equal(e.start) ||
+			!h[i].End.Equal(e.start.Add(time.Minute)) ||
+			h[i].Histogram.TotCount != e.count {
+			t.Errorf("window %d, unexpected: %v - %v, %v",
+				i, h[i].Start, h[i].End, h[i].Histogram)
+		}
+	}
+
+	// The windows are copies.
+	h[0].Histogram.Add(5, 100)
+	if gh.History()[0].Histogram.TotCount != 3 {
+		t.Errorf("expected History() to return copies")
+	}
+
+	// Only the last 3 windows are kept across a long idle gap.
+	gh.Add(5, 1)
+	clock.Advance(time.Hour)
+	h = gh.History()
+	if len(h) != 3 || !h[2].End.Equal(clock.Now()) ||
+		h[0].Histogram.TotCount+h[1].Histogram.TotCount+
+			h[2].Histogram.TotCount != 0 {
+		t.Errorf("unexpected windows after a gap: %v", h)
+	}
+
+	gh.EnableHistory(0, 0)
+	if gh.History() != nil {
+		t.Errorf("expected no history when disabled")
+	}
+}
+
+func TestHistoryAutoRange(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+
+	gh := NewNamedHistogram("get", 4, 10, 2.0)
+	gh.SetClock(clock)
+	gh.SetAutoRange(0.5, 2)
+	gh.EnableHistory(2, time.Minute)
+
+	gh.Add(1000, 5)
+	clock.Advance(time.Minute)
+
+	h := gh.History()
+	if len(h) != 1 || !sameRanges(h[0].Histogram, gh.Snapshot()) ||
+		h[0].Histogram.TotCount != 5 {
+		t.Errorf("expected the interval rebinned with the histogram,"+
+			" got: %v, histogram: %v", h, gh)
+	}
+}
+
+func TestWriteHistoryCSV(t *testing.T) {
+	start := time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+
+	gh := NewNamedHistogram("get", 5, 10, 2.0).WithUnit("ms")
+	gh.SetClock(clock)
+	gh.EnableHistory(2, time.Minute)
+
+	gh.Add(5, 10)
+	clock.Advance(2 * time.Minute)
+
+	var buf bytes.Buffer
+	if err := gh.WriteHistoryCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := `start,end,count,min,max,p50,p90,p99,p99.9,unit
+2017-01-01T12:00:00Z,2017-01-01T12:01:00Z,10,5,5,5,5,5,5,ms
+2017-01-01T12:01:00Z,2017-01-01T12:02:00Z,0,0,0,0,0,0,0,ms
+`
+	if buf.String() != exp {
+		t.Errorf("got:\n%s\nexp:\n%s", buf.String(), exp)
+	}
+}
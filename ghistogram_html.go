@@ -0,0 +1,64 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+)
+
+// emitHTML renders the histogram as a standalone HTML table snippet,
+// for embedding into incident reports and wiki pages.  Only inline
+// styles are used, and every non-empty bin's row has a bar whose width
+// is relative to the fullest bin, for example:
+//
+//    <table class="ghistogram">
+//    <caption>get (4 Total)</caption>
+//    <tr><th>Range</th><th>Count</th><th>Percent</th><th>Cumulative</th><th></th></tr>
+//    <tr><td>0 - 10</td><td>1</td><td>25.00%</td><td>25.00%</td><td style="width:200px"><div style="background:#4682b4;height:1em;width:33.33%"></div></td></tr>
+//    ...
+//    </table>
+func emitHTML(w io.Writer, gh *Histogram) error {
+	var out bytes.Buffer
+
+	fmt.Fprintf(&out, "<table class=\"ghistogram\">\n"+
+		"<caption>%s (%v Total)</caption>\n"+
+		"<tr><th>Range</th><th>Count</th><th>Percent</th>"+
+		"<th>Cumulative</th><th></th></tr>\n",
+		html.EscapeString(gh.Name), gh.TotCount)
+
+	bins := gh.binLabelsUNLOCKED()
+	maxCount := maxBinCount(gh.Counts)
+
+	var runCount uint64
+	for i, c := range gh.Counts {
+		if c == 0 {
+			continue
+		}
+
+		runCount += c
+		p := percentHundredths(c, gh.TotCount)
+		pRun := percentHundredths(runCount, gh.TotCount)
+		pBar := percentHundredths(c, maxCount)
+
+		fmt.Fprintf(&out, "<tr><td>%s</td><td>%d</td>"+
+			"<td>%d.%02d%%</td><td>%d.%02d%%</td>"+
+			"<td style=\"width:200px\"><div style=\"background:#4682b4;"+
+			"height:1em;width:%d.%02d%%\"></div></td></tr>\n",
+			html.EscapeString(bins[i]), c, p/100, p%100,
+			pRun/100, pRun%100, pBar/100, pBar%100)
+	}
+
+	out.WriteString("</table>\n")
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
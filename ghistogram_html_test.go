@@ -0,0 +1,40 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEmitHTML(t *testing.T) {
+	gh := NewNamedHistogram("get <µs>", 3, 10, 0.0)
+	gh.Add(5, 1)
+	gh.Add(25, 3)
+
+	var buf bytes.Buffer
+	if err := gh.Emit(FormatHTML, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := `<table class="ghistogram">
+<caption>get &lt;µs&gt; (4 Total)</caption>
+<tr><th>Range</th><th>Count</th><th>Percent</th><th>Cumulative</th><th></th></tr>
+<tr><td>0 - 10</td><td>1</td><td>25.00%</td><td>25.00%</td>` +
+		`<td style="width:200px"><div style="background:#4682b4;height:1em;` +
+		`width:33.33%"></div></td></tr>
+<tr><td>20 - inf</td><td>3</td><td>75.00%</td><td>100.00%</td>` +
+		`<td style="width:200px"><div style="background:#4682b4;height:1em;` +
+		`width:100.00%"></div></td></tr>
+</table>
+`
+	if buf.String() != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, buf.String())
+	}
+}
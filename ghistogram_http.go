@@ -0,0 +1,98 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Handler returns an http.Handler that serves the histograms of the
+// map, as ASCII graphs by default or as JSON when the request's
+// Accept header includes "application/json".
+//
+// The optional "name" query parameters restrict the response to the
+// histograms with those names, and "reset=true" resets the served
+// histograms right after they are captured.  With "normalize=max",
+// the response is instead the JSON of the histograms' bins normalized
+// to their fullest bin, see Histogram.NormalizedToMax().
+//
+// The map itself must not be modified while the handler is in use,
+// see SyncHistograms.Handler() for a concurrent safe alternative.
+func (hmap Histograms) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveHistograms(w, r, hmap)
+	})
+}
+
+// Handler returns an http.Handler that serves the registered
+// histograms, see Histograms.Handler().
+func (s *SyncHistograms) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveHistograms(w, r, s.Snapshot())
+	})
+}
+
+func serveHistograms(w http.ResponseWriter, r *http.Request,
+	hmap Histograms) {
+	q := r.URL.Query()
+
+	names := q["name"]
+	if len(names) == 0 {
+		names = hmap.SortedNames(nil)
+	}
+
+	reset := q.Get("reset") == "true"
+
+	captured := make(Histograms, len(names))
+	for _, name := range names {
+		if gh := hmap[name]; gh != nil {
+			captured[name] = gh.capture(reset)
+		}
+	}
+
+	if q.Get("normalize") == "max" {
+		writeJSON(w, captured.NormalizedToMax())
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		writeJSON(w, captured)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	captured.Fprint(w)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+// capture returns a copy of the histogram, optionally resetting the
+// histogram while it's still locked so no data points are lost.
+func (gh *Histogram) capture(reset bool) *Histogram {
+	gh.m.Lock()
+	rv := gh.CloneEmpty()
+	gh.copyIntoUNLOCKED(rv)
+	if reset {
+		gh.resetUNLOCKED()
+	}
+	gh.m.Unlock()
+
+	return rv
+}
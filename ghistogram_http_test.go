@@ -0,0 +1,70 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	histograms, exp1, exp2 := initAndFetchHistograms(t)
+
+	h := histograms.Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Body.String() != exp1+"\n"+exp2 {
+		t.Errorf("Unexpected text response, got: %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/?name=test2", nil))
+	if rec.Body.String() != exp2 {
+		t.Errorf("Unexpected filtered response, got: %s", rec.Body.String())
+	}
+
+	req := httptest.NewRequest("GET", "/?name=test1&reset=true", nil)
+	req.Header.Set("Accept", "application/json")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected JSON content type")
+	}
+
+	var decoded Histograms
+	if err := json.Unmarshal(rec.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(decoded) != 1 || decoded["test1"].EmitGraph(nil, nil).String() != exp1 {
+		t.Errorf("Unexpected JSON response, got: %s", rec.Body.String())
+	}
+
+	if histograms["test1"].TotCount != 0 || histograms["test2"].TotCount != 4 {
+		t.Errorf("expected only test1 to be reset")
+	}
+}
+
+func TestHandlerNormalized(t *testing.T) {
+	histograms, _, _ := initAndFetchHistograms(t)
+
+	rec := httptest.NewRecorder()
+	histograms.Handler().ServeHTTP(rec,
+		httptest.NewRequest("GET", "/?name=test2&normalize=max", nil))
+
+	var decoded map[string]NormalizedBins
+	if err := json.Unmarshal(rec.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(decoded) != 1 || decoded["test2"].Values[2] != 100 {
+		t.Errorf("Unexpected normalized response, got: %s",
+			rec.Body.String())
+	}
+}
@@ -0,0 +1,134 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// histogramJSON is the JSON representation of a Histogram, which
+// matches the encoding of the public fields of Histogram, plus the
+// bin boundary convention when it isn't the default, the unit when set
+// and the first and last sample times when known.
+type histogramJSON struct {
+	Name         string
+	Ranges       []uint64
+	Counts       []uint64
+	TotCount     uint64
+	TotDataPoint uint64
+	MinDataPoint uint64
+	MaxDataPoint uint64
+
+	Boundary BinBoundary `json:",omitempty"`
+
+	Unit string `json:",omitempty"`
+
+	FirstSample *time.Time `json:",omitempty"`
+	LastSample  *time.Time `json:",omitempty"`
+}
+
+// MarshalJSON encodes the histogram while it is locked.
+func (gh *Histogram) MarshalJSON() ([]byte, error) {
+	gh.m.Lock()
+	hj := histogramJSON{
+		Name:         gh.Name,
+		Ranges:       gh.Ranges,
+		Counts:       gh.Counts,
+		TotCount:     gh.TotCount,
+		TotDataPoint: gh.TotDataPoint,
+		MinDataPoint: gh.MinDataPoint,
+		MaxDataPoint: gh.MaxDataPoint,
+		Boundary:     gh.boundary,
+		Unit:         gh.unit,
+	}
+	if gh.firstSample != 0 {
+		first := time.Unix(0, gh.firstSample).UTC()
+		last := time.Unix(0, gh.lastSample).UTC()
+		hj.FirstSample, hj.LastSample = &first, &last
+	}
+	b, err := json.Marshal(&hj)
+	gh.m.Unlock()
+
+	return b, err
+}
+
+// UnmarshalJSON decodes a histogram that was encoded by MarshalJSON.
+func (gh *Histogram) UnmarshalJSON(b []byte) error {
+	var hj histogramJSON
+
+	err := json.Unmarshal(b, &hj)
+	if err != nil {
+		return err
+	}
+
+	decoded := Histogram{
+		Name:     hj.Name,
+		Ranges:   hj.Ranges,
+		Counts:   hj.Counts,
+		TotCount: hj.TotCount,
+	}
+	if err = decoded.validateUNLOCKED(); err != nil {
+		return fmt.Errorf("ghistogram: UnmarshalJSON, %v", err)
+	}
+
+	gh.m.Lock()
+	gh.Name = hj.Name
+	gh.Ranges = hj.Ranges
+	gh.Counts = hj.Counts
+	gh.TotCount = hj.TotCount
+	atomic.StoreUint64(&gh.total, gh.TotCount)
+	gh.TotDataPoint = hj.TotDataPoint
+	gh.MinDataPoint = hj.MinDataPoint
+	gh.MaxDataPoint = hj.MaxDataPoint
+	gh.boundary = hj.Boundary
+	gh.unit = hj.Unit
+	gh.firstSample, gh.lastSample = 0, 0
+	if hj.FirstSample != nil && hj.LastSample != nil {
+		gh.firstSample = hj.FirstSample.UnixNano()
+		gh.lastSample = hj.LastSample.UnixNano()
+	}
+	gh.m.Unlock()
+
+	return nil
+}
+
+// MarshalJSON encodes the map as a JSON object of histogram name to
+// histogram.
+func (hmap Histograms) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]*Histogram(hmap))
+}
+
+// UnmarshalJSON decodes a JSON object of histogram name to histogram
+// into the map, replacing any existing histograms of the same name.
+// Use AddAll() to merge the decoded histograms into other maps.
+func (hmap *Histograms) UnmarshalJSON(b []byte) error {
+	var m map[string]*Histogram
+
+	err := json.Unmarshal(b, &m)
+	if err != nil {
+		return err
+	}
+
+	if *hmap == nil {
+		*hmap = make(Histograms, len(m))
+	}
+
+	for k, v := range m {
+		if v == nil {
+			return fmt.Errorf("ghistogram: UnmarshalJSON, null histogram %q", k)
+		}
+
+		(*hmap)[k] = v
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONHistograms(t *testing.T) {
+	histograms, exp1, exp2 := initAndFetchHistograms(t)
+
+	b, err := json.Marshal(histograms)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	var decoded Histograms
+	if err = json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if decoded.String() != exp1+"\n"+exp2 {
+		t.Errorf("Unexpected content after JSON round trip, got: %s",
+			decoded.String())
+	}
+
+	// Decoded histograms can be merged by a central aggregator.
+	aggregate := make(Histograms)
+	aggregate.AddAll(decoded)
+	aggregate.AddAll(histograms)
+	if aggregate["test1"].TotCount != 12 ||
+		aggregate["test1"].MinDataPoint != 1 ||
+		aggregate["test1"].MaxDataPoint != 3 {
+		t.Errorf("Unexpected aggregate after JSON round trip")
+	}
+
+	err = json.Unmarshal([]byte(`{"x":{"Ranges":[0,10],"Counts":[1]}}`),
+		&decoded)
+	if err == nil {
+		t.Errorf("expected err on mismatched Ranges and Counts")
+	}
+}
+
+func TestJSONSampleTimes(t *testing.T) {
+	gh := NewHistogram(5, 10, 2.0)
+	gh.SetTrackSampleTimes(true)
+	gh.Add(1, 1)
+
+	b, err := json.Marshal(gh)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	var decoded Histogram
+	if err = json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	first, last := gh.SampleTimes()
+	first2, last2 := decoded.SampleTimes()
+	if !first.Equal(first2) || !last.Equal(last2) {
+		t.Errorf("expected sample times after JSON round trip, got: %s", b)
+	}
+}
@@ -0,0 +1,53 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+// Layout is a named combination of the binFirst and binGrowthFactor
+// parameters of NewNamedHistogram().
+type Layout struct {
+	Name            string
+	BinFirst        uint64
+	BinGrowthFactor float64
+}
+
+// Common layouts, to avoid copying magic numbers around.
+var (
+	// Base2 bins double in width: 0, 1, 2, 4, 8, ...
+	Base2 = Layout{"base2", 1, 2.0}
+
+	// Base10Decades bins are decades: 0, 1, 10, 100, 1000, ...
+	Base10Decades = Layout{"base10decades", 1, 10.0}
+
+	// QuarterPowers bins grow by 2^(1/4), rounded up, so that wider
+	// bins split every power of two into about four bins: 0, 1, 2, 3,
+	// 4, 5, 6, 8, 10, 12, 15, 18, 22, 27, 33, 40, 48, ...
+	QuarterPowers = Layout{"quarterpowers", 1, 1.189207115002721}
+)
+
+// Layouts lists the predefined layouts.
+var Layouts = []Layout{Base2, Base10Decades, QuarterPowers}
+
+// New creates a new, ready to use Histogram with the layout, see
+// NewNamedHistogram().
+func (l Layout) New(name string, numBins int) *Histogram {
+	return NewNamedHistogram(name, numBins, l.BinFirst, l.BinGrowthFactor)
+}
+
+// Ranges returns the [low, high) data point domain of every bin of a
+// histogram of numBins bins with the layout, see BinRanges(), or nil
+// when numBins is invalid or the bins would overflow.
+func (l Layout) Ranges(numBins int) [][2]uint64 {
+	gh, err := NewNamedHistogramChecked(l.Name,
+		numBins, l.BinFirst, l.BinGrowthFactor)
+	if err != nil {
+		return nil
+	}
+
+	return gh.BinRanges()
+}
@@ -0,0 +1,53 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestLayouts(t *testing.T) {
+	tests := []struct {
+		layout    Layout
+		numBins   int
+		expRanges [][2]uint64
+	}{
+		{Base2, 5, [][2]uint64{
+			{0, 1}, {1, 2}, {2, 4}, {4, 8}, {8, math.MaxUint64}}},
+		{Base10Decades, 4, [][2]uint64{
+			{0, 1}, {1, 10}, {10, 100}, {100, math.MaxUint64}}},
+		{QuarterPowers, 8, [][2]uint64{
+			{0, 1}, {1, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 6}, {6, 8},
+			{8, math.MaxUint64}}},
+		{Base2, 0, nil},
+		{Base10Decades, 30, nil},
+	}
+
+	for testi, test := range tests {
+		got := test.layout.Ranges(test.numBins)
+		if !reflect.DeepEqual(got, test.expRanges) {
+			t.Errorf("test #%d, %s, exp: %v, got: %v",
+				testi, test.layout.Name, test.expRanges, got)
+		}
+
+		if test.expRanges != nil {
+			gh := test.layout.New("x", test.numBins)
+			if !reflect.DeepEqual(gh.BinRanges(), test.expRanges) {
+				t.Errorf("test #%d, New() ranges mismatch: %v",
+					testi, gh.BinRanges())
+			}
+		}
+	}
+
+	if len(Layouts) != 3 {
+		t.Errorf("expected 3 predefined layouts")
+	}
+}
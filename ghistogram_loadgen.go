@@ -0,0 +1,162 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// LoadGen drives Add()'s into a histogram from concurrent goroutines
+// using deterministic, seeded pseudo-random data points, and then
+// validates that no updates were lost.  It's meant for soak and
+// stress testing.
+type LoadGen struct {
+	// Goroutines is the number of concurrent adders, defaults to 1.
+	Goroutines int
+
+	// AddsPerGoroutine is the number of Add()'s each goroutine makes.
+	AddsPerGoroutine int
+
+	// Seed for the pseudo-random data points.  Goroutine i uses
+	// Seed+i, so a given LoadGen always produces the same data.
+	Seed int64
+
+	// Dist generates the data points, defaults to UniformDist(1000).
+	Dist func(r *rand.Rand) uint64
+
+	// Rate optionally limits the Add()'s per second per goroutine,
+	// where 0 means unthrottled.
+	Rate int
+}
+
+// UniformDist returns a distribution of data points uniformly
+// spread over [0, max), or always 0 when max is 0.
+func UniformDist(max uint64) func(r *rand.Rand) uint64 {
+	if max == 0 {
+		return func(r *rand.Rand) uint64 { return 0 }
+	}
+
+	if max > math.MaxInt64 {
+		return func(r *rand.Rand) uint64 {
+			for {
+				if v := r.Uint64(); v < max {
+					return v
+				}
+			}
+		}
+	}
+
+	return func(r *rand.Rand) uint64 {
+		return uint64(r.Int63n(int64(max)))
+	}
+}
+
+// ExpDist returns an exponential distribution of data points with
+// the given mean, which resembles typical latency timings.
+func ExpDist(mean float64) func(r *rand.Rand) uint64 {
+	return func(r *rand.Rand) uint64 {
+		return uint64(r.ExpFloat64() * mean)
+	}
+}
+
+// Run drives the configured load into the histogram and then
+// validates the count, data point and per bin invariants, returning
+// an error describing the first violation found.
+func (lg *LoadGen) Run(gh *Histogram) error {
+	goroutines := lg.Goroutines
+	if goroutines <= 0 {
+		goroutines = 1
+	}
+
+	dist := lg.Dist
+	if dist == nil {
+		dist = UniformDist(1000)
+	}
+
+	var throttle time.Duration
+	if lg.Rate > 0 {
+		throttle = time.Second / time.Duration(lg.Rate)
+	}
+
+	before := gh.CloneEmpty()
+	before.AddAll(gh)
+
+	// Each goroutine tracks what it added to a private histogram, so
+	// the expected result is known without any extra contention.
+	expected := make([]*Histogram, goroutines)
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		expected[g] = gh.CloneEmpty()
+
+		wg.Add(1)
+		go func(r *rand.Rand, exp *Histogram) {
+			for i := 0; i < lg.AddsPerGoroutine; i++ {
+				dataPoint := dist(r)
+				gh.Add(dataPoint, 1)
+				exp.addUNLOCKED(dataPoint, 1)
+
+				if throttle > 0 {
+					time.Sleep(throttle)
+				}
+			}
+			wg.Done()
+		}(rand.New(rand.NewSource(lg.Seed+int64(g))), expected[g])
+	}
+	wg.Wait()
+
+	for _, exp := range expected {
+		before.AddAll(exp)
+	}
+
+	return checkSame(before, gh)
+}
+
+// checkSame returns an error if the counts and data point statistics
+// of the two histograms differ.
+func checkSame(exp, got *Histogram) error {
+	exp.m.Lock()
+	defer exp.m.Unlock()
+	got.m.Lock()
+	defer got.m.Unlock()
+
+	if exp.TotCount != got.TotCount {
+		return fmt.Errorf("ghistogram: TotCount mismatch, exp: %d, got: %d",
+			exp.TotCount, got.TotCount)
+	}
+	if exp.TotDataPoint != got.TotDataPoint {
+		return fmt.Errorf("ghistogram: TotDataPoint mismatch,"+
+			" exp: %d, got: %d", exp.TotDataPoint, got.TotDataPoint)
+	}
+	if exp.MinDataPoint != got.MinDataPoint ||
+		exp.MaxDataPoint != got.MaxDataPoint {
+		return fmt.Errorf("ghistogram: Min/MaxDataPoint mismatch,"+
+			" exp: %d/%d, got: %d/%d", exp.MinDataPoint, exp.MaxDataPoint,
+			got.MinDataPoint, got.MaxDataPoint)
+	}
+
+	var sum uint64
+	for i := range exp.Counts {
+		if exp.Counts[i] != got.Counts[i] {
+			return fmt.Errorf("ghistogram: bin %d mismatch, exp: %d, got: %d",
+				i, exp.Counts[i], got.Counts[i])
+		}
+		sum += got.Counts[i]
+	}
+	if sum != got.TotCount {
+		return fmt.Errorf("ghistogram: TotCount (%d) != sum of Counts (%d)",
+			got.TotCount, sum)
+	}
+
+	return nil
+}
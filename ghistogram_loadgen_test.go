@@ -0,0 +1,63 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestLoadGen(t *testing.T) {
+	lg := &LoadGen{
+		Goroutines:       8,
+		AddsPerGoroutine: 10000,
+		Seed:             42,
+		Dist:             ExpDist(100),
+	}
+
+	gh1 := NewHistogram(20, 10, 1.5)
+	if err := lg.Run(gh1); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if gh1.TotCount != 80000 {
+		t.Errorf("TotCount wrong, got: %d", gh1.TotCount)
+	}
+
+	// The same LoadGen must produce the same data points.
+	gh2 := NewHistogram(20, 10, 1.5)
+	if err := lg.Run(gh2); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if err := checkSame(gh1, gh2); err != nil {
+		t.Errorf("expected deterministic load, err: %v", err)
+	}
+
+	// Running again on a non-empty histogram accounts for prior counts.
+	if err := lg.Run(gh2); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+}
+
+func TestUniformDist(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+
+	if v := UniformDist(0)(r); v != 0 {
+		t.Errorf("expected UniformDist(0) to be 0, got: %d", v)
+	}
+
+	for _, max := range []uint64{1, 1000, math.MaxInt64 + 1, math.MaxUint64} {
+		dist := UniformDist(max)
+		for i := 0; i < 100; i++ {
+			if v := dist(r); v >= max {
+				t.Errorf("max: %d, got out of range: %d", max, v)
+			}
+		}
+	}
+}
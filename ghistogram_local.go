@@ -0,0 +1,141 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"sync"
+	"time"
+)
+
+// LocalRecorder removes the contention of many goroutines adding to
+// one shared parent histogram.  Each worker goroutine adds to its own
+// local histogram, see Local(), and the local histograms are
+// periodically folded into the parent, see Fold().  The lock of a
+// local histogram is only contended by a fold, so adding to it stays
+// on the fast path.  The parent lags its locals until the next fold.
+type LocalRecorder struct {
+	parent *Histogram
+
+	m      sync.Mutex
+	locals map[*Histogram]struct{}
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+// NewLocalRecorder creates a LocalRecorder folding into the parent
+// histogram every interval of the parent's clock, see SetClock(),
+// until Stop() is invoked.  An interval <= 0 means folding only
+// happens on demand.
+func NewLocalRecorder(parent *Histogram,
+	interval time.Duration) *LocalRecorder {
+	r := &LocalRecorder{
+		parent: parent,
+		locals: make(map[*Histogram]struct{}),
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+
+	if interval > 0 {
+		parent.m.Lock()
+		ticker := clockTicker(parent.clock, interval)
+		parent.m.Unlock()
+
+		go r.run(ticker)
+	} else {
+		close(r.doneCh)
+	}
+
+	return r
+}
+
+func (r *LocalRecorder) run(ticker Ticker) {
+	defer ticker.Stop()
+	defer close(r.doneCh)
+
+	for {
+		select {
+		case <-r.stopCh:
+			return
+		case <-ticker.Chan():
+			r.Fold()
+		}
+	}
+}
+
+// Local returns a new local histogram, shaped like the parent, for
+// the exclusive use of one goroutine.  The local histogram should be
+// passed to Release() when the goroutine is done with it.  Only the
+// parent auto ranges, see SetAutoRange(), so the local histograms keep
+// a layout the parent's bins can be derived from.
+func (r *LocalRecorder) Local() *Histogram {
+	r.parent.m.Lock()
+	local := r.parent.CloneEmpty()
+	r.parent.m.Unlock()
+
+	local.autoRangeFraction = 0
+
+	r.m.Lock()
+	r.locals[local] = struct{}{}
+	r.m.Unlock()
+
+	return local
+}
+
+// Release folds the local histogram into the parent one last time and
+// stops tracking it.
+func (r *LocalRecorder) Release(local *Histogram) {
+	r.m.Lock()
+	delete(r.locals, local)
+	r.m.Unlock()
+
+	r.fold(local)
+}
+
+// Fold moves the counts of all the local histograms into the parent.
+// Each local histogram is captured and reset under its lock, so no
+// data points are lost or counted twice.
+func (r *LocalRecorder) Fold() {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	for local := range r.locals {
+		if local.Total() > 0 {
+			r.fold(local)
+		}
+	}
+}
+
+// fold moves the counts of the local histogram into the parent, first
+// rebinning them like the parent when it auto ranged since the local
+// histogram was created.
+func (r *LocalRecorder) fold(local *Histogram) {
+	src := local.capture(true)
+	origRanges, origCounts := src.Ranges, src.Counts
+
+	for !r.parent.addAllSameLayout(src) {
+		ranges, counts, ok := rebinDouble(src.Ranges, src.Counts)
+		if !ok {
+			// Not a layout the parent rebinned to, so as before.
+			src.Ranges, src.Counts = origRanges, origCounts
+			r.parent.AddAll(src)
+			return
+		}
+		src.Ranges, src.Counts = ranges, counts
+	}
+}
+
+// Stop stops the periodic folding and does a final Fold().  It's safe
+// to invoke Stop more than once.
+func (r *LocalRecorder) Stop() {
+	r.stopOnce.Do(func() { close(r.stopCh) })
+	<-r.doneCh
+	r.Fold()
+}
@@ -0,0 +1,109 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLocalRecorder(t *testing.T) {
+	parent := NewHistogram(10, 10, 2.0)
+	r := NewLocalRecorder(parent, time.Millisecond)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+
+			local := r.Local()
+			for i := 0; i < 10000; i++ {
+				local.Add(uint64(i%100), 1)
+			}
+
+			if g%2 == 0 {
+				r.Release(local)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	r.Stop()
+	r.Stop()
+
+	if parent.TotCount != 80000 {
+		t.Errorf("expected 80000 after final fold, got: %d", parent.TotCount)
+	}
+
+	exp := NewHistogram(10, 10, 2.0)
+	for i := 0; i < 80000; i++ {
+		exp.Add(uint64(i%100), 1)
+	}
+	if err := checkSame(exp, parent); err != nil {
+		t.Errorf("unexpected parent: %v", err)
+	}
+}
+
+func TestLocalRecorderOnDemand(t *testing.T) {
+	parent := NewHistogram(10, 10, 2.0)
+	r := NewLocalRecorder(parent, 0)
+
+	local := r.Local()
+	local.Add(5, 3)
+	if parent.TotCount != 0 {
+		t.Errorf("expected parent to lag until a fold")
+	}
+
+	r.Fold()
+	r.Fold()
+	if parent.TotCount != 3 || local.TotCount != 0 {
+		t.Errorf("expected fold to move counts, parent: %d, local: %d",
+			parent.TotCount, local.TotCount)
+	}
+
+	r.Stop()
+}
+
+func TestLocalRecorderAutoRange(t *testing.T) {
+	parent := NewHistogram(5, 10, 0.0)
+	parent.SetAutoRange(0.5, 10)
+	r := NewLocalRecorder(parent, 0)
+
+	// Enough data points beyond the range to auto range the local, were
+	// it not for the parent owning the layout.
+	local := r.Local()
+	local.Add(100, 30)
+	local.Add(5, 2)
+	local.Add(15, 3)
+	if local.Ranges[4] != 40 {
+		t.Errorf("expected the local not to auto range, got: %v",
+			local.Ranges)
+	}
+
+	parent.Add(100, 30)
+	if parent.Ranges[4] == 40 {
+		t.Fatalf("expected the parent to auto range, got: %v", parent.Ranges)
+	}
+
+	// The fold rebins the local counts like the parent's, rather than
+	// adding them to the bins of the same index.
+	r.Release(local)
+	r.Stop()
+
+	expRanges := []uint64{0, 20, 40, 60, 80}
+	expCounts := []uint64{5, 0, 60, 0, 0}
+	if !reflect.DeepEqual(parent.Ranges, expRanges) ||
+		!reflect.DeepEqual(parent.Counts, expCounts) ||
+		parent.TotCount != 65 {
+		t.Errorf("unexpected parent: %v, %v", parent.Ranges, parent.Counts)
+	}
+}
@@ -0,0 +1,66 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"time"
+)
+
+// NewLogLinearHistogram creates a new, ready to use Histogram whose
+// bins follow the HdrHistogram layout, where every power of two range
+// is split into linear sub-bins.  This bounds the relative width of
+// every bin, and so the error of percentiles, to 10^-sigDigits across
+// all the orders of magnitude from lowest to highest, unlike the 2x
+// width of the top bins of a binGrowthFactor of 2.0.
+//
+// The lowest must be >= 1, the highest >= 2 * lowest and the
+// sigDigits in [1, 5], otherwise NewLogLinearHistogram panics.  The
+// bins are identical to those of FromHdr() for the same parameters.
+func NewLogLinearHistogram(name string,
+	lowest, highest uint64, sigDigits int) *Histogram {
+	gh, err := NewLogLinearHistogramChecked(name, lowest, highest, sigDigits)
+	if err != nil {
+		panic(err)
+	}
+
+	return gh
+}
+
+// NewLogLinearHistogramChecked is like NewLogLinearHistogram(), but
+// returns an error instead of panicking on invalid parameters.
+func NewLogLinearHistogramChecked(name string,
+	lowest, highest uint64, sigDigits int) (*Histogram, error) {
+	if highest > math.MaxInt64 {
+		highest = math.MaxInt64
+	}
+
+	l, err := newHdrLayout(int64(lowest), int64(highest), int64(sigDigits))
+	if err != nil {
+		return nil, err
+	}
+
+	gh := &Histogram{
+		Name:         name,
+		Ranges:       make([]uint64, l.countsLen),
+		Counts:       make([]uint64, l.countsLen),
+		MinDataPoint: math.MaxUint64,
+		start:        time.Now(),
+	}
+
+	for i := range gh.Ranges {
+		gh.Ranges[i] = l.valueFromIndex(i)
+	}
+
+	if err := gh.Validate(); err != nil {
+		return nil, err
+	}
+
+	return gh, nil
+}
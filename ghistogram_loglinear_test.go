@@ -0,0 +1,48 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"testing"
+)
+
+func TestNewLogLinearHistogram(t *testing.T) {
+	gh := NewLogLinearHistogram("TestLogLinear", 1, 3600000000, 2)
+
+	if gh.Ranges[0] != 0 || len(gh.Ranges) != len(gh.Counts) {
+		t.Fatalf("unexpected ranges")
+	}
+
+	// Beyond the unit width bins, every bin is at most 1% wide.
+	for i := 1; i < len(gh.Ranges)-1; i++ {
+		lower, upper := gh.Ranges[i], gh.Ranges[i+1]
+		if upper <= lower {
+			t.Fatalf("ranges not increasing at %d: %d, %d", i, lower, upper)
+		}
+		if upper-lower > 1 && (upper-lower)*100 > lower {
+			t.Errorf("bin %d too wide: [%d - %d)", i, lower, upper)
+		}
+	}
+
+	if gh.Ranges[len(gh.Ranges)-1] < 3600000000/2 {
+		t.Errorf("ranges don't reach highest: %d", gh.Ranges[len(gh.Ranges)-1])
+	}
+
+	gh.Add(123456789, 1)
+	if p := gh.Percentile(50); p != 123456789 {
+		t.Errorf("Percentile wrong, got: %d", p)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic on invalid sigDigits")
+		}
+	}()
+	NewLogLinearHistogram("invalid", 1, 1000, 6)
+}
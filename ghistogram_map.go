@@ -10,7 +10,9 @@ package ghistogram
 
 import (
 	"errors"
+	"fmt"
 	"io"
+	"sort"
 	"strings"
 )
 
@@ -20,11 +22,62 @@ type Histograms map[string]*Histogram
 
 // API that converts the contents of all histograms within
 // the map into a string and returns the string to caller.
+// The histograms are emitted in the order of their names.
 func (hmap Histograms) String() string {
+	return hmap.StringOrdered(nil)
+}
+
+// StringOrdered is like String(), but emits the histograms in the
+// order of their names as defined by the less func.  A nil less
+// orders the names lexicographically.
+func (hmap Histograms) StringOrdered(less func(a, b string) bool) string {
 	var output []string
 
+	for _, k := range hmap.SortedNames(less) {
+		output = append(output, hmap[k].EmitGraph(nil, nil).String())
+	}
+
+	return strings.Join(output, "\n")
+}
+
+// SortedNames returns the names of the histograms within the map,
+// ordered by the less func, or lexicographically if less is nil.
+func (hmap Histograms) SortedNames(less func(a, b string) bool) []string {
+	names := make([]string, 0, len(hmap))
+	for k := range hmap {
+		names = append(names, k)
+	}
+
+	if less == nil {
+		sort.Strings(names)
+	} else {
+		sort.Slice(names, func(i, j int) bool {
+			return less(names[i], names[j])
+		})
+	}
+
+	return names
+}
+
+// StringWithShares is like String(), but the header of each graph
+// also shows that histogram's share of the total events across all
+// the histograms of the map, e.g. "get (900 Total, 90.00% of 1000)".
+func (hmap Histograms) StringWithShares() string {
+	var groupTotCount uint64
 	for _, v := range hmap {
-		output = append(output, v.EmitGraph(nil, nil).String())
+		v.m.Lock()
+		groupTotCount += v.TotCount
+		v.m.Unlock()
+	}
+
+	var output []string
+
+	for _, k := range hmap.SortedNames(nil) {
+		v := hmap[k]
+		v.m.Lock()
+		output = append(output,
+			v.emitGraphUNLOCKED(nil, nil, groupTotCount).String())
+		v.m.Unlock()
 	}
 
 	return strings.Join(output, "\n")
@@ -40,28 +93,85 @@ func (hmap Histograms) Fprint(w io.Writer) (int, error) {
 // Adds all entries/records from all histograms within the
 // given map, to all histograms in the current map.
 // If a histogram from the source doesn't exist in the
-// destination map, it will be created first.
+// destination map, it will be created first.  The layouts
+// are checked up front, and again while each histogram is
+// added, in case an auto ranging histogram rebinned since.
 func (hmap Histograms) AddAll(srcmap Histograms) error {
 	for k, v := range srcmap {
 		if hmap[k] == nil {
 			// Histogram entry not found, create a new one, based
 			// on the same creation parameters
-			hmap[k] = v.CloneEmpty()
-		} else if (len(hmap[k].Counts) != len(v.Counts)) ||
-			(len(hmap[k].Ranges) != len(v.Ranges)) {
+			hmap[k] = v.cloneEmptyLocked()
+		} else if !hmap[k].SameLayout(v) {
 			return errors.New("Mismatch in histogram creation parameters")
-		} else {
-			for i := 0; i < len(v.Ranges); i++ {
-				if hmap[k].Ranges[i] != v.Ranges[i] {
-					return errors.New("Mismatch in histogram creation parmeters")
-				}
-			}
 		}
 	}
 
 	for k, v := range srcmap {
-		hmap[k].AddAll(v)
+		if !hmap[k].addAllSameLayout(v) {
+			return errors.New("Mismatch in histogram creation parameters")
+		}
 	}
 
 	return nil
 }
+
+// Merged returns a new histogram with the given name that holds the
+// sum of all the histograms within the map, which must all have the
+// same creation parameters.
+func (hmap Histograms) Merged(name string) (*Histogram, error) {
+	var merged *Histogram
+	var firstName string
+
+	for _, k := range hmap.SortedNames(nil) {
+		v := hmap[k]
+		if merged == nil {
+			merged = v.cloneEmptyLocked()
+			merged.Name = name
+			firstName = k
+		}
+
+		if !merged.addAllSameLayout(v) {
+			ranges := v.Snapshot().Ranges
+			return nil, fmt.Errorf("ghistogram: Merged, histogram %q has"+
+				" %d bins %v, mismatching histogram %q with %d bins %v",
+				k, len(ranges), ranges,
+				firstName, len(merged.Ranges), merged.Ranges)
+		}
+	}
+
+	if merged == nil {
+		return nil, errors.New("ghistogram: Merged, no histograms")
+	}
+
+	return merged, nil
+}
+
+// sameRanges returns true when the two histograms have identical bins.
+func sameRanges(a, b *Histogram) bool {
+	if len(a.Ranges) != len(b.Ranges) || len(a.Counts) != len(b.Counts) ||
+		a.boundary != b.boundary {
+		return false
+	}
+
+	for i := 0; i < len(a.Ranges); i++ {
+		if a.Ranges[i] != b.Ranges[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ResetAll resets every histogram within the map.
+func (hmap Histograms) ResetAll() {
+	hmap.ResetAllWithReason("")
+}
+
+// ResetAllWithReason resets every histogram within the map, recording
+// the reason for the reset, see Histogram.ResetWithReason().
+func (hmap Histograms) ResetAllWithReason(reason string) {
+	for _, v := range hmap {
+		v.ResetWithReason(reason)
+	}
+}
@@ -60,3 +60,82 @@ func TestAddAllHistograms(t *testing.T) {
 		t.Errorf("Unexpected content in String() after AddAll")
 	}
 }
+
+func TestStringWithSharesHistograms(t *testing.T) {
+	histograms, _, _ := initAndFetchHistograms(t)
+
+	output := histograms.StringWithShares()
+	if !strings.Contains(output, "test1 (µs) (6 Total, 60.00% of 10)\n") ||
+		!strings.Contains(output, "test2 (µs) (4 Total, 40.00% of 10)\n") {
+		t.Errorf("Unexpected content in StringWithShares(), got: %s", output)
+	}
+}
+
+func TestStringOrderedHistograms(t *testing.T) {
+	histograms, exp1, exp2 := initAndFetchHistograms(t)
+
+	if histograms.String() != exp1+"\n"+exp2 {
+		t.Errorf("Unexpected order in String()")
+	}
+
+	output := histograms.StringOrdered(func(a, b string) bool {
+		return a > b
+	})
+	if output != exp2+"\n"+exp1 {
+		t.Errorf("Unexpected order in StringOrdered()")
+	}
+}
+
+func TestMergedHistograms(t *testing.T) {
+	histograms, _, _ := initAndFetchHistograms(t)
+
+	merged, err := histograms.Merged("all (µs)")
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	exp := `all (µs) (10 Total)
+[0 - 2]   20.00%   20.00% ############ (2)
+[2 - 4]   50.00%   70.00% ############################## (5)
+[4 - 8]   30.00%  100.00% ################## (3)
+`
+	if merged.EmitGraph(nil, nil).String() != exp {
+		t.Errorf("Unexpected content in Merged(), got: %s",
+			merged.EmitGraph(nil, nil).String())
+	}
+
+	histograms["test3"] = NewNamedHistogram("test3", 5, 2, 2)
+	if _, err = histograms.Merged("all"); err == nil {
+		t.Errorf("expected err on mismatched histograms")
+	}
+
+	if _, err = make(Histograms).Merged("all"); err == nil {
+		t.Errorf("expected err on empty histograms")
+	}
+}
+
+func TestResetAllHistograms(t *testing.T) {
+	histograms, _, _ := initAndFetchHistograms(t)
+
+	histograms.ResetAllWithReason("rebalance started")
+
+	output := histograms.String()
+	if strings.Count(output, ": rebalance started)\n") != 2 ||
+		!strings.Contains(output, "test1 (µs) (0 Total)\n(reset at ") {
+		t.Errorf("Unexpected content in String() after ResetAll, got: %s",
+			output)
+	}
+
+	histograms["test1"].Reset()
+	if !strings.Contains(histograms.String(), "test1 (µs) (0 Total)\n(empty)") {
+		t.Errorf("expected Reset() to clear the reset reason")
+	}
+
+	histograms["test2"].Add(5, 1)
+	histograms.ResetAll()
+	if histograms["test2"].TotCount != 0 ||
+		strings.Contains(histograms.String(), "(reset at ") {
+		t.Errorf("expected ResetAll() to reset without a reason, got: %s",
+			histograms.String())
+	}
+}
@@ -0,0 +1,91 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HTTPMiddleware returns a middleware that records the duration of
+// every request served by the wrapped http.Handler into the child
+// histogram of the vec for the request's method, route and status
+// code, so the vec must have exactly those three labels, for example:
+//
+//    vec := ghistogram.NewHistogramVec(
+//        ghistogram.NewNamedHistogram("http", 20, 100, 2.0).
+//            WithUnit("µs"), "method", "route", "status")
+//    http.ListenAndServe(addr, ghistogram.HTTPMiddleware(vec)(mux))
+//
+// The route is the matched pattern when the wrapped handler is an
+// *http.ServeMux, so the number of children stays bounded, and the URL
+// path otherwise.  The durations are recorded in the time unit of the
+// vec's histograms, see WithUnit(), or in microseconds by default.
+func HTTPMiddleware(h *HistogramVec) func(http.Handler) http.Handler {
+	if len(h.labelNames) != 3 {
+		panic(fmt.Sprintf("ghistogram: HTTPMiddleware, expected 3 labels"+
+			" for the method, route and status, got: %d", len(h.labelNames)))
+	}
+
+	unit := h.proto.durationUnit()
+
+	return func(next http.Handler) http.Handler {
+		mux, _ := next.(*http.ServeMux)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			sr := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sr, r)
+
+			d := time.Since(start)
+
+			route := r.URL.Path
+			if mux != nil {
+				_, route = mux.Handler(r)
+			}
+
+			h.WithLabelValues(r.Method, route, strconv.Itoa(sr.status)).
+				Add(uint64(d/unit), 1)
+		})
+	}
+}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	if !sr.wroteHeader {
+		sr.status, sr.wroteHeader = status, true
+	}
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	sr.wroteHeader = true
+	return sr.ResponseWriter.Write(b)
+}
+
+// Flush supports streaming handlers when the wrapped writer does.
+func (sr *statusRecorder) Flush() {
+	if f, ok := sr.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap supports http.ResponseController.
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
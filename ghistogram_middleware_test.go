@@ -0,0 +1,83 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/docs/", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(2 * time.Millisecond)
+		w.Write([]byte("doc"))
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	})
+
+	vec := NewHistogramVec(NewNamedHistogram("http", 10, 1, 2.0).
+		WithUnit("ms"), "method", "route", "status")
+
+	h := HTTPMiddleware(vec)(mux)
+
+	for _, path := range []string{"/docs/a", "/docs/b", "/missing", "/x"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PUT", "/docs/c", nil))
+
+	hmap := vec.Histograms()
+
+	tests := []struct {
+		labels string
+		count  uint64
+	}{
+		{"method=GET,route=/docs/,status=200", 2},
+		{"method=PUT,route=/docs/,status=200", 1},
+		{"method=GET,route=/missing,status=410", 1},
+		{"method=GET,route=,status=404", 1},
+	}
+
+	if len(hmap) != len(tests) {
+		t.Errorf("expected %d children, got: %v", len(tests), hmap)
+	}
+
+	for _, test := range tests {
+		gh := hmap[test.labels]
+		if gh == nil || gh.TotCount != test.count {
+			t.Errorf("expected %s count %d, got: %v",
+				test.labels, test.count, gh)
+		}
+	}
+
+	// The duration is recorded in the unit of the histograms.
+	if gh := hmap["method=GET,route=/docs/,status=200"]; gh.MinDataPoint < 2 ||
+		gh.MaxDataPoint > 1000 {
+		t.Errorf("expected durations in ms, got: %v", gh)
+	}
+
+	// Without a ServeMux, the route is the URL path.
+	vec = NewHistogramVec(NewNamedHistogram("http", 10, 1, 2.0),
+		"method", "route", "status")
+	HTTPMiddleware(vec)(http.NotFoundHandler()).ServeHTTP(
+		httptest.NewRecorder(), httptest.NewRequest("GET", "/a/b", nil))
+	if vec.Histograms()["method=GET,route=/a/b,status=404"] == nil {
+		t.Errorf("expected URL path route, got: %v", vec.Histograms())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic for a vec without 3 labels")
+		}
+	}()
+	HTTPMiddleware(NewHistogramVec(NewNamedHistogram("http", 10, 1, 2.0), "op"))
+}
@@ -0,0 +1,21 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+//go:build !ghistogram_audit
+// +build !ghistogram_audit
+
+package ghistogram
+
+// auditState is a no-op unless built with the ghistogram_audit tag.
+type auditState struct{}
+
+func (a *auditState) enter() {}
+
+func (a *auditState) exit() {}
+
+func (a *auditState) assertHeld(op string) {}
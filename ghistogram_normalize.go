@@ -0,0 +1,60 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+// NormalizedBins is the export of a histogram where each bin's count
+// is normalized to the count of the fullest bin, as required by some
+// heat-map dashboards.
+type NormalizedBins struct {
+	Ranges []uint64  // The lower bound of each bin.
+	Values []float64 // In [0, 100], where the fullest bin is 100.
+}
+
+// NormalizedToMax returns the bin counts normalized to the fullest
+// bin, in the range [0, 100].  All values are 0 for an empty
+// histogram.
+func (gh *Histogram) NormalizedToMax() NormalizedBins {
+	gh.m.Lock()
+	rv := gh.normalizedToMaxUNLOCKED()
+	gh.m.Unlock()
+	return rv
+}
+
+func (gh *Histogram) normalizedToMaxUNLOCKED() NormalizedBins {
+	rv := NormalizedBins{
+		Ranges: make([]uint64, len(gh.Counts)),
+		Values: make([]float64, len(gh.Counts)),
+	}
+
+	var maxCount uint64
+	for i, c := range gh.Counts {
+		rv.Ranges[i] = gh.rangeLabel(gh.Ranges[i])
+		if c > maxCount {
+			maxCount = c
+		}
+	}
+
+	if maxCount > 0 {
+		for i, c := range gh.Counts {
+			rv.Values[i] = float64(c) * 100 / float64(maxCount)
+		}
+	}
+
+	return rv
+}
+
+// NormalizedToMax returns the normalized bins of every histogram of
+// the map, keyed by name, see Histogram.NormalizedToMax().
+func (hmap Histograms) NormalizedToMax() map[string]NormalizedBins {
+	rv := make(map[string]NormalizedBins, len(hmap))
+	for name, gh := range hmap {
+		rv[name] = gh.NormalizedToMax()
+	}
+	return rv
+}
@@ -0,0 +1,45 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizedToMax(t *testing.T) {
+	gh := NewHistogram(4, 10, 0.0)
+
+	exp := NormalizedBins{
+		Ranges: []uint64{0, 10, 20, 30},
+		Values: []float64{0, 0, 0, 0},
+	}
+	if got := gh.NormalizedToMax(); !reflect.DeepEqual(got, exp) {
+		t.Errorf("empty, expected: %+v, got: %+v", exp, got)
+	}
+
+	gh.Add(5, 2)
+	gh.Add(25, 8)
+	gh.Add(35, 1)
+
+	exp.Values = []float64{25, 0, 100, 12.5}
+	if got := gh.NormalizedToMax(); !reflect.DeepEqual(got, exp) {
+		t.Errorf("expected: %+v, got: %+v", exp, got)
+	}
+
+	gh.SetTransform(DivTransform{Unit: 1000})
+	if got := gh.NormalizedToMax().Ranges; got[1] != 10000 {
+		t.Errorf("expected ranges in data point units, got: %v", got)
+	}
+
+	m := Histograms{"a": gh}.NormalizedToMax()
+	if len(m) != 1 || m["a"].Values[2] != 100 {
+		t.Errorf("unexpected map export: %+v", m)
+	}
+}
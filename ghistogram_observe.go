@@ -0,0 +1,110 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"time"
+)
+
+// Rounding selects how float64 values are converted to uint64 data
+// points, as truncation biases bins of small values, like microsecond
+// latencies, downwards.
+type Rounding int
+
+const (
+	// RoundFloor truncates towards zero.  This is the default.
+	RoundFloor Rounding = iota
+
+	// RoundHalfEven rounds to the nearest integer, and ties to even.
+	RoundHalfEven
+
+	// RoundCeil rounds up.
+	RoundCeil
+)
+
+// SetRounding changes how Observe() converts float64 values.
+func (gh *Histogram) SetRounding(rounding Rounding) {
+	gh.m.Lock()
+	gh.rounding = rounding
+	gh.m.Unlock()
+}
+
+// Observe adds a float64 value with a count of 1, converting it to a
+// data point according to the histogram's Rounding.  Negative values
+// are treated as 0, values beyond math.MaxUint64 as math.MaxUint64,
+// and NaN's are ignored.
+func (gh *Histogram) Observe(v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+
+	gh.m.Lock()
+	gh.addUNLOCKED(roundToUint64(v, gh.rounding), 1)
+	gh.m.Unlock()
+}
+
+// Observer records float64 values, and matches the prometheus.Observer
+// interface, so code written against that interface can record into
+// histograms directly.  A *Histogram is an Observer of values that are
+// already in its data point units, see SecondsObserver() for durations
+// in seconds.
+type Observer interface {
+	Observe(float64)
+}
+
+// ObserverFunc adapts a function to the Observer interface.
+type ObserverFunc func(float64)
+
+// Observe invokes the function.
+func (f ObserverFunc) Observe(v float64) {
+	f(v)
+}
+
+var _ Observer = (*Histogram)(nil)
+
+// SecondsObserver returns an Observer of durations in seconds, like
+// the ones observed by the prometheus instrumentation, which records
+// them in the time unit of the histogram, see WithUnit(), or in
+// microseconds by default, for example:
+//
+//    gh := ghistogram.NewNamedHistogram("get", 20, 10, 2.0).WithUnit("µs")
+//    timer := prometheus.NewTimer(gh.SecondsObserver())
+//    defer timer.ObserveDuration()
+func (gh *Histogram) SecondsObserver() Observer {
+	gh.m.Lock()
+	perSecond := float64(time.Second / gh.durationUnit())
+	gh.m.Unlock()
+
+	return ObserverFunc(func(v float64) {
+		gh.Observe(v * perSecond)
+	})
+}
+
+// roundToUint64 converts v to a uint64 according to the rounding,
+// saturating at 0 and math.MaxUint64.
+func roundToUint64(v float64, rounding Rounding) uint64 {
+	switch rounding {
+	case RoundHalfEven:
+		v = math.RoundToEven(v)
+	case RoundCeil:
+		v = math.Ceil(v)
+	default:
+		v = math.Floor(v)
+	}
+
+	if v <= 0 {
+		return 0
+	}
+	if v >= math.MaxUint64 {
+		return math.MaxUint64
+	}
+
+	return uint64(v)
+}
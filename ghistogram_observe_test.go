@@ -0,0 +1,90 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRoundToUint64(t *testing.T) {
+	tests := []struct {
+		v        float64
+		rounding Rounding
+		exp      uint64
+	}{
+		{1.5, RoundFloor, 1},
+		{1.5, RoundHalfEven, 2},
+		{2.5, RoundHalfEven, 2},
+		{2.51, RoundHalfEven, 3},
+		{1.01, RoundCeil, 2},
+		{2, RoundCeil, 2},
+		{-1, RoundFloor, 0},
+		{-0.4, RoundCeil, 0},
+		{1e30, RoundFloor, math.MaxUint64},
+		{math.Inf(1), RoundHalfEven, math.MaxUint64},
+	}
+
+	for testi, test := range tests {
+		got := roundToUint64(test.v, test.rounding)
+		if got != test.exp {
+			t.Errorf("test #%d, v: %v, rounding: %d, exp: %d, got: %d",
+				testi, test.v, test.rounding, test.exp, got)
+		}
+	}
+}
+
+func TestObserve(t *testing.T) {
+	gh := NewHistogram(3, 10, 0.0)
+
+	gh.Observe(9.9)
+	gh.SetRounding(RoundHalfEven)
+	gh.Observe(9.9)
+	gh.Observe(math.NaN())
+
+	if gh.Counts[0] != 1 || gh.Counts[1] != 1 || gh.TotCount != 2 {
+		t.Errorf("unexpected counts: %v", gh.Counts)
+	}
+
+	if gh.CloneEmpty().rounding != RoundHalfEven {
+		t.Errorf("expected CloneEmpty to keep the rounding")
+	}
+}
+
+func TestSecondsObserver(t *testing.T) {
+	tests := []struct {
+		unit string
+		exp  uint64
+	}{
+		{"", 2500},
+		{"ns", 2500000},
+		{"us", 2500},
+		{"ms", 2},
+		{"s", 0},
+		{"bytes", 2500},
+	}
+
+	for _, test := range tests {
+		gh := NewHistogram(3, 10, 0.0).WithUnit(test.unit)
+
+		var o Observer = gh.SecondsObserver()
+		o.Observe(0.0025)
+
+		if gh.MaxDataPoint != test.exp || gh.TotCount != 1 {
+			t.Errorf("unit: %q, expected data point %d, got: %d",
+				test.unit, test.exp, gh.MaxDataPoint)
+		}
+	}
+
+	var got float64
+	ObserverFunc(func(v float64) { got = v }).Observe(1.5)
+	if got != 1.5 {
+		t.Errorf("expected ObserverFunc to observe 1.5, got: %v", got)
+	}
+}
@@ -0,0 +1,96 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WriteOpenMetrics writes the histogram in the OpenMetrics, and
+// Prometheus text, exposition format, as a histogram metric family of
+// the metricName with the optional labels on every sample, without
+// depending on the Prometheus client library, for example:
+//
+//    # TYPE kv_get histogram
+//    kv_get_bucket{bucket="default",le="9"} 3
+//    kv_get_bucket{bucket="default",le="19"} 8
+//    kv_get_bucket{bucket="default",le="+Inf"} 9
+//    kv_get_sum{bucket="default"} 157.5
+//    kv_get_count{bucket="default"} 9
+//
+// The "le" buckets are cumulative and inclusive, so for the default
+// RightOpen bins, see BinBoundary, the "le" of a bin is one less than
+// the start of the next bin, as data points are integers.  The sum is
+// estimated from the midpoints of the bins, as the TotDataPoint does
+// not account for the counts of Add().  The writer of the whole
+// exposition appends the terminating "# EOF" line that OpenMetrics
+// requires.
+func (gh *Histogram) WriteOpenMetrics(w io.Writer, metricName string,
+	labels map[string]string) error {
+	s := gh.Snapshot()
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var pairs []string
+	for _, name := range names {
+		pairs = append(pairs, name+`="`+openMetricsEscape(labels[name])+`"`)
+	}
+
+	withLabels := func(extra ...string) string {
+		all := append(append([]string(nil), pairs...), extra...)
+		if len(all) == 0 {
+			return ""
+		}
+		return "{" + strings.Join(all, ",") + "}"
+	}
+
+	bw := bufio.NewWriter(w)
+
+	bw.WriteString("# TYPE " + metricName + " histogram\n")
+
+	var cumulative uint64
+	for i, c := range s.Counts {
+		cumulative += c
+
+		le := "+Inf"
+		if i < len(s.Counts)-1 {
+			upper := s.rangeLabel(s.Ranges[i+1])
+			if s.boundary == RightOpen {
+				upper--
+			}
+			le = strconv.FormatUint(upper, 10)
+		}
+
+		bw.WriteString(metricName + "_bucket" + withLabels(`le="`+le+`"`) +
+			" " + strconv.FormatUint(cumulative, 10) + "\n")
+	}
+
+	bw.WriteString(metricName + "_sum" + withLabels() + " " +
+		strconv.FormatFloat(s.sumUNLOCKED(), 'f', -1, 64) + "\n")
+	bw.WriteString(metricName + "_count" + withLabels() + " " +
+		strconv.FormatUint(s.TotCount, 10) + "\n")
+
+	return bw.Flush()
+}
+
+var openMetricsEscaper = strings.NewReplacer(
+	`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// openMetricsEscape escapes a label value.
+func openMetricsEscape(v string) string {
+	return openMetricsEscaper.Replace(v)
+}
@@ -0,0 +1,71 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteOpenMetrics(t *testing.T) {
+	gh := NewNamedHistogram("get", 3, 10, 0.0)
+	gh.Add(5, 3)
+	gh.Add(15, 5)
+	gh.Add(100, 1)
+
+	rc := NewNamedHistogram("get", 3, 10, 0.0)
+	rc.SetBinBoundary(RightClosed)
+	rc.Add(10, 2)
+
+	tests := []struct {
+		gh     *Histogram
+		labels map[string]string
+		exp    string
+	}{
+		{gh, map[string]string{"op": "get", "bucket": `de"f\`}, `# TYPE kv histogram
+kv_bucket{bucket="de\"f\\",op="get",le="9"} 3
+kv_bucket{bucket="de\"f\\",op="get",le="19"} 8
+kv_bucket{bucket="de\"f\\",op="get",le="+Inf"} 9
+kv_sum{bucket="de\"f\\",op="get"} 157.5
+kv_count{bucket="de\"f\\",op="get"} 9
+`},
+		{rc, nil, `# TYPE kv histogram
+kv_bucket{le="10"} 2
+kv_bucket{le="20"} 2
+kv_bucket{le="+Inf"} 2
+kv_sum 20
+kv_count 2
+`},
+	}
+
+	for testi, test := range tests {
+		var buf bytes.Buffer
+		if err := test.gh.WriteOpenMetrics(&buf, "kv", test.labels); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != test.exp {
+			t.Errorf("test #%d, got:\n%s\nexp:\n%s", testi, buf.String(), test.exp)
+		}
+	}
+}
+
+func TestWriteOpenMetricsWeightedSum(t *testing.T) {
+	gh := NewHistogram(20, 1, 0.0)
+	gh.Add(7, 4)
+	gh.Add(3, 1)
+
+	var buf bytes.Buffer
+	if err := gh.WriteOpenMetrics(&buf, "kv", nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("\nkv_sum 31.5\nkv_count 5\n")) {
+		t.Errorf("expected the sum of the weighted data points, got:\n%s",
+			buf.String())
+	}
+}
@@ -0,0 +1,85 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// KVOperations are the conventional names of the histograms of
+// Couchbase KV operation timings, in the order they are reported.
+var KVOperations = []string{"get", "set", "delete", "durability"}
+
+// EmitOpsReport writes a combined operations timing report of the
+// histograms within the map, for example:
+//
+//    Operation timings
+//    op                count        p50        p90        p99      p99.9        max
+//    get                1000         12         40        130        410        998
+//    set                 200         25         80        240        600        610
+//
+//    get (1000 Total)
+//    [0 - 10]   ...
+//
+// The summary table lists the ops in the given order, followed by any
+// other histograms of the map in name order, and then the graph of
+// each listed histogram.  A nil ops uses KVOperations.  Ops without a
+// histogram in the map are skipped.
+func (hmap Histograms) EmitOpsReport(w io.Writer, ops []string) error {
+	if ops == nil {
+		ops = KVOperations
+	}
+
+	var names []string
+
+	seen := make(map[string]bool, len(hmap))
+	for _, op := range ops {
+		if hmap[op] != nil && !seen[op] {
+			names = append(names, op)
+			seen[op] = true
+		}
+	}
+	for _, k := range hmap.SortedNames(nil) {
+		if !seen[k] {
+			names = append(names, k)
+		}
+	}
+
+	var out bytes.Buffer
+
+	out.WriteString("Operation timings\n")
+	fmt.Fprintf(&out, "%-12s %10s %10s %10s %10s %10s %10s\n",
+		"op", "count", "p50", "p90", "p99", "p99.9", "max")
+
+	for _, k := range names {
+		v := hmap[k]
+
+		v.m.Lock()
+		var max uint64
+		if v.TotCount > 0 {
+			max = v.MaxDataPoint
+		}
+		fmt.Fprintf(&out, "%-12s %10d %10d %10d %10d %10d %10d\n",
+			k, v.TotCount,
+			v.percentileUNLOCKED(50), v.percentileUNLOCKED(90),
+			v.percentileUNLOCKED(99), v.percentileUNLOCKED(99.9), max)
+		v.m.Unlock()
+	}
+
+	for _, k := range names {
+		out.WriteString("\n")
+		hmap[k].EmitGraph(nil, &out)
+	}
+
+	_, err := w.Write(out.Bytes())
+
+	return err
+}
@@ -0,0 +1,49 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEmitOpsReport(t *testing.T) {
+	hmap := make(Histograms)
+	for _, op := range []string{"set", "get", "observe"} {
+		hmap[op] = NewNamedHistogram(op, 5, 10, 2.0)
+	}
+
+	hmap["get"].Add(5, 10)
+	hmap["set"].Add(25, 2)
+	hmap["observe"].Add(100, 1)
+
+	var buf bytes.Buffer
+	if err := hmap.EmitOpsReport(&buf, nil); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	expTable := `Operation timings
+op                count        p50        p90        p99      p99.9        max
+get                  10          5          5          5          5          5
+set                   2         25         25         25         25         25
+observe               1        100        100        100        100        100
+`
+	got := buf.String()
+	if !strings.HasPrefix(got, expTable) {
+		t.Errorf("unexpected table, got: %s", got)
+	}
+
+	iGet := strings.Index(got, "\nget (10 Total)\n")
+	iSet := strings.Index(got, "\nset (2 Total)\n")
+	iObserve := strings.Index(got, "\nobserve (1 Total)\n")
+	if iGet < 0 || iSet < iGet || iObserve < iSet {
+		t.Errorf("unexpected graphs, got: %s", got)
+	}
+}
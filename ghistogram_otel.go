@@ -0,0 +1,125 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"fmt"
+	"math"
+)
+
+// OTelExpHistogram holds the fields of an OpenTelemetry (OTLP)
+// ExponentialHistogramDataPoint, so it can be copied into the OTel
+// SDK or protobuf types without this package depending on them.
+type OTelExpHistogram struct {
+	Count     uint64
+	Sum       float64
+	Min       float64
+	Max       float64
+	Scale     int32
+	ZeroCount uint64
+	Positive  OTelExpBuckets
+}
+
+// OTelExpBuckets holds the counts of consecutive exponential buckets,
+// where bucket index Offset+i holds the values in the range of
+// (base^(Offset+i), base^(Offset+i+1)] with base = 2^(2^-Scale).
+type OTelExpBuckets struct {
+	Offset       int32
+	BucketCounts []uint64
+}
+
+// ToOTelExp converts the histogram into an OTLP exponential histogram
+// data point of the given scale, which must be in [-10, 20].
+//
+// As the bins don't generally align with the exponential buckets, the
+// count of each bin is attributed to the bucket holding the midpoint
+// of the bin, where the bin is narrowed to the smallest and largest
+// data points seen.  Bins whose midpoint is 0 are counted in the
+// ZeroCount.  Histograms with a binGrowthFactor of 2.0 and a power of
+// two binFirst map one bin per bucket at scale 0.  The Sum is likewise
+// estimated from the midpoints of the bins, as the TotDataPoint does
+// not account for the counts of Add().
+func (gh *Histogram) ToOTelExp(scale int32) (*OTelExpHistogram, error) {
+	if scale < -10 || scale > 20 {
+		return nil, fmt.Errorf("ghistogram: ToOTelExp, invalid scale: %d",
+			scale)
+	}
+
+	gh.m.Lock()
+	defer gh.m.Unlock()
+
+	rv := &OTelExpHistogram{
+		Count: gh.TotCount,
+		Sum:   gh.sumUNLOCKED(),
+		Scale: scale,
+	}
+
+	if gh.TotCount == 0 {
+		return rv, nil
+	}
+
+	rv.Min = float64(gh.MinDataPoint)
+	rv.Max = float64(gh.MaxDataPoint)
+
+	var counts []uint64
+
+	for i, c := range gh.Counts {
+		if c == 0 {
+			continue
+		}
+
+		lower := gh.rangeLabel(gh.Ranges[i])
+		if lower < gh.MinDataPoint {
+			lower = gh.MinDataPoint
+		}
+
+		upper := gh.MaxDataPoint
+		if i < len(gh.Ranges)-1 && gh.rangeLabel(gh.Ranges[i+1])-1 < upper {
+			upper = gh.rangeLabel(gh.Ranges[i+1]) - 1
+		}
+		if upper < lower {
+			upper = lower
+		}
+
+		mid := lower + (upper-lower)/2
+		if mid == 0 {
+			rv.ZeroCount += c
+			continue
+		}
+
+		idx := otelExpIndex(mid, scale)
+		if counts == nil {
+			rv.Positive.Offset = idx
+		}
+		if idx < rv.Positive.Offset {
+			// Unreachable for increasing ranges, but stay safe with
+			// transforms that aren't monotonic.
+			grown := make([]uint64, int(rv.Positive.Offset-idx)+len(counts))
+			copy(grown[rv.Positive.Offset-idx:], counts)
+			counts = grown
+			rv.Positive.Offset = idx
+		}
+		for int(idx-rv.Positive.Offset) >= len(counts) {
+			counts = append(counts, 0)
+		}
+		counts[idx-rv.Positive.Offset] += c
+	}
+
+	rv.Positive.BucketCounts = counts
+
+	return rv, nil
+}
+
+// otelExpIndex returns the index of the exponential bucket of the
+// given scale that holds the value v > 0.
+func otelExpIndex(v uint64, scale int32) int32 {
+	// Buckets are upper inclusive, so exact powers of the base belong
+	// to the lower bucket.  The math.Log2 is exact for powers of two.
+	return int32(math.Ceil(math.Log2(float64(v))*math.Exp2(float64(scale)))) - 1
+}
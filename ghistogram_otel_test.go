@@ -0,0 +1,78 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"testing"
+)
+
+func TestOTelExpIndex(t *testing.T) {
+	tests := []struct {
+		v     uint64
+		scale int32
+		exp   int32
+	}{
+		{1, 0, -1},
+		{2, 0, 0},
+		{3, 0, 1},
+		{4, 0, 1},
+		{5, 0, 2},
+		{4, 1, 3},
+		{5, 1, 4},
+		{16, -1, 1},
+		{17, -1, 2},
+	}
+
+	for testi, test := range tests {
+		got := otelExpIndex(test.v, test.scale)
+		if got != test.exp {
+			t.Errorf("test #%d, v: %d, scale: %d, exp: %d, got: %d",
+				testi, test.v, test.scale, test.exp, got)
+		}
+	}
+}
+
+func TestToOTelExp(t *testing.T) {
+	// Bins will look like: {0, 1, 2, 4, 8, 16}.
+	gh := NewHistogram(6, 1, 2.0)
+	gh.Add(0, 1)
+	gh.Add(3, 2)
+	gh.Add(6, 3)
+	gh.Add(9, 4)
+
+	o, err := gh.ToOTelExp(0)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if o.Count != 10 || o.ZeroCount != 1 || o.Min != 0 || o.Max != 9 {
+		t.Errorf("unexpected data point: %+v", o)
+	}
+
+	// The Sum accounts for the counts, unlike the TotDataPoint of 18.
+	if o.Sum != 0.5+3*2+6*3+8.5*4 {
+		t.Errorf("unexpected sum: %v", o.Sum)
+	}
+
+	// Bin [2, 4) has midpoint 2 in bucket 0, bin [4, 8) has
+	// midpoint 5 in bucket 2 and bin [8, 9] has midpoint 8 in bucket 2.
+	exp := []uint64{2, 0, 7}
+	if o.Positive.Offset != 0 || len(o.Positive.BucketCounts) != len(exp) {
+		t.Fatalf("unexpected buckets: %+v", o.Positive)
+	}
+	for i := range exp {
+		if o.Positive.BucketCounts[i] != exp[i] {
+			t.Errorf("unexpected buckets: %+v", o.Positive)
+		}
+	}
+
+	if _, err = gh.ToOTelExp(21); err == nil {
+		t.Errorf("expected err on invalid scale")
+	}
+}
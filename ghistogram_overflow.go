@@ -0,0 +1,42 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+// OverflowCount returns the count of data points that exceeded the
+// intended range of the histogram, and so landed in its last,
+// catch-all "N - inf" bin, since creation or the last reset.  Unlike
+// the count of the last bin, the overflow count is kept when auto
+// ranging rebins the histogram, see SetAutoRange().  A large share of
+// overflowed data points means the histogram is mis-sized and its
+// upper percentiles are meaningless.  The overflow count includes the
+// data points rejected by a histogram without a catch-all bin, see
+// WithoutCatchAll().
+func (gh *Histogram) OverflowCount() uint64 {
+	gh.m.Lock()
+	rv := gh.overflow
+	gh.m.Unlock()
+	return rv
+}
+
+// OverflowHook registers fn to be invoked when a data point lands in
+// the catch-all bin, so applications can warn about mis-sized
+// histograms.  As with SlowOpHook(), the invocations are rate-limited
+// to one per SlowOpHookInterval, and a nil fn removes the hook.
+//
+// The fn is invoked while the histogram is locked, so it must not
+// call any of the histogram's methods.
+func (gh *Histogram) OverflowHook(fn func(dataPoint uint64)) {
+	gh.m.Lock()
+	if fn == nil {
+		gh.overflowHook = nil
+	} else {
+		gh.overflowHook = newSlowOpHook(0, fn, HookOptions{})
+	}
+	gh.m.Unlock()
+}
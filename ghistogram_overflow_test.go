@@ -0,0 +1,61 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOverflowCount(t *testing.T) {
+	defer func(orig time.Duration) { SlowOpHookInterval = orig }(
+		SlowOpHookInterval)
+	SlowOpHookInterval = 0
+
+	gh := NewHistogram(4, 10, 0)
+
+	var overflowed []uint64
+	gh.OverflowHook(func(dataPoint uint64) {
+		overflowed = append(overflowed, dataPoint)
+	})
+
+	gh.Add(5, 1)
+	gh.Add(29, 1)
+	gh.Add(30, 2)
+	gh.Add(1000, 1)
+
+	if gh.OverflowCount() != 3 || len(overflowed) != 2 ||
+		overflowed[0] != 30 || overflowed[1] != 1000 {
+		t.Errorf("unexpected overflow: %d, %v",
+			gh.OverflowCount(), overflowed)
+	}
+
+	gh2 := gh.CloneEmpty()
+	gh2.Add(40, 1)
+	gh2.AddAll(gh)
+	if gh2.OverflowCount() != 4 || gh.Clone().OverflowCount() != 3 {
+		t.Errorf("expected merged and cloned overflow counts")
+	}
+
+	// Rebinning keeps the overflow count.
+	gh.SetAutoRange(0.5, 0)
+	gh.Add(1000, 1)
+	if gh.OverflowCount() != 4 || gh.Counts[len(gh.Counts)-1] == 4 {
+		t.Errorf("expected overflow count 4 after rebinning, got: %d, %v",
+			gh.OverflowCount(), gh.Counts)
+	}
+
+	gh.OverflowHook(nil)
+	gh.Reset()
+	gh.Add(1000, 1)
+	if gh.OverflowCount() != 1 || len(overflowed) != 3 {
+		t.Errorf("expected reset overflow count and no hook, got: %d, %v",
+			gh.OverflowCount(), overflowed)
+	}
+}
@@ -0,0 +1,235 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	graphHeaderRE = regexp.MustCompile(
+		`^(.*) \((\d+) Total(?: in \S+, [\d.]+/sec)?(, .*)?\)$`)
+	graphBinRE    = regexp.MustCompile(`^\[(\d+) - (\d+|inf)\].*\((\d+)\)$`)
+)
+
+// ParseGraph reconstructs a histogram from the text of EmitGraph(),
+// such as found in support dumps, so it may be re-aggregated or have
+// its percentiles recomputed.  As the graph omits empty bins, a gap
+// between two bins becomes a single empty bin, and a catch-all bin is
+// appended when missing.  The graph does not carry the sum of the data
+// points, so TotDataPoint is 0, and the min and max data points are
+// estimated from the bounds of the first and last non-empty bins.
+func ParseGraph(r io.Reader) (*Histogram, error) {
+	hmap, names, err := parseGraphs(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) != 1 {
+		return nil, fmt.Errorf("ghistogram: ParseGraph,"+
+			" expected 1 graph, got: %d", len(names))
+	}
+
+	return hmap[names[0]], nil
+}
+
+// ParseGraphs reconstructs the histograms from the concatenated texts
+// of EmitGraph(), as emitted by Histograms.String(), keyed by their
+// names, see ParseGraph().
+func ParseGraphs(r io.Reader) (Histograms, error) {
+	hmap, _, err := parseGraphs(r)
+	return hmap, err
+}
+
+// graphParser accumulates the bins of the graph being parsed.
+type graphParser struct {
+	name     string
+	totCount uint64
+	ranges   []uint64
+	counts   []uint64
+	end      uint64 // Upper bound of the last parsed bin.
+	inf      bool   // Whether the last parsed bin is the catch-all.
+}
+
+func parseGraphs(r io.Reader) (Histograms, []string, error) {
+	hmap := Histograms{}
+	var names []string
+
+	var p *graphParser
+
+	finish := func() error {
+		if p == nil {
+			return nil
+		}
+
+		gh, err := p.histogram()
+		if err != nil {
+			return err
+		}
+		if hmap[p.name] != nil {
+			return fmt.Errorf("ghistogram: ParseGraph,"+
+				" duplicate graph: %q", p.name)
+		}
+
+		hmap[p.name] = gh
+		names = append(names, p.name)
+		p = nil
+
+		return nil
+	}
+
+	s := bufio.NewScanner(r)
+	for lineNum := 1; s.Scan(); lineNum++ {
+		line := strings.TrimSpace(s.Text())
+
+		if m := graphBinRE.FindStringSubmatch(line); m != nil && p != nil {
+			if err := p.addBin(m); err != nil {
+				return nil, nil, fmt.Errorf("ghistogram: ParseGraph,"+
+					" line %d: %v", lineNum, err)
+			}
+			continue
+		}
+
+		if m := graphHeaderRE.FindStringSubmatch(line); m != nil {
+			if err := finish(); err != nil {
+				return nil, nil, err
+			}
+
+			totCount, err := strconv.ParseUint(m[2], 10, 64)
+			if err != nil {
+				return nil, nil, fmt.Errorf("ghistogram: ParseGraph,"+
+					" line %d: %v", lineNum, err)
+			}
+
+			p = &graphParser{name: m[1], totCount: totCount}
+			continue
+		}
+
+		// Skip blank lines, "(empty)", reset and SLO lines.
+		if line == "" || strings.HasPrefix(line, "(") {
+			continue
+		}
+
+		return nil, nil, fmt.Errorf("ghistogram: ParseGraph,"+
+			" line %d: unexpected: %q", lineNum, line)
+	}
+	if err := s.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := finish(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(names) == 0 {
+		return nil, nil, errors.New("ghistogram: ParseGraph, no graph")
+	}
+
+	return hmap, names, nil
+}
+
+// addBin adds a bin from the submatches of graphBinRE, see add().
+func (p *graphParser) addBin(m []string) error {
+	lo, err := strconv.ParseUint(m[1], 10, 64)
+	if err != nil {
+		return err
+	}
+
+	count, err := strconv.ParseUint(m[3], 10, 64)
+	if err != nil {
+		return err
+	}
+
+	if m[2] == "inf" {
+		return p.add(lo, 0, true, count)
+	}
+
+	end, err := strconv.ParseUint(m[2], 10, 64)
+	if err != nil {
+		return err
+	}
+
+	return p.add(lo, end, false, count)
+}
+
+// add adds the bin [lo, end), or [lo, inf) when inf, preceded by an
+// empty bin for any gap since the previous bin.
+func (p *graphParser) add(lo, end uint64, inf bool, count uint64) error {
+	if p.inf || (len(p.ranges) > 0 && lo < p.end) {
+		return fmt.Errorf("bin %d out of order", lo)
+	}
+
+	if lo > p.end {
+		p.ranges = append(p.ranges, p.end)
+		p.counts = append(p.counts, 0)
+	}
+
+	p.ranges = append(p.ranges, lo)
+	p.counts = append(p.counts, count)
+
+	if inf {
+		p.inf = true
+		return nil
+	}
+
+	if end <= lo {
+		return fmt.Errorf("bin %d has invalid end %d", lo, end)
+	}
+
+	p.end = end
+
+	return nil
+}
+
+func (p *graphParser) histogram() (*Histogram, error) {
+	if !p.inf {
+		p.ranges = append(p.ranges, p.end)
+		p.counts = append(p.counts, 0)
+	}
+
+	gh := &Histogram{
+		Name:         p.name,
+		Ranges:       p.ranges,
+		Counts:       p.counts,
+		MinDataPoint: math.MaxUint64,
+	}
+
+	for i, c := range gh.Counts {
+		if c == 0 {
+			continue
+		}
+
+		gh.TotCount += c
+
+		if gh.MinDataPoint == math.MaxUint64 {
+			gh.MinDataPoint = gh.Ranges[i]
+		}
+
+		gh.MaxDataPoint = gh.Ranges[i]
+		if i < len(gh.Ranges)-1 {
+			gh.MaxDataPoint = gh.Ranges[i+1] - 1
+		}
+	}
+
+	gh.total = gh.TotCount
+
+	if gh.TotCount != p.totCount {
+		return nil, fmt.Errorf("ghistogram: ParseGraph, graph %q has"+
+			" %d Total, but its bins sum to %d",
+			p.name, p.totCount, gh.TotCount)
+	}
+
+	return gh, nil
+}
@@ -0,0 +1,136 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseGraph(t *testing.T) {
+	gh := NewNamedHistogram("get (µs)", 10, 10, 2.0).WithSLOThresholds(50)
+	gh.Add(15, 3)
+	gh.Add(100, 5)
+	gh.Add(5000, 1)
+	gh.ResetWithReason("test")
+	gh.Add(15, 3)
+	gh.Add(100, 5)
+	gh.Add(5000, 1)
+
+	graph := gh.EmitGraph(nil, nil).String()
+
+	got, err := ParseGraph(strings.NewReader(graph))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expRanges := []uint64{0, 10, 20, 80, 160, 5120}
+	expCounts := []uint64{0, 3, 0, 5, 0, 0}
+	if gh.Ranges[9] != 5120 {
+		expRanges = []uint64{0, 10, 20, 80, 160, 2560}
+		expCounts = []uint64{0, 3, 0, 5, 0, 1}
+	}
+
+	if got.Name != "get (µs)" || got.TotCount != 9 ||
+		!reflect.DeepEqual(got.Ranges, expRanges) ||
+		!reflect.DeepEqual(got.Counts, expCounts) {
+		t.Errorf("unexpected parse of:\n%s\ngot: %+v", graph, got)
+	}
+
+	if got.MinDataPoint != 10 || got.MaxDataPoint != 2560 {
+		t.Errorf("unexpected min/max: %d, %d",
+			got.MinDataPoint, got.MaxDataPoint)
+	}
+
+	// Re-emitting the parsed graph gives the same bins.
+	reparsed := got.EmitGraph(nil, nil).String()
+	if !strings.Contains(graph, reparsed[strings.Index(reparsed, "\n[")+1:]) {
+		t.Errorf("expected same bins, graph:\n%s\nreparsed:\n%s",
+			graph, reparsed)
+	}
+}
+
+func TestParseGraphs(t *testing.T) {
+	hmap, exp1, exp2 := initAndFetchHistograms(t)
+
+	got, err := ParseGraphs(strings.NewReader(hmap.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 ||
+		got["test1 (µs)"].EmitGraph(nil, nil).String() != exp1 ||
+		got["test2 (µs)"].EmitGraph(nil, nil).String() != exp2 {
+		t.Errorf("unexpected parse: %v", got)
+	}
+
+	if _, err = ParseGraph(strings.NewReader(hmap.String())); err == nil {
+		t.Errorf("expected ParseGraph error for 2 graphs")
+	}
+}
+
+func TestParseGraphWindow(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+
+	gh := NewNamedHistogram("TestGraph", 10, 10, 2.0)
+	gh.SetClock(clock)
+	gh.Add(15, 20)
+	gh.Add(100, 2)
+	clock.Advance(2 * time.Minute)
+
+	graph := gh.EmitGraph(nil, nil).String()
+	if !strings.Contains(graph, "(22 Total in 2m0s, 0.18/sec)") {
+		t.Fatalf("expected a window in the header, got:\n%s", graph)
+	}
+
+	got, err := ParseGraph(strings.NewReader(graph))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "TestGraph" || got.TotCount != 22 {
+		t.Errorf("unexpected parse of:\n%s\ngot: %+v", graph, got)
+	}
+
+	shares := Histograms{"TestGraph": gh}.StringWithShares()
+	if !strings.Contains(shares, "in 2m0s, 0.18/sec, 100.00% of 22)") {
+		t.Fatalf("expected a window and share, got:\n%s", shares)
+	}
+
+	hmap, err := ParseGraphs(strings.NewReader(shares))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hmap["TestGraph"] == nil || hmap["TestGraph"].TotCount != 22 {
+		t.Errorf("unexpected parse of:\n%s\ngot: %v", shares, hmap)
+	}
+}
+
+func TestParseGraphErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"not a graph\n",
+		"x (3 Total)\n[0 - 10] 100.00% 100.00% ### (2)\n",
+		"x (3 Total)\n[10 - 20] 1% 1% # (1)\n[0 - 10] 1% 1% # (2)\n",
+		"x (1 Total)\n[0 - inf] 1% 1% # (1)\n[10 - 20] 1% 1% # (2)\n",
+		"x (0 Total)\n(empty)\nx (0 Total)\n(empty)\n",
+	}
+
+	for testi, test := range tests {
+		if _, err := ParseGraphs(strings.NewReader(test)); err == nil {
+			t.Errorf("test #%d, expected error for: %q", testi, test)
+		}
+	}
+
+	gh, err := ParseGraph(strings.NewReader("x (0 Total)\n(empty)\n"))
+	if err != nil || gh.TotCount != 0 || len(gh.Counts) != 1 {
+		t.Errorf("expected an empty histogram, got: %+v, err: %v", gh, err)
+	}
+}
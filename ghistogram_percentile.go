@@ -0,0 +1,253 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+)
+
+// Percentile returns an estimate of the data point at the given
+// percentile, in the range of [0.0, 100.0], by linearly interpolating
+// within the bin holding that percentile.  The estimate is clamped to
+// the smallest and largest data points seen, and is 0 for an empty
+// histogram.
+func (gh *Histogram) Percentile(p float64) uint64 {
+	gh.m.Lock()
+	v := gh.percentileUNLOCKED(p)
+	gh.m.Unlock()
+	return v
+}
+
+func (gh *Histogram) percentileUNLOCKED(p float64) uint64 {
+	if gh.TotCount == 0 {
+		return 0
+	}
+
+	if p <= 0 {
+		return gh.MinDataPoint
+	}
+	if p >= 100 {
+		return gh.MaxDataPoint
+	}
+
+	rank := p / 100 * float64(gh.TotCount)
+
+	var runCount uint64
+	for i, c := range gh.Counts {
+		if c == 0 || float64(runCount+c) < rank {
+			runCount += c
+			continue
+		}
+
+		lower := gh.rangeLabel(gh.Ranges[i])
+		if lower < gh.MinDataPoint {
+			lower = gh.MinDataPoint
+		}
+
+		upper := gh.MaxDataPoint
+		if i < len(gh.Ranges)-1 && gh.rangeLabel(gh.Ranges[i+1]) < upper {
+			upper = gh.rangeLabel(gh.Ranges[i+1])
+		}
+
+		if upper <= lower {
+			return lower
+		}
+
+		frac := (rank - float64(runCount)) / float64(c)
+
+		return lower + uint64(frac*float64(upper-lower))
+	}
+
+	return gh.MaxDataPoint
+}
+
+// meanUNLOCKED estimates the mean data point, see sumUNLOCKED().
+func (gh *Histogram) meanUNLOCKED() float64 {
+	if gh.TotCount == 0 {
+		return 0
+	}
+
+	return gh.sumUNLOCKED() / float64(gh.TotCount)
+}
+
+// sumUNLOCKED estimates the sum of the data points, weighted by their
+// counts, from the midpoints of the bins, clamped to the min and max
+// data points, as TotDataPoint doesn't account for the counts of
+// Add().  Exporters should report it as the sum, so that it stays
+// consistent with the TotCount.
+func (gh *Histogram) sumUNLOCKED() float64 {
+	var sum float64
+	for i, c := range gh.Counts {
+		if c > 0 {
+			sum += float64(c) * gh.binMidpointUNLOCKED(i)
+		}
+	}
+
+	return sum
+}
+
+// varianceUNLOCKED estimates the population variance of the data
+// points around the mean from the midpoints of the bins, see
+// meanUNLOCKED().
+func (gh *Histogram) varianceUNLOCKED(mean float64) float64 {
+	if gh.TotCount == 0 {
+		return 0
+	}
+
+	var sum float64
+	for i, c := range gh.Counts {
+		if c > 0 {
+			d := gh.binMidpointUNLOCKED(i) - mean
+			sum += float64(c) * d * d
+		}
+	}
+
+	return sum / float64(gh.TotCount)
+}
+
+// binMidpointUNLOCKED returns the midpoint of the domain of bin i,
+// clamped to the min and max data points.
+func (gh *Histogram) binMidpointUNLOCKED(i int) float64 {
+	lower := float64(gh.rangeLabel(gh.Ranges[i]))
+	upper := float64(gh.MaxDataPoint)
+	if i < len(gh.Ranges)-1 {
+		upper = float64(gh.rangeLabel(gh.Ranges[i+1]))
+	}
+	if lower < float64(gh.MinDataPoint) {
+		lower = float64(gh.MinDataPoint)
+	}
+	if upper > float64(gh.MaxDataPoint) {
+		upper = float64(gh.MaxDataPoint)
+	}
+	if upper < lower {
+		upper = lower
+	}
+
+	return (lower + upper) / 2
+}
+
+// CountBelow returns an estimate of the number of data points < v,
+// such as the requests faster than 50ms.  Whole bins below v are
+// summed, and the bin holding v contributes proportionally to the
+// part of its domain below v, where the domain is clamped to the
+// smallest and largest data points seen.
+func (gh *Histogram) CountBelow(v uint64) uint64 {
+	gh.m.Lock()
+	n := gh.countBelowUNLOCKED(v)
+	gh.m.Unlock()
+	return uint64(math.Round(n))
+}
+
+// CountAtOrAbove returns an estimate of the number of data points
+// >= v, such as the requests slower than 50ms, see CountBelow().
+func (gh *Histogram) CountAtOrAbove(v uint64) uint64 {
+	gh.m.Lock()
+	n := float64(gh.TotCount) - gh.countBelowUNLOCKED(v)
+	gh.m.Unlock()
+	return uint64(math.Round(n))
+}
+
+// CountInRange returns an estimate of the number of data points in
+// [lo, hi), see CountBelow().
+func (gh *Histogram) CountInRange(lo, hi uint64) uint64 {
+	if hi <= lo {
+		return 0
+	}
+
+	gh.m.Lock()
+	n := gh.countBelowUNLOCKED(hi) - gh.countBelowUNLOCKED(lo)
+	gh.m.Unlock()
+	return uint64(math.Round(n))
+}
+
+func (gh *Histogram) countBelowUNLOCKED(v uint64) float64 {
+	var rv float64
+
+	for i, c := range gh.Counts {
+		if c == 0 {
+			continue
+		}
+
+		lower := gh.rangeLabel(gh.Ranges[i])
+		if lower < gh.MinDataPoint {
+			lower = gh.MinDataPoint
+		}
+		if v <= lower {
+			break
+		}
+
+		// The upper is exclusive, so a bin of a single value works.
+		upper := float64(gh.MaxDataPoint) + 1
+		if i < len(gh.Ranges)-1 &&
+			float64(gh.rangeLabel(gh.Ranges[i+1])) < upper {
+			upper = float64(gh.rangeLabel(gh.Ranges[i+1]))
+		}
+
+		if float64(v) >= upper || upper <= float64(lower) {
+			rv += float64(c)
+			continue
+		}
+
+		rv += float64(c) * (float64(v) - float64(lower)) /
+			(upper - float64(lower))
+	}
+
+	return rv
+}
+
+// Stats returns flat stats entries of the histogram, suitable for
+// flat stats maps such as memcached STAT output or expvar ints, with
+// the keys "<prefix>.count", "<prefix>.min", "<prefix>.max",
+// "<prefix>.p50", "<prefix>.p90", "<prefix>.p99" and "<prefix>.p999".
+// The min and max are omitted while the histogram is empty, and an
+// empty prefix gives the bare keys, like "count".
+func (gh *Histogram) Stats(prefix string) map[string]uint64 {
+	rv := make(map[string]uint64, 3+len(summaryPercentiles))
+
+	gh.m.Lock()
+	gh.statsUNLOCKED(prefix, rv)
+	gh.m.Unlock()
+
+	return rv
+}
+
+func (gh *Histogram) statsUNLOCKED(prefix string, rv map[string]uint64) {
+	rv[statKey(prefix, "count")] = gh.TotCount
+	if gh.TotCount > 0 {
+		rv[statKey(prefix, "min")] = gh.MinDataPoint
+		rv[statKey(prefix, "max")] = gh.MaxDataPoint
+	}
+	for _, sp := range summaryPercentiles {
+		rv[statKey(prefix, sp.name)] = gh.percentileUNLOCKED(sp.p)
+	}
+}
+
+// statKey returns the "<prefix>.<name>" key of a stat or metric, or
+// just the name when the prefix is empty.
+func statKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// Stats returns the flat stats entries of all the histograms of the
+// map, using "<prefix><name>" as the prefix of each histogram's
+// entries, see Histogram.Stats().
+func (hmap Histograms) Stats(prefix string) map[string]uint64 {
+	rv := make(map[string]uint64, len(hmap)*(3+len(summaryPercentiles)))
+
+	for k, v := range hmap {
+		v.m.Lock()
+		v.statsUNLOCKED(prefix+k, rv)
+		v.m.Unlock()
+	}
+
+	return rv
+}
@@ -0,0 +1,138 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPercentile(t *testing.T) {
+	// Bins will look like: {0, 10, 20, 40, 80}.
+	gh := NewHistogram(5, 10, 2.0)
+
+	if gh.Percentile(50) != 0 {
+		t.Errorf("expected 0 for an empty histogram")
+	}
+
+	for i := uint64(0); i < 40; i++ {
+		gh.Add(i, 1)
+	}
+	gh.Add(1000, 40)
+
+	tests := []struct {
+		p   float64
+		exp uint64
+	}{
+		{0, 0},
+		{10, 8},
+		{25, 20},
+		{40, 32},
+		{50, 40},
+		{75, 540},
+		{99, 981},
+		{100, 1000},
+	}
+
+	for testi, test := range tests {
+		got := gh.Percentile(test.p)
+		if got != test.exp {
+			t.Errorf("test #%d, p: %v, exp: %d, got: %d",
+				testi, test.p, test.exp, got)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	histograms, _, _ := initAndFetchHistograms(t)
+
+	stats := histograms.Stats("cmd_")
+
+	exp := map[string]uint64{
+		"cmd_test1.count": 6,
+		"cmd_test1.min":   1,
+		"cmd_test1.max":   3,
+		"cmd_test1.p50":   2,
+		"cmd_test1.p90":   2,
+		"cmd_test1.p99":   2,
+		"cmd_test1.p999":  2,
+		"cmd_test2.count": 4,
+		"cmd_test2.min":   2,
+		"cmd_test2.max":   4,
+		"cmd_test2.p50":   4,
+		"cmd_test2.p90":   4,
+		"cmd_test2.p99":   4,
+		"cmd_test2.p999":  4,
+	}
+
+	if len(stats) != len(exp) {
+		t.Errorf("unexpected stats: %v", stats)
+	}
+	for k, v := range exp {
+		if stats[k] != v {
+			t.Errorf("stat %s, exp: %d, got: %d", k, v, stats[k])
+		}
+	}
+
+	stats = NewHistogram(5, 10, 2.0).Stats("empty")
+	if _, exists := stats["empty.min"]; exists || len(stats) != 5 {
+		t.Errorf("unexpected stats for an empty histogram: %v", stats)
+	}
+
+	stats = histograms["test1"].Stats("")
+	if stats["count"] != 6 || stats["p999"] != 2 || len(stats) != 7 {
+		t.Errorf("expected bare keys for an empty prefix, got: %v", stats)
+	}
+}
+
+func TestCountQueries(t *testing.T) {
+	// Bins will look like: {0, 10, 20, 40, 80}.
+	gh := NewHistogram(5, 10, 2.0)
+
+	if gh.CountBelow(50) != 0 || gh.CountAtOrAbove(0) != 0 {
+		t.Errorf("expected 0 for an empty histogram")
+	}
+
+	for i := uint64(0); i < 40; i++ {
+		gh.Add(i, 1)
+	}
+	gh.Add(1000, 40)
+
+	tests := []struct {
+		lo, hi   uint64
+		expBelow uint64 // CountBelow(hi)
+		expIn    uint64 // CountInRange(lo, hi)
+	}{
+		{0, 0, 0, 0},
+		{0, 10, 10, 10},
+		{0, 5, 5, 5},
+		{5, 30, 30, 25},
+		{30, 40, 40, 10},
+		{40, 80, 40, 0},
+		{40, 540, 60, 20},
+		{40, 1001, 80, 40},
+		{0, math.MaxUint64, 80, 80},
+		{50, 10, 10, 0},
+	}
+
+	for testi, test := range tests {
+		if got := gh.CountBelow(test.hi); got != test.expBelow {
+			t.Errorf("test #%d, CountBelow(%d), exp: %d, got: %d",
+				testi, test.hi, test.expBelow, got)
+		}
+		if got := gh.CountAtOrAbove(test.hi); got != 80-test.expBelow {
+			t.Errorf("test #%d, CountAtOrAbove(%d), exp: %d, got: %d",
+				testi, test.hi, 80-test.expBelow, got)
+		}
+		if got := gh.CountInRange(test.lo, test.hi); got != test.expIn {
+			t.Errorf("test #%d, CountInRange(%d, %d), exp: %d, got: %d",
+				testi, test.lo, test.hi, test.expIn, got)
+		}
+	}
+}
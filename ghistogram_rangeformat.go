@@ -0,0 +1,59 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+// RangeFormat selects how EmitGraph() and similar outputs render bin
+// range boundaries that have no scaled unit, see WithUnit().
+type RangeFormat int
+
+const (
+	// RangeRaw renders boundaries as plain integers, like 1073741824.
+	// This is the default.
+	RangeRaw RangeFormat = iota
+
+	// RangeSI abbreviates boundaries by powers of 1000, like 1.07G.
+	RangeSI
+
+	// RangeIEC abbreviates boundaries by powers of 1024, like 1Gi.
+	RangeIEC
+)
+
+var siScales = []unitScale{
+	{1, ""}, {1e3, "k"}, {1e6, "M"}, {1e9, "G"},
+	{1e12, "T"}, {1e15, "P"}, {1e18, "E"},
+}
+
+var iecScales = []unitScale{
+	{1, ""}, {1 << 10, "Ki"}, {1 << 20, "Mi"}, {1 << 30, "Gi"},
+	{1 << 40, "Ti"}, {1 << 50, "Pi"}, {1 << 60, "Ei"},
+}
+
+// SetRangeFormat changes how the bin range boundaries are rendered,
+// which makes graphs of large boundaries readable, for example
+// "[1.07G - 2.15G]" instead of "[1073741824 - 2147483648]".  The
+// format doesn't apply to boundaries of scaled units, like "µs" or
+// "bytes", see WithUnit(), and machine-readable outputs, like CSV,
+// keep the raw boundaries.
+func (gh *Histogram) SetRangeFormat(format RangeFormat) {
+	gh.m.Lock()
+	gh.rangeFormat = format
+	gh.m.Unlock()
+}
+
+// rangeFormatScales returns the scales of the histogram's range
+// format, or nil for RangeRaw.
+func (gh *Histogram) rangeFormatScales() []unitScale {
+	switch gh.rangeFormat {
+	case RangeSI:
+		return siScales
+	case RangeIEC:
+		return iecScales
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"testing"
+)
+
+func TestSetRangeFormat(t *testing.T) {
+	tests := []struct {
+		format RangeFormat
+		unit   string
+		v      uint64
+		exp    string
+	}{
+		{RangeRaw, "", 1073741824, "1073741824"},
+		{RangeSI, "", 1073741824, "1.07G"},
+		{RangeSI, "", 999, "999"},
+		{RangeSI, "", 1500, "1.5k"},
+		{RangeIEC, "", 1073741824, "1Gi"},
+		{RangeIEC, "", 1000, "1000"},
+		{RangeSI, "req", 2000000, "2Mreq"},
+		{RangeSI, "bytes", 1073741824, "1GiB"}, // Scaled units win.
+	}
+
+	for testi, test := range tests {
+		gh := NewHistogram(2, 1, 0).WithUnit(test.unit)
+		gh.SetRangeFormat(test.format)
+		if got := gh.boundLabel(test.v); got != test.exp {
+			t.Errorf("test #%d, expected %q, got: %q", testi, test.exp, got)
+		}
+	}
+
+	gh := NewNamedHistogram("size", 3, 1<<30, 2.0)
+	gh.SetRangeFormat(RangeSI)
+	gh.Add(1<<30, 1)
+
+	exp := `size (1 Total)
+[1.07G - 2.15G]  100.00%  100.00% ############################## (1)
+`
+	if got := gh.CloneEmpty(); got.rangeFormat != RangeSI {
+		t.Errorf("expected CloneEmpty to keep the range format")
+	}
+	if got := gh.EmitGraph(nil, nil).String(); got != exp {
+		t.Errorf("unexpected graph, got:\n%s\nexp:\n%s", got, exp)
+	}
+}
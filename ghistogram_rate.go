@@ -0,0 +1,64 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"time"
+)
+
+// Rate returns the throughput of the histogram, in data points per
+// second, over its Window(), that is since its creation or last reset,
+// or 0 when the window is unknown or empty.
+func (gh *Histogram) Rate() float64 {
+	gh.m.Lock()
+	defer gh.m.Unlock()
+
+	start, end := gh.windowUNLOCKED()
+	if start.IsZero() || !end.After(start) {
+		return 0
+	}
+
+	return float64(gh.TotCount) / end.Sub(start).Seconds()
+}
+
+// RateInWindow returns the throughput of the histogram, in data points
+// per second, over the last d of its history, see EnableHistory(), or
+// 0 when the history is not enabled.  The rate is over whole retained
+// windows, so d is rounded up to the start of the window it falls in,
+// plus the current, partial interval, and it is limited to the
+// retained windows.  Unlike Rate(), it is not affected by resets.
+func (gh *Histogram) RateInWindow(d time.Duration) float64 {
+	gh.m.Lock()
+	defer gh.m.Unlock()
+
+	h := gh.history
+	if h == nil || d <= 0 {
+		return 0
+	}
+
+	now := gh.now()
+	h.rotate(now)
+
+	cutoff := now.Add(-d)
+
+	from, count := h.start, h.cur.TotCount
+	for i := len(h.windows) - 1; i >= 0; i-- {
+		w := h.windows[(h.next+i)%len(h.windows)]
+		if !w.End.After(cutoff) {
+			break
+		}
+		from, count = w.Start, count+w.Histogram.TotCount
+	}
+
+	if !now.After(from) {
+		return 0
+	}
+
+	return float64(count) / now.Sub(from).Seconds()
+}
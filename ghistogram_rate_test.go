@@ -0,0 +1,71 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRate(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+
+	gh := NewNamedHistogram("get", 3, 10, 2.0)
+	gh.SetClock(clock)
+
+	if r := gh.Rate(); r != 0 {
+		t.Errorf("expected 0 for an empty window, got: %v", r)
+	}
+	if r := gh.RateInWindow(time.Minute); r != 0 {
+		t.Errorf("expected 0 without history, got: %v", r)
+	}
+
+	gh.EnableHistory(3, time.Minute)
+
+	// 60, 120, 240 and then 30 (so far) data points per minute.
+	for _, n := range []uint64{60, 120, 240} {
+		gh.Add(5, n)
+		clock.Advance(time.Minute)
+	}
+	gh.Add(5, 30)
+	clock.Advance(30 * time.Second)
+
+	if r := gh.Rate(); r != 450.0/210 {
+		t.Errorf("unexpected rate: %v", r)
+	}
+
+	tests := []struct {
+		d   time.Duration
+		exp float64
+	}{
+		{-time.Second, 0},
+		{10 * time.Second, 30.0 / 30},
+		{30 * time.Second, 30.0 / 30},
+		{time.Minute, 270.0 / 90},
+		{2 * time.Minute, 390.0 / 150},
+		{time.Hour, 450.0 / 210}, // Limited to the retained windows.
+	}
+
+	for testi, test := range tests {
+		if r := gh.RateInWindow(test.d); r != test.exp {
+			t.Errorf("test #%d, d: %v, got: %v, exp: %v",
+				testi, test.d, r, test.exp)
+		}
+	}
+
+	gh.Reset()
+	clock.Advance(10 * time.Second)
+
+	if r := gh.Rate(); r != 0 {
+		t.Errorf("expected 0 after a reset, got: %v", r)
+	}
+	if r := gh.RateInWindow(30 * time.Second); r != 30.0/40 {
+		t.Errorf("expected the history to survive a reset, got: %v", r)
+	}
+}
@@ -0,0 +1,90 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// NewNamedHistogramRatio creates a new, ready to use Histogram like
+// NewNamedHistogram(), but with bins that grow by the exact ratio of
+// growthNum / growthDen, such as 3 / 2, using only integer arithmetic,
+// so the bin boundaries are deterministic across platforms and free of
+// floating point surprises.  Each boundary is the previous boundary
+// multiplied by the ratio, rounded half up, for example 10, 15, 23,
+// 35, 53 for 3 / 2.  Where rounding would not increase a boundary, it
+// increases by 1 instead.
+//
+// The numBins must be >= 2, the binFirst > 0, and the ratio > 1,
+// otherwise NewNamedHistogramRatio panics.  As with NewNamedHistogram(),
+// bins that would overflow a uint64 are capped.
+func NewNamedHistogramRatio(
+	name string,
+	numBins int,
+	binFirst uint64,
+	growthNum, growthDen uint64) *Histogram {
+	if numBins < 2 || binFirst == 0 || growthDen == 0 ||
+		growthNum <= growthDen {
+		panic("ghistogram: NewNamedHistogramRatio, invalid parameters")
+	}
+
+	gh := &Histogram{
+		Name:         name,
+		Ranges:       make([]uint64, numBins),
+		Counts:       make([]uint64, numBins),
+		MinDataPoint: math.MaxUint64,
+		start:        time.Now(),
+	}
+
+	gh.Ranges[1] = binFirst
+
+	for i := 2; i < len(gh.Ranges); i++ {
+		next, ok := nextBinRangeRatio(gh.Ranges[i-1], growthNum, growthDen)
+		if !ok {
+			gh.Ranges[i] = math.MaxUint64
+			gh.Ranges = gh.Ranges[:i+1]
+			gh.Counts = gh.Counts[:i+1]
+			break
+		}
+
+		gh.Ranges[i] = next
+	}
+
+	return gh
+}
+
+// nextBinRangeRatio returns prev * num / den, rounded half up and at
+// least prev + 1, or false if it would overflow a uint64.
+func nextBinRangeRatio(prev, num, den uint64) (uint64, bool) {
+	hi, lo := bits.Mul64(prev, num)
+
+	// Adding den / 2 before the division rounds half up.
+	var carry uint64
+	lo, carry = bits.Add64(lo, den/2, 0)
+	hi += carry
+
+	if hi >= den {
+		return 0, false // The quotient overflows.
+	}
+
+	next, _ := bits.Div64(hi, lo, den)
+	if next <= prev {
+		if prev == math.MaxUint64-1 {
+			return 0, false
+		}
+		next = prev + 1
+	}
+	if next == math.MaxUint64 {
+		return 0, false
+	}
+
+	return next, true
+}
@@ -0,0 +1,59 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNewNamedHistogramRatio(t *testing.T) {
+	tests := []struct {
+		numBins   int
+		binFirst  uint64
+		num, den  uint64
+		expRanges []uint64
+	}{
+		{6, 10, 3, 2, []uint64{0, 10, 15, 23, 35, 53}},
+		{5, 1, 3, 2, []uint64{0, 1, 2, 3, 5}},
+		{5, 1, 11, 10, []uint64{0, 1, 2, 3, 4}},
+		{5, 7, 2, 1, []uint64{0, 7, 14, 28, 56}},
+		{4, 1 << 62, 2, 1, []uint64{0, 1 << 62, 1 << 63, math.MaxUint64}},
+		{100, 1 << 62, 3, 2,
+			[]uint64{0, 1 << 62, 3 << 61, 9 << 60, 27 << 59,
+				math.MaxUint64}},
+	}
+
+	for testi, test := range tests {
+		gh := NewNamedHistogramRatio("h", test.numBins, test.binFirst,
+			test.num, test.den)
+		if !reflect.DeepEqual(gh.Ranges, test.expRanges) {
+			t.Errorf("test #%d, expected ranges: %v, got: %v",
+				testi, test.expRanges, gh.Ranges)
+		}
+		if err := gh.Validate(); err != nil {
+			t.Errorf("test #%d, unexpected err: %v", testi, err)
+		}
+	}
+
+	for _, args := range [][4]uint64{
+		{1, 10, 3, 2}, {5, 0, 3, 2}, {5, 10, 2, 2}, {5, 10, 3, 0},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic for: %v", args)
+				}
+			}()
+			NewNamedHistogramRatio("h", int(args[0]), args[1],
+				args[2], args[3])
+		}()
+	}
+}
@@ -0,0 +1,106 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Reporter periodically hands snapshots of a Histograms map to a sink,
+// such as a logger, file or exporter, see StartReporting().
+type Reporter struct {
+	hmap  Histograms
+	reset bool
+	sink  func(Histograms)
+
+	m sync.Mutex // Serializes the reports.
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+// StartReporting starts a goroutine that, every interval, snapshots
+// the histograms of the map, optionally resetting them while they're
+// locked so no data points are lost between reports, and invokes the
+// sink with the snapshots.  The reporting stops when the ctx is done
+// or Stop() is invoked, after a final report, so the data points of
+// the last partial interval also reach the sink.  For example:
+//
+//    r := ghistogram.StartReporting(ctx, hmap, time.Minute, true,
+//        func(snaps ghistogram.Histograms) {
+//            log.Printf("interval stats:\n%s", snaps)
+//        })
+//    defer r.Stop()
+//
+// The histograms may be updated concurrently, but the map itself must
+// not be modified while reporting.
+func StartReporting(ctx context.Context, hmap Histograms,
+	interval time.Duration, reset bool, sink func(Histograms)) *Reporter {
+	return StartReportingWithClock(ctx, SystemClock, hmap, interval,
+		reset, sink)
+}
+
+// StartReportingWithClock is StartReporting() where the intervals are
+// ticked by the clock, such as a ManualClock in tests.
+func StartReportingWithClock(ctx context.Context, clock Clock,
+	hmap Histograms, interval time.Duration, reset bool,
+	sink func(Histograms)) *Reporter {
+	r := &Reporter{
+		hmap:   hmap,
+		reset:  reset,
+		sink:   sink,
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+
+	go r.run(ctx, clock.NewTicker(interval))
+
+	return r
+}
+
+func (r *Reporter) run(ctx context.Context, ticker Ticker) {
+	defer ticker.Stop()
+	defer close(r.doneCh)
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.Report()
+			return
+		case <-r.stopCh:
+			r.Report()
+			return
+		case <-ticker.Chan():
+			r.Report()
+		}
+	}
+}
+
+// Report snapshots the histograms and invokes the sink immediately.
+func (r *Reporter) Report() {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	snaps := make(Histograms, len(r.hmap))
+	for name, gh := range r.hmap {
+		snaps[name] = gh.capture(r.reset)
+	}
+
+	r.sink(snaps)
+}
+
+// Stop stops the reporting, waiting for the final report to be handed
+// to the sink.  It's safe to invoke Stop more than once.
+func (r *Reporter) Stop() {
+	r.stopOnce.Do(func() { close(r.stopCh) })
+	<-r.doneCh
+}
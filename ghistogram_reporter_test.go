@@ -0,0 +1,70 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReporter(t *testing.T) {
+	for _, reset := range []bool{false, true} {
+		hmap := Histograms{"get": NewNamedHistogram("get", 4, 10, 2.0)}
+
+		var m sync.Mutex
+		var totals []uint64
+
+		r := StartReporting(context.Background(), hmap, time.Hour, reset,
+			func(snaps Histograms) {
+				m.Lock()
+				totals = append(totals, snaps["get"].TotCount)
+				m.Unlock()
+			})
+
+		hmap["get"].Add(5, 2)
+		r.Report()
+		hmap["get"].Add(5, 3)
+		r.Stop()
+		r.Stop()
+
+		exp := []uint64{2, 5}
+		if reset {
+			exp = []uint64{2, 3}
+		}
+
+		m.Lock()
+		if len(totals) != 2 || totals[0] != exp[0] || totals[1] != exp[1] {
+			t.Errorf("reset %v, expected totals: %v, got: %v",
+				reset, exp, totals)
+		}
+		m.Unlock()
+	}
+}
+
+func TestReporterContext(t *testing.T) {
+	hmap := Histograms{"get": NewNamedHistogram("get", 4, 10, 2.0)}
+	hmap["get"].Add(5, 1)
+
+	reports := make(chan Histograms, 100)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r := StartReporting(ctx, hmap, time.Millisecond, true,
+		func(snaps Histograms) { reports <- snaps })
+
+	first := <-reports
+	if first["get"].TotCount != 1 || hmap["get"].Total() != 0 {
+		t.Errorf("expected a reset snapshot, got: %v", first)
+	}
+
+	cancel()
+	r.Stop() // Returns once the context's final report is done.
+}
@@ -0,0 +1,130 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// reservoir is a fixed-size uniform random sample of the data points
+// added to a histogram, maintained with Li's Algorithm L, which skips
+// ahead to the next replaced data point, so a large count of an Add()
+// costs about as much as a single data point.
+type reservoir struct {
+	samples []uint64 // Preallocated to its full capacity.
+	seen    uint64   // Saturates at math.MaxUint64.
+	next    uint64   // The 1-based data point that replaces a sample.
+	w       float64  // Algorithm L's running weight.
+	rng     *rand.Rand
+}
+
+func newReservoir(size int) *reservoir {
+	return &reservoir{
+		samples: make([]uint64, 0, size),
+		rng:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// SetReservoirSize keeps a uniform random sample of up to size raw
+// data points next to the bins, see Samples(), which allows for exact
+// percentile spot-checks and outlier inspection.  The reservoir is
+// allocated up front, so Add() remains allocation free, and a
+// weighted Add() costs only the random numbers of the samples it
+// replaces.  Changing the size discards the
+// current samples, and a size <= 0 removes the reservoir.
+func (gh *Histogram) SetReservoirSize(size int) {
+	gh.m.Lock()
+	if size > 0 {
+		gh.reservoir = newReservoir(size)
+	} else {
+		gh.reservoir = nil
+	}
+	gh.m.Unlock()
+}
+
+// Samples returns a copy of the reservoir's raw data points, in no
+// particular order, or nil when there's no reservoir.
+func (gh *Histogram) Samples() []uint64 {
+	gh.m.Lock()
+	defer gh.m.Unlock()
+
+	if gh.reservoir == nil {
+		return nil
+	}
+
+	rv := make([]uint64, len(gh.reservoir.samples))
+	copy(rv, gh.reservoir.samples)
+
+	return rv
+}
+
+func (r *reservoir) add(dataPoint uint64, count uint64) {
+	for count > 0 && len(r.samples) < cap(r.samples) {
+		r.samples = append(r.samples, dataPoint)
+		r.seen++
+		count--
+
+		if len(r.samples) == cap(r.samples) {
+			r.w = math.Exp(math.Log(r.random()) / float64(cap(r.samples)))
+			r.skip()
+		}
+	}
+
+	for count > 0 {
+		if r.next <= r.seen || r.next-r.seen > count {
+			if count > math.MaxUint64-r.seen {
+				count = math.MaxUint64 - r.seen
+			}
+			r.seen += count
+			return
+		}
+
+		count -= r.next - r.seen
+		r.seen = r.next
+
+		r.samples[r.rng.Intn(len(r.samples))] = dataPoint
+
+		r.w *= math.Exp(math.Log(r.random()) / float64(cap(r.samples)))
+		r.skip()
+	}
+}
+
+// skip advances next past a random number of data points, that are
+// not sampled, saturating so that no more samples are replaced once
+// the data points can no longer be counted.
+func (r *reservoir) skip() {
+	gap := math.Floor(math.Log(r.random())/math.Log1p(-r.w)) + 1
+	if !(gap < float64(math.MaxUint64-r.seen)) {
+		r.next = math.MaxUint64
+		return
+	}
+
+	r.next = r.seen + uint64(gap)
+}
+
+// random returns a pseudo-random number in (0, 1].
+func (r *reservoir) random() float64 {
+	return 1 - r.rng.Float64()
+}
+
+func (r *reservoir) cloneEmpty() *reservoir {
+	if r == nil {
+		return nil
+	}
+	return newReservoir(cap(r.samples))
+}
+
+func (r *reservoir) reset() {
+	if r != nil {
+		r.samples = r.samples[:0]
+		r.seen, r.next, r.w = 0, 0, 0
+	}
+}
@@ -0,0 +1,104 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestReservoir(t *testing.T) {
+	gh := NewHistogram(10, 10, 2.0)
+
+	if gh.Samples() != nil {
+		t.Errorf("expected no samples without a reservoir")
+	}
+
+	gh.SetReservoirSize(100)
+
+	gh.Add(1, 1)
+	gh.Add(2, 2)
+	if got := gh.Samples(); !reflect.DeepEqual(got, []uint64{1, 2, 2}) {
+		t.Errorf("expected all samples while not full, got: %v", got)
+	}
+
+	// With 100000 data points of 0 to 99, each value should keep
+	// about 1% of the reservoir, so should show up more often than not.
+	for i := uint64(0); i < 100000; i++ {
+		gh.Add(i%100, 1)
+	}
+
+	samples := gh.Samples()
+	if len(samples) != 100 {
+		t.Fatalf("expected a full reservoir, got: %d", len(samples))
+	}
+
+	distinct := map[uint64]bool{}
+	for _, v := range samples {
+		if v > 99 {
+			t.Errorf("unexpected sample: %d", v)
+		}
+		distinct[v] = true
+	}
+	if len(distinct) < 30 {
+		t.Errorf("expected a spread of samples, got: %v", samples)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() { gh.Add(5, 1) })
+	if allocs != 0 {
+		t.Errorf("expected no allocations in Add, got: %v", allocs)
+	}
+
+	c := gh.CloneEmpty()
+	if s := c.Samples(); s == nil || len(s) != 0 {
+		t.Errorf("expected clone to have an empty reservoir, got: %v", s)
+	}
+
+	gh.Reset()
+	if len(gh.Samples()) != 0 {
+		t.Errorf("expected Reset to clear the reservoir")
+	}
+
+	gh.SetReservoirSize(0)
+	if gh.Samples() != nil {
+		t.Errorf("expected reservoir to be removed")
+	}
+}
+
+func TestReservoirWeighted(t *testing.T) {
+	gh := NewHistogram(10, 10, 2.0)
+	gh.SetReservoirSize(100)
+
+	gh.Add(1, 50)
+	gh.Add(2, 1<<40) // Must not take a step per unit of count.
+
+	samples := gh.Samples()
+	if len(samples) != 100 {
+		t.Fatalf("expected a full reservoir, got: %d", len(samples))
+	}
+
+	var twos int
+	for _, v := range samples {
+		if v == 2 {
+			twos++
+		}
+	}
+	if twos < 90 {
+		t.Errorf("expected the huge count to dominate, got: %v", samples)
+	}
+
+	// The count of data points seen saturates instead of wrapping.
+	gh.Add(3, math.MaxUint64)
+	gh.Add(4, math.MaxUint64)
+	gh.Add(5, 1)
+	if len(gh.Samples()) != 100 {
+		t.Errorf("expected a full reservoir after the wrap")
+	}
+}
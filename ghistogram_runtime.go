@@ -0,0 +1,193 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+//go:build go1.16
+// +build go1.16
+
+package ghistogram
+
+import (
+	"fmt"
+	"math"
+	"runtime/metrics"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FromRuntimeMetrics creates a new histogram from a histogram of the
+// Go runtime, such as the one of "/sched/latencies:seconds", so it may
+// be merged and rendered like any other histogram.  The runtime metric
+// name becomes the histogram name and selects the unit: durations in
+// seconds become nanosecond data points, see WithUnit().
+//
+// Each runtime bucket becomes a bin starting at the bucket's lower
+// bound, where buckets below 0 are folded into the first bin, and
+// buckets that start at the same integer data point are merged.  As
+// runtime histograms don't track the sum of the values, the counts are
+// recorded at the lower bounds of the bins, like FromHdr().
+func FromRuntimeMetrics(name string, h *metrics.Float64Histogram) *Histogram {
+	scale, unit := runtimeMetricUnit(name)
+
+	ranges, bins := runtimeBins(h.Buckets, scale)
+
+	gh := &Histogram{
+		Name:         name,
+		Ranges:       ranges,
+		Counts:       make([]uint64, len(ranges)),
+		MinDataPoint: math.MaxUint64,
+		unit:         unit,
+	}
+
+	addRuntimeCounts(gh, bins, h.Counts, nil)
+
+	return gh
+}
+
+// runtimeMetricUnit returns the scale of the values of the runtime
+// metric to data points, and the unit of the data points.
+func runtimeMetricUnit(name string) (float64, string) {
+	i := strings.LastIndexByte(name, ':')
+	if i < 0 {
+		return 1, ""
+	}
+
+	switch unit := name[i+1:]; unit {
+	case "seconds":
+		return 1e9, "ns"
+	case "bytes":
+		return 1, "bytes"
+	}
+
+	return 1, ""
+}
+
+// runtimeBins returns the ranges of the bins for the runtime buckets
+// boundaries, scaled to data points, and the bin of every bucket.
+func runtimeBins(buckets []float64, scale float64) ([]uint64, []int) {
+	ranges := []uint64{0}
+	bins := make([]int, 0, len(buckets))
+
+	for i := 0; i < len(buckets)-1; i++ {
+		if lower := roundToUint64(buckets[i]*scale,
+			RoundFloor); lower > ranges[len(ranges)-1] {
+			ranges = append(ranges, lower)
+		}
+		bins = append(bins, len(ranges)-1)
+	}
+
+	// A bounded last bucket is followed by an empty catch-all bin.
+	if n := len(buckets); n > 0 && !math.IsInf(buckets[n-1], 1) {
+		if upper := roundToUint64(buckets[n-1]*scale,
+			RoundFloor); upper > ranges[len(ranges)-1] {
+			ranges = append(ranges, upper)
+		}
+	}
+
+	return ranges, bins
+}
+
+// addRuntimeCounts adds the counts of the runtime buckets, minus the
+// optional previous counts, to the bins of the histogram.
+func addRuntimeCounts(gh *Histogram, bins []int, counts, prev []uint64) {
+	for i, c := range counts {
+		if prev != nil {
+			c -= prev[i]
+		}
+		if c > 0 {
+			gh.addUNLOCKED(gh.Ranges[bins[i]], c)
+		}
+	}
+}
+
+// RuntimeSampler periodically records the new counts of a histogram
+// of the Go runtime into a histogram, see StartRuntimeSampler().
+type RuntimeSampler struct {
+	hist *Histogram
+
+	samples []metrics.Sample
+	bins    []int
+	prev    []uint64
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+// StartRuntimeSampler starts a goroutine that records the counts the
+// runtime histogram metric gained every interval into a histogram,
+// see Histogram(), until Stop() is invoked.  The histogram starts with
+// the counts since the process started, see FromRuntimeMetrics(), and
+// may be reset to only hold the counts of later intervals.
+func StartRuntimeSampler(name string,
+	interval time.Duration) (*RuntimeSampler, error) {
+	samples := []metrics.Sample{{Name: name}}
+	metrics.Read(samples)
+
+	if samples[0].Value.Kind() != metrics.KindFloat64Histogram {
+		return nil, fmt.Errorf("ghistogram: StartRuntimeSampler,"+
+			" not a runtime histogram metric: %q", name)
+	}
+
+	h := samples[0].Value.Float64Histogram()
+	scale, _ := runtimeMetricUnit(name)
+	_, bins := runtimeBins(h.Buckets, scale)
+
+	s := &RuntimeSampler{
+		hist:    FromRuntimeMetrics(name, h),
+		samples: samples,
+		bins:    bins,
+		prev:    append([]uint64(nil), h.Counts...),
+		stopCh:  make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}
+
+	go s.run(interval)
+
+	return s, nil
+}
+
+// Histogram returns the histogram the RuntimeSampler records into.
+func (s *RuntimeSampler) Histogram() *Histogram {
+	return s.hist
+}
+
+func (s *RuntimeSampler) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer close(s.doneCh)
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			s.Sample()
+		}
+	}
+}
+
+// Sample records the counts the runtime histogram gained immediately.
+func (s *RuntimeSampler) Sample() {
+	s.hist.m.Lock()
+	defer s.hist.m.Unlock()
+
+	// Under the lock, so concurrent Sample() calls see distinct prev's.
+	metrics.Read(s.samples)
+	counts := s.samples[0].Value.Float64Histogram().Counts
+
+	addRuntimeCounts(s.hist, s.bins, counts, s.prev)
+	copy(s.prev, counts)
+}
+
+// Stop stops the sampling, waiting for any in-flight sample to be
+// recorded.  It's safe to invoke Stop more than once.
+func (s *RuntimeSampler) Stop() {
+	s.stopOnce.Do(func() { close(s.stopCh) })
+	<-s.doneCh
+}
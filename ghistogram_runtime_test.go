@@ -0,0 +1,114 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+//go:build go1.16
+// +build go1.16
+
+package ghistogram
+
+import (
+	"math"
+	"reflect"
+	"runtime"
+	"runtime/metrics"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFromRuntimeMetrics(t *testing.T) {
+	tests := []struct {
+		name      string
+		h         metrics.Float64Histogram
+		expRanges []uint64
+		expCounts []uint64
+		expUnit   string
+	}{
+		{
+			name: "/sched/latencies:seconds",
+			h: metrics.Float64Histogram{
+				Buckets: []float64{math.Inf(-1), 0, 1e-9, 1.5e-9, 2e-6,
+					math.Inf(1)},
+				Counts: []uint64{1, 2, 3, 4, 5},
+			},
+			expRanges: []uint64{0, 1, 2000},
+			expCounts: []uint64{3, 7, 5},
+			expUnit:   "ns",
+		},
+		{
+			name: "/gc/heap/allocs-by-size:bytes",
+			h: metrics.Float64Histogram{
+				Buckets: []float64{8.5, 16.5, 32.5},
+				Counts:  []uint64{2, 0},
+			},
+			expRanges: []uint64{0, 8, 16, 32},
+			expCounts: []uint64{0, 2, 0, 0},
+			expUnit:   "bytes",
+		},
+		{
+			name: "/custom:objects",
+			h: metrics.Float64Histogram{
+				Buckets: []float64{0, 10, math.Inf(1)},
+				Counts:  []uint64{0, 0},
+			},
+			expRanges: []uint64{0, 10},
+			expCounts: []uint64{0, 0},
+		},
+	}
+
+	for _, test := range tests {
+		gh := FromRuntimeMetrics(test.name, &test.h)
+
+		if !reflect.DeepEqual(gh.Ranges, test.expRanges) ||
+			!reflect.DeepEqual(gh.Counts, test.expCounts) ||
+			gh.Unit() != test.expUnit || gh.Name != test.name {
+			t.Errorf("%s, unexpected histogram: %v, unit: %q",
+				test.name, gh, gh.Unit())
+		}
+
+		if err := gh.Validate(); err != nil {
+			t.Errorf("%s, err: %v", test.name, err)
+		}
+	}
+}
+
+func TestRuntimeSampler(t *testing.T) {
+	const name = "/gc/heap/allocs-by-size:bytes"
+
+	s, err := StartRuntimeSampler(name, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+
+	gh := s.Histogram()
+	if gh.Name != name || gh.Unit() != "bytes" {
+		t.Errorf("unexpected histogram: %v", gh)
+	}
+
+	gh.Reset()
+
+	var sink [][]byte
+	for i := 0; i < 1000; i++ {
+		sink = append(sink, make([]byte, 100))
+	}
+	_ = sink
+	runtime.GC() // Flushes the per-P allocation stats.
+
+	s.Sample()
+	if gh.Total() == 0 {
+		t.Errorf("expected sampled allocations")
+	}
+
+	s.Stop()
+
+	_, err = StartRuntimeSampler("/gc/cycles/total:gc-cycles", time.Hour)
+	if err == nil || !strings.Contains(err.Error(), "not a runtime histogram") {
+		t.Errorf("expected not a histogram err, got: %v", err)
+	}
+}
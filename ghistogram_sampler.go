@@ -0,0 +1,78 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"sync"
+	"time"
+)
+
+// Sampler polls a gauge, such as a queue depth or memory usage, at
+// an interval and records each sample into a histogram, turning the
+// instantaneous gauge into a distribution.
+type Sampler struct {
+	hist  *Histogram
+	gauge func() uint64
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+// StartSampler starts a goroutine that records the value of the gauge
+// into the histogram every interval of the histogram's clock, see
+// SetClock(), until Stop() is invoked.  An interval <= 0 means
+// sampling only happens on demand, see Sample().
+func StartSampler(gh *Histogram, interval time.Duration,
+	gauge func() uint64) *Sampler {
+	s := &Sampler{
+		hist:   gh,
+		gauge:  gauge,
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+
+	if interval > 0 {
+		gh.m.Lock()
+		ticker := clockTicker(gh.clock, interval)
+		gh.m.Unlock()
+
+		go s.run(ticker)
+	} else {
+		close(s.doneCh)
+	}
+
+	return s
+}
+
+func (s *Sampler) run(ticker Ticker) {
+	defer ticker.Stop()
+	defer close(s.doneCh)
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.Chan():
+			s.Sample()
+		}
+	}
+}
+
+// Sample records the current value of the gauge immediately.
+func (s *Sampler) Sample() {
+	s.hist.Add(s.gauge(), 1)
+}
+
+// Stop stops the sampling, waiting for any in-flight sample to be
+// recorded.  It's safe to invoke Stop more than once.
+func (s *Sampler) Stop() {
+	s.stopOnce.Do(func() { close(s.stopCh) })
+	<-s.doneCh
+}
@@ -0,0 +1,66 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSampler(t *testing.T) {
+	gh := NewHistogram(5, 10, 2.0)
+
+	var depth uint64
+	s := StartSampler(gh, time.Millisecond, func() uint64 {
+		return atomic.AddUint64(&depth, 5)
+	})
+
+	for i := 0; i < 1000 && gh.Percentile(100) < 50; i++ {
+		time.Sleep(time.Millisecond)
+	}
+
+	s.Stop()
+	s.Stop()
+
+	var totCount uint64
+	gh.CallSync(func() { totCount = gh.TotCount })
+	if totCount < 10 {
+		t.Fatalf("expected at least 10 samples, got: %d", totCount)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	gh.CallSync(func() {
+		if gh.TotCount != totCount {
+			t.Errorf("expected no samples after Stop")
+		}
+	})
+
+	s.Sample()
+	gh.CallSync(func() {
+		if gh.TotCount != totCount+1 ||
+			gh.MaxDataPoint != atomic.LoadUint64(&depth) {
+			t.Errorf("expected Sample to record the gauge")
+		}
+	})
+}
+
+func TestSamplerOnDemand(t *testing.T) {
+	gh := NewHistogram(5, 10, 2.0)
+
+	s := StartSampler(gh, 0, func() uint64 { return 7 })
+	s.Sample()
+	s.Stop()
+
+	if gh.Total() != 1 {
+		t.Errorf("expected only the on demand sample, got: %d", gh.Total())
+	}
+
+	StartSampler(gh, -time.Second, func() uint64 { return 7 }).Stop()
+}
@@ -0,0 +1,48 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"math/bits"
+)
+
+// Saturated returns true when a count, the TotCount or the
+// TotDataPoint of the histogram reached math.MaxUint64 since creation
+// or the last reset.  Rather than wrapping around to tiny values, such
+// as in long running aggregators that merge many sources, these
+// saturate at math.MaxUint64, so a saturated histogram's counts and
+// percentiles are lower bounds.
+func (gh *Histogram) Saturated() bool {
+	gh.m.Lock()
+	rv := gh.saturated
+	gh.m.Unlock()
+	return rv
+}
+
+// satAddUNLOCKED returns a + b, saturated at math.MaxUint64, where
+// reaching math.MaxUint64 marks the histogram as saturated.
+func (gh *Histogram) satAddUNLOCKED(a, b uint64) uint64 {
+	sum, saturated := satAdd(a, b)
+	if saturated {
+		gh.saturated = true
+		gh.diag.Saturations++
+	}
+	return sum
+}
+
+// satAdd returns a + b, saturated at math.MaxUint64, and whether the
+// sum reached math.MaxUint64.
+func satAdd(a, b uint64) (uint64, bool) {
+	sum, carry := bits.Add64(a, b, 0)
+	if carry != 0 || sum == math.MaxUint64 {
+		return math.MaxUint64, true
+	}
+	return sum, false
+}
@@ -0,0 +1,57 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSaturated(t *testing.T) {
+	gh := NewHistogram(3, 10, 0)
+	gh.Add(5, math.MaxUint64-1)
+	if gh.Saturated() {
+		t.Errorf("expected no saturation yet")
+	}
+
+	gh.Add(15, 2)
+	if !gh.Saturated() || gh.TotCount != math.MaxUint64 ||
+		gh.Total() != math.MaxUint64 || gh.Counts[1] != 2 {
+		t.Errorf("expected a saturated TotCount, got: %+v", gh)
+	}
+
+	merged := gh.CloneEmpty()
+	merged.AddAll(gh)
+	merged.AddAll(gh)
+	if !merged.Saturated() || merged.Counts[0] != math.MaxUint64 ||
+		merged.Counts[1] != 4 {
+		t.Errorf("expected saturated merged counts, got: %+v", merged)
+	}
+
+	weighted := gh.CloneEmpty()
+	weighted.AddAllWeighted(merged, 2)
+	scaled := merged.Clone()
+	scaled.Scale(0.5)
+	if !weighted.Saturated() || weighted.Counts[1] != 8 ||
+		!scaled.Saturated() {
+		t.Errorf("expected saturation to carry over, got: %+v, %+v",
+			weighted, scaled)
+	}
+
+	gh.Reset()
+	if gh.Saturated() {
+		t.Errorf("expected Reset() to clear the saturation")
+	}
+
+	gh.Add(math.MaxUint64, 1)
+	gh.Add(1, 1)
+	if !gh.Saturated() || gh.TotDataPoint != math.MaxUint64 {
+		t.Errorf("expected a saturated TotDataPoint, got: %+v", gh)
+	}
+}
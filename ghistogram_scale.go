@@ -0,0 +1,87 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// Scale multiplies every count of the histogram by the factor, for
+// example to extrapolate a histogram of sampled data points back to
+// the full population.  The scaled counts are converted according to
+// the histogram's Rounding, see SetRounding(), and the TotCount is
+// recomputed as their sum.  The min and max data points are kept,
+// unless the scaled histogram becomes empty.  A negative or NaN factor
+// zeroes the counts.
+func (gh *Histogram) Scale(factor float64) {
+	weigh := gh.weigher(factor)
+
+	gh.m.Lock()
+
+	gh.TotCount = 0
+	for i, c := range gh.Counts {
+		gh.Counts[i] = gh.satAddUNLOCKED(weigh(c), 0)
+		gh.TotCount = gh.satAddUNLOCKED(gh.TotCount, gh.Counts[i])
+	}
+	atomic.StoreUint64(&gh.total, gh.TotCount)
+
+	gh.TotDataPoint = gh.satAddUNLOCKED(weigh(gh.TotDataPoint), 0)
+	gh.overflow = weigh(gh.overflow)
+
+	if gh.TotCount == 0 {
+		gh.MinDataPoint = math.MaxUint64
+		gh.MaxDataPoint = 0
+	}
+
+	gh.slo.scale(weigh)
+
+	if gh.delta != nil {
+		for i, c := range gh.delta.counts {
+			gh.delta.counts[i] = weigh(c)
+		}
+		gh.delta.totCount = weigh(gh.delta.totCount)
+		gh.delta.totDataPoint = weigh(gh.delta.totDataPoint)
+	}
+
+	gh.invalidateSummaryUNLOCKED()
+
+	gh.m.Unlock()
+}
+
+// AddAllWeighted adds all the Counts from the src histogram, each
+// multiplied by the weight, into this histogram, so histograms from
+// nodes with different sampling rates can be combined fairly, for
+// example with a weight of 10 for a node that samples 1 in 10 data
+// points.  The weighted counts are converted according to this
+// histogram's Rounding.  As with AddAll(), both histograms must have
+// the same creation parameters, and the weighted counts are also added
+// to the current interval of the history, see EnableHistory().
+func (gh *Histogram) AddAllWeighted(src *Histogram, weight float64) {
+	gh.addAll(src, gh.weigher(weight), false)
+}
+
+// weigher returns a func that multiplies a count by the factor, with
+// the histogram's Rounding.
+func (gh *Histogram) weigher(factor float64) func(uint64) uint64 {
+	gh.m.Lock()
+	rounding := gh.rounding
+	gh.m.Unlock()
+
+	if math.IsNaN(factor) {
+		factor = 0
+	}
+
+	return func(c uint64) uint64 {
+		if c == 0 || factor == 1 {
+			return c
+		}
+		return roundToUint64(float64(c)*factor, rounding)
+	}
+}
@@ -0,0 +1,111 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestScale(t *testing.T) {
+	tests := []struct {
+		factor    float64
+		rounding  Rounding
+		expCounts []uint64
+	}{
+		{1, RoundFloor, []uint64{3, 5, 0}},
+		{2, RoundFloor, []uint64{6, 10, 0}},
+		{0.5, RoundFloor, []uint64{1, 2, 0}},
+		{0.5, RoundHalfEven, []uint64{2, 2, 0}},
+		{0.5, RoundCeil, []uint64{2, 3, 0}},
+		{0, RoundCeil, []uint64{0, 0, 0}},
+		{-1, RoundCeil, []uint64{0, 0, 0}},
+		{math.NaN(), RoundCeil, []uint64{0, 0, 0}},
+	}
+
+	for testi, test := range tests {
+		gh := NewHistogram(3, 10, 0).WithSLOThresholds(9)
+		gh.SetRounding(test.rounding)
+		gh.Add(5, 3)
+		gh.Add(15, 5)
+
+		gh.Scale(test.factor)
+
+		var expTot uint64
+		for _, c := range test.expCounts {
+			expTot += c
+		}
+
+		if !reflect.DeepEqual(gh.Counts, test.expCounts) ||
+			gh.TotCount != expTot || gh.Total() != expTot ||
+			gh.SLOReport()[0].Violations != test.expCounts[1] {
+			t.Errorf("test #%d, unexpected scale: %+v", testi, gh)
+		}
+
+		if expTot == 0 && (gh.MinDataPoint != math.MaxUint64 ||
+			gh.MaxDataPoint != 0) {
+			t.Errorf("test #%d, expected min/max reset: %+v", testi, gh)
+		}
+	}
+}
+
+func TestAddAllWeighted(t *testing.T) {
+	sampled := NewHistogram(3, 10, 0)
+	sampled.Add(5, 1)
+	sampled.Add(25, 2)
+
+	full := sampled.CloneEmpty()
+	full.Add(15, 7)
+
+	full.AddAllWeighted(sampled, 10)
+
+	if !reflect.DeepEqual(full.Counts, []uint64{10, 7, 20}) ||
+		full.TotCount != 37 || full.MinDataPoint != 5 ||
+		full.MaxDataPoint != 25 {
+		t.Errorf("unexpected weighted merge: %+v", full)
+	}
+
+	empty := full.CloneEmpty()
+	empty.AddAllWeighted(sampled, 0)
+	if empty.TotCount != 0 || empty.MinDataPoint != math.MaxUint64 {
+		t.Errorf("expected a 0 weight to add nothing: %+v", empty)
+	}
+}
+
+func TestAddAllWeightedHistory(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+
+	gh := NewNamedHistogram("get", 5, 10, 2.0)
+	gh.SetClock(clock)
+	gh.EnableHistory(2, time.Minute)
+
+	src := NewNamedHistogram("get", 5, 10, 2.0)
+	src.Add(5, 3)
+	src.Add(15, 3)
+
+	gh.AddAllWeighted(src, 10)
+	clock.Advance(time.Minute)
+
+	h := gh.History()
+	if len(h) != 1 || h[0].Histogram.TotCount != 60 ||
+		!reflect.DeepEqual(h[0].Histogram.Counts, []uint64{30, 30, 0, 0, 0}) {
+		t.Errorf("expected the weighted counts in the history, got: %v", h)
+	}
+
+	if r := gh.RateInWindow(time.Minute); r != 1 {
+		t.Errorf("expected a rate of 1/sec, got: %v", r)
+	}
+
+	if gh.Total() != 60 || gh.Diagnostics() != (Diagnostics{}) {
+		t.Errorf("unexpected total: %d, diagnostics: %+v",
+			gh.Total(), gh.Diagnostics())
+	}
+}
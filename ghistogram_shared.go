@@ -0,0 +1,240 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sync/atomic"
+	"unsafe"
+)
+
+// SharedHistogram is a variant of Histogram whose counters live in a
+// memory mapped file, so another process on the same host, such as a
+// stats scraper sidecar, can read the counts without any IPC, see
+// ReadSharedHistogram(), and the counts survive crashes of the
+// process, as the mapped pages belong to the operating system.  The
+// counters are updated with atomic operations rather than under a
+// lock.  Memory mapped files are only supported on linux and darwin.
+//
+// The file has a fixed size, versioned layout of uint64 words in the
+// host byte order, where the bins follow a header:
+//
+//    word 0     magic (uint32), version (uint32)
+//    word 1     number of bins (uint32), BinBoundary (uint32)
+//    word 2-5   TotCount, TotDataPoint, MinDataPoint, MaxDataPoint
+//    word 6-7   reserved
+//    word 8-15  name, up to 64 bytes, zero padded
+//    ...        the bin ranges, one word per bin
+//    ...        the bin counts, one word per bin
+type SharedHistogram struct {
+	// Histogram name.
+	Name string
+
+	layout *Histogram // Immutable, for the bin ranges and transform.
+
+	data  []byte   // The mapped file region.
+	words []uint64 // The data as uint64 words.
+
+	counts []uint64 // A subslice of words.
+
+	closer func() error
+}
+
+const (
+	sharedMagic   = 0x6768736d // "ghsm".
+	sharedVersion = 1
+
+	sharedWordTotCount     = 2
+	sharedWordTotDataPoint = 3
+	sharedWordMinDataPoint = 4
+	sharedWordMaxDataPoint = 5
+	sharedWordName         = 8
+	sharedWordBins         = 16
+
+	sharedNameMax = (sharedWordBins - sharedWordName) * 8
+)
+
+// sharedFileSize returns the size in bytes of the file of a
+// SharedHistogram with numBins bins.
+func sharedFileSize(numBins int) int {
+	return (sharedWordBins + 2*numBins) * 8
+}
+
+// bytesToWords views the 8-byte aligned data as uint64 words.  The
+// slice header is built from the length of the data, rather than by
+// converting to a pointer to a large array, so it builds on 32-bit
+// platforms.
+func bytesToWords(data []byte) []uint64 {
+	n := len(data) / 8
+	if n == 0 {
+		return nil
+	}
+
+	var words []uint64
+	sh := (*reflect.SliceHeader)(unsafe.Pointer(&words))
+	sh.Data = uintptr(unsafe.Pointer(&data[0]))
+	sh.Len, sh.Cap = n, n
+
+	return words
+}
+
+// initShared writes the header and ranges of the layout into
+// the zeroed words of a new file.
+func initShared(words []uint64, name string, layout *Histogram) {
+	words[0] = sharedMagic<<32 | sharedVersion
+	words[1] = uint64(len(layout.Ranges))<<32 | uint64(uint32(layout.boundary))
+	words[sharedWordMinDataPoint] = math.MaxUint64
+
+	if len(name) > sharedNameMax {
+		name = name[:sharedNameMax]
+	}
+	nameBytes := (*[sharedNameMax]byte)(unsafe.Pointer(&words[sharedWordName]))
+	copy(nameBytes[:], name)
+
+	copy(words[sharedWordBins:], layout.Ranges)
+}
+
+// checkShared returns an error unless the words hold a valid header
+// and, when the layout is non-nil, the ranges of the layout.
+func checkShared(words []uint64, layout *Histogram) error {
+	if len(words) < sharedWordBins {
+		return fmt.Errorf("file too small for a header")
+	}
+	if words[0]>>32 != sharedMagic {
+		return fmt.Errorf("not a shared histogram file")
+	}
+	if v := uint32(words[0]); v != sharedVersion {
+		return fmt.Errorf("unsupported version: %d", v)
+	}
+
+	numBins := int(words[1] >> 32)
+	if len(words)*8 != sharedFileSize(numBins) {
+		return fmt.Errorf("file size does not match %d bins", numBins)
+	}
+
+	if layout != nil {
+		if numBins != len(layout.Ranges) ||
+			BinBoundary(uint32(words[1])) != layout.boundary {
+			return fmt.Errorf("bin layout does not match")
+		}
+		for i, r := range layout.Ranges {
+			if words[sharedWordBins+i] != r {
+				return fmt.Errorf("bin layout does not match")
+			}
+		}
+	}
+
+	return nil
+}
+
+func newSharedHistogram(name string, layout *Histogram,
+	data []byte, closer func() error) *SharedHistogram {
+	words := bytesToWords(data)
+	numBins := int(words[1] >> 32)
+
+	return &SharedHistogram{
+		Name:   name,
+		layout: layout,
+		data:   data,
+		words:  words,
+		counts: words[sharedWordBins+numBins:],
+		closer: closer,
+	}
+}
+
+// Add increases the count in the bin for the given dataPoint in a
+// concurrent-safe manner, including with other processes that have
+// the same file open.
+func (h *SharedHistogram) Add(dataPoint uint64, count uint64) {
+	l := h.layout
+
+	idx := binIndex(l.Ranges, l.boundary, l.binValue(dataPoint))
+	if idx < 0 {
+		return
+	}
+
+	atomic.AddUint64(&h.counts[idx], count)
+	atomic.AddUint64(&h.words[sharedWordTotCount], count)
+	atomic.AddUint64(&h.words[sharedWordTotDataPoint], dataPoint)
+
+	for {
+		min := atomic.LoadUint64(&h.words[sharedWordMinDataPoint])
+		if min <= dataPoint || atomic.CompareAndSwapUint64(
+			&h.words[sharedWordMinDataPoint], min, dataPoint) {
+			break
+		}
+	}
+
+	for {
+		max := atomic.LoadUint64(&h.words[sharedWordMaxDataPoint])
+		if max >= dataPoint || atomic.CompareAndSwapUint64(
+			&h.words[sharedWordMaxDataPoint], max, dataPoint) {
+			break
+		}
+	}
+}
+
+// Histogram returns a regular Histogram copy of the SharedHistogram.
+// As the counters are read one at a time while they may be updated,
+// the TotCount is the sum of the copied counts.
+func (h *SharedHistogram) Histogram() *Histogram {
+	rv := h.layout.CloneEmpty()
+	rv.Name = h.Name
+
+	loadShared(rv, h.words)
+
+	return rv
+}
+
+// loadShared copies the counts and data point statistics of the words
+// of a shared histogram file into the empty histogram of its layout.
+func loadShared(rv *Histogram, words []uint64) {
+	counts := words[sharedWordBins+len(rv.Ranges):]
+	for i := range rv.Counts {
+		c := atomic.LoadUint64(&counts[i])
+		rv.Counts[i] = c
+		rv.TotCount += c
+	}
+	rv.total = rv.TotCount
+	rv.TotDataPoint = atomic.LoadUint64(&words[sharedWordTotDataPoint])
+	rv.MinDataPoint = atomic.LoadUint64(&words[sharedWordMinDataPoint])
+	rv.MaxDataPoint = atomic.LoadUint64(&words[sharedWordMaxDataPoint])
+}
+
+// Close unmaps and closes the file.  The SharedHistogram must not be
+// used after Close.
+func (h *SharedHistogram) Close() error {
+	h.words, h.counts = nil, nil
+	return h.closer()
+}
+
+// sharedLayout returns a histogram with the ranges, boundary and name
+// recorded in the checked words of a shared histogram file.
+func sharedLayout(words []uint64) *Histogram {
+	numBins := int(words[1] >> 32)
+
+	nameBytes := (*[sharedNameMax]byte)(unsafe.Pointer(&words[sharedWordName]))
+	n := 0
+	for n < sharedNameMax && nameBytes[n] != 0 {
+		n++
+	}
+
+	rv := &Histogram{
+		Name:         string(nameBytes[:n]),
+		Ranges:       make([]uint64, numBins),
+		Counts:       make([]uint64, numBins),
+		MinDataPoint: math.MaxUint64,
+		boundary:     BinBoundary(uint32(words[1])),
+	}
+	copy(rv.Ranges, words[sharedWordBins:])
+
+	return rv
+}
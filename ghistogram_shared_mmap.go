@@ -0,0 +1,121 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+//go:build linux || darwin
+// +build linux darwin
+
+package ghistogram
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// OpenSharedHistogram opens the shared histogram file at path, with
+// the bin ranges, bin boundary and transform of the layout histogram,
+// creating the file if it doesn't exist.  The counts of an existing
+// file, such as one left by a crashed process, are kept, and its bin
+// layout must match the layout histogram.
+func OpenSharedHistogram(path, name string,
+	layout *Histogram) (*SharedHistogram, error) {
+	layout.m.Lock()
+	l := layout.CloneEmpty()
+	layout.m.Unlock()
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	data, created, err := mapShared(f, len(l.Ranges))
+	f.Close() // The mapping stays valid after the close.
+	if err != nil {
+		return nil, fmt.Errorf("ghistogram: OpenSharedHistogram,"+
+			" path: %s, err: %v", path, err)
+	}
+
+	words := bytesToWords(data)
+	if created {
+		initShared(words, name, l)
+	} else if err = checkShared(words, l); err != nil {
+		syscall.Munmap(data)
+		return nil, fmt.Errorf("ghistogram: OpenSharedHistogram,"+
+			" path: %s, err: %v", path, err)
+	}
+
+	return newSharedHistogram(name, l, data, func() error {
+		return syscall.Munmap(data)
+	}), nil
+}
+
+// mapShared maps the file read-write, first sizing it for numBins bins
+// when it is empty, which is reported as created.
+func mapShared(f *os.File, numBins int) ([]byte, bool, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+
+	size, created := int(fi.Size()), fi.Size() == 0
+	if created {
+		size = sharedFileSize(numBins)
+		if err = f.Truncate(int64(size)); err != nil {
+			return nil, false, err
+		}
+	} else if size != sharedFileSize(numBins) {
+		return nil, false, fmt.Errorf("file size does not match %d bins",
+			numBins)
+	}
+
+	data, err := syscall.Mmap(int(f.Fd()), 0, size,
+		syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+
+	return data, created, err
+}
+
+// ReadSharedHistogram returns a regular Histogram copy of the shared
+// histogram file at path, which another process may be updating, see
+// SharedHistogram.  The copy has the name and bin ranges recorded in
+// the file, but not the transform of the writer's layout histogram.
+func ReadSharedHistogram(path string) (*Histogram, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	size := int(fi.Size())
+	if size < sharedFileSize(0) {
+		return nil, fmt.Errorf("ghistogram: ReadSharedHistogram,"+
+			" path: %s, err: file too small for a header", path)
+	}
+
+	data, err := syscall.Mmap(int(f.Fd()), 0, size,
+		syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.Munmap(data)
+
+	words := bytesToWords(data)
+	if err = checkShared(words, nil); err != nil {
+		return nil, fmt.Errorf("ghistogram: ReadSharedHistogram,"+
+			" path: %s, err: %v", path, err)
+	}
+
+	rv := sharedLayout(words)
+	loadShared(rv, words)
+
+	return rv, nil
+}
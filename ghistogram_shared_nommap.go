@@ -0,0 +1,32 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package ghistogram
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// OpenSharedHistogram returns an error, as memory mapped files are
+// only supported on linux and darwin, see SharedHistogram.
+func OpenSharedHistogram(path, name string,
+	layout *Histogram) (*SharedHistogram, error) {
+	return nil, fmt.Errorf("ghistogram: OpenSharedHistogram,"+
+		" not supported on %s", runtime.GOOS)
+}
+
+// ReadSharedHistogram returns an error, as memory mapped files are
+// only supported on linux and darwin, see SharedHistogram.
+func ReadSharedHistogram(path string) (*Histogram, error) {
+	return nil, fmt.Errorf("ghistogram: ReadSharedHistogram,"+
+		" not supported on %s", runtime.GOOS)
+}
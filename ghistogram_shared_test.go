@@ -0,0 +1,111 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+//go:build linux || darwin
+// +build linux darwin
+
+package ghistogram
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSharedHistogram(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ghistogram")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "get.ghsm")
+	layout := NewNamedHistogram("layout", 5, 10, 2)
+
+	sh, err := OpenSharedHistogram(path, "get", layout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fi, _ := os.Stat(path)
+	if fi.Size() != int64(sharedFileSize(5)) {
+		t.Errorf("expected fixed file size %d, got: %d",
+			sharedFileSize(5), fi.Size())
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			for i := uint64(0); i < 100; i++ {
+				sh.Add(i, 1)
+			}
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+
+	exp := layout.CloneEmpty()
+	exp.Name = "get"
+	for g := 0; g < 4; g++ {
+		for i := uint64(0); i < 100; i++ {
+			exp.Add(i, 1)
+		}
+	}
+
+	got := sh.Histogram()
+	if got.String() != exp.String() || got.TotDataPoint != exp.TotDataPoint ||
+		got.MinDataPoint != 0 || got.MaxDataPoint != 99 {
+		t.Errorf("unexpected histogram, got:\n%s\nexp:\n%s", got, exp)
+	}
+
+	// A sidecar reads the counts while the writer has the file open.
+	read, err := ReadSharedHistogram(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.String() != exp.String() {
+		t.Errorf("unexpected read histogram, got:\n%s\nexp:\n%s", read, exp)
+	}
+
+	if err = sh.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The counts survive reopening, as after a crash.
+	sh, err = OpenSharedHistogram(path, "get", layout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sh.Add(5, 10)
+	if got = sh.Histogram(); got.TotCount != 410 {
+		t.Errorf("expected counts to survive a reopen, got: %d",
+			got.TotCount)
+	}
+	sh.Close()
+
+	_, err = OpenSharedHistogram(path, "get", NewNamedHistogram("", 5, 20, 2))
+	if err == nil || !strings.Contains(err.Error(), "layout does not match") {
+		t.Errorf("expected a layout mismatch err, got: %v", err)
+	}
+
+	other := filepath.Join(dir, "other")
+	ioutil.WriteFile(other, make([]byte, sharedFileSize(5)), 0644)
+
+	_, err = ReadSharedHistogram(other)
+	if err == nil || !strings.Contains(err.Error(), "not a shared histogram") {
+		t.Errorf("expected a not shared histogram err, got: %v", err)
+	}
+
+	if _, err = ReadSharedHistogram(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("expected err for a missing file")
+	}
+}
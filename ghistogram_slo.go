@@ -0,0 +1,162 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// sloState holds exact counts of the data points exceeding each of a
+// histogram's SLO thresholds.
+type sloState struct {
+	thresholds []uint64 // Sorted ascending.
+	violations []uint64 // Data points > the corresponding threshold.
+}
+
+// SLOStatus is the compliance of a histogram with an SLO threshold.
+type SLOStatus struct {
+	Threshold  uint64
+	Violations uint64 // Count of data points > Threshold.
+	Total      uint64 // Count of all data points.
+}
+
+// WithSLOThresholds registers SLO thresholds, for which Add() keeps
+// exact counts of the data points above each threshold, so that SLO
+// compliance is not subject to the error of bins whose boundaries
+// don't match the thresholds.  They're meant to be registered right
+// after creation, as they do not account for data points added
+// earlier, for example:
+//
+//    gh := ghistogram.NewNamedHistogram("get (µs)", 20, 10, 2.0).
+//        WithSLOThresholds(1000, 10000)
+//
+// The thresholds are reported by SLOReport() and in the footer of
+// EmitGraph().  Calling WithSLOThresholds again replaces the
+// thresholds and clears their counts.  Returns the histogram.
+func (gh *Histogram) WithSLOThresholds(thresholds ...uint64) *Histogram {
+	var slo *sloState
+	if len(thresholds) > 0 {
+		slo = &sloState{
+			thresholds: append([]uint64(nil), thresholds...),
+			violations: make([]uint64, len(thresholds)),
+		}
+		sort.Slice(slo.thresholds, func(i, j int) bool {
+			return slo.thresholds[i] < slo.thresholds[j]
+		})
+	}
+
+	gh.m.Lock()
+	gh.slo = slo
+	gh.m.Unlock()
+
+	return gh
+}
+
+// SLOReport returns the compliance with every SLO threshold, in
+// ascending threshold order, see WithSLOThresholds().
+func (gh *Histogram) SLOReport() []SLOStatus {
+	gh.m.Lock()
+	defer gh.m.Unlock()
+
+	if gh.slo == nil {
+		return nil
+	}
+
+	rv := make([]SLOStatus, len(gh.slo.thresholds))
+	for i, threshold := range gh.slo.thresholds {
+		rv[i] = SLOStatus{
+			Threshold:  threshold,
+			Violations: gh.slo.violations[i],
+			Total:      gh.TotCount,
+		}
+	}
+
+	return rv
+}
+
+func (s *sloState) add(dataPoint uint64, count uint64) {
+	for i, threshold := range s.thresholds {
+		if dataPoint <= threshold {
+			break
+		}
+		s.violations[i] += count
+	}
+}
+
+func (s *sloState) cloneEmpty() *sloState {
+	if s == nil {
+		return nil
+	}
+
+	return &sloState{
+		thresholds: s.thresholds,
+		violations: make([]uint64, len(s.thresholds)),
+	}
+}
+
+func (s *sloState) reset() {
+	if s != nil {
+		for i := range s.violations {
+			s.violations[i] = 0
+		}
+	}
+}
+
+// addAll adds the violations of src, when it has the same thresholds,
+// passed through the optional weigh func.
+func (s *sloState) addAll(src *sloState, weigh func(uint64) uint64) {
+	if s == nil || src == nil || len(s.thresholds) != len(src.thresholds) {
+		return
+	}
+
+	for i := range s.thresholds {
+		if s.thresholds[i] != src.thresholds[i] {
+			return
+		}
+	}
+
+	for i := range s.violations {
+		if weigh != nil {
+			s.violations[i] += weigh(src.violations[i])
+		} else {
+			s.violations[i] += src.violations[i]
+		}
+	}
+}
+
+// scale passes the violations through the weigh func, see Scale().
+func (s *sloState) scale(weigh func(uint64) uint64) {
+	if s != nil {
+		for i := range s.violations {
+			s.violations[i] = weigh(s.violations[i])
+		}
+	}
+}
+
+// emitFooter emits a line per threshold, like "(slo <= 1000: 99.50%,
+// 5 over)".
+func (s *sloState) emitFooter(prefix []byte, out *bytes.Buffer,
+	totCount uint64) {
+	if totCount == 0 {
+		return
+	}
+
+	for i, threshold := range s.thresholds {
+		if prefix != nil {
+			out.Write(prefix)
+		}
+
+		p := percentHundredths(totCount-s.violations[i], totCount)
+
+		fmt.Fprintf(out, "(slo <= %v: %d.%02d%%, %v over)\n",
+			threshold, p/100, p%100, s.violations[i])
+	}
+}
@@ -0,0 +1,60 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSLOThresholds(t *testing.T) {
+	gh := NewNamedHistogram("get", 3, 100, 0.0).WithSLOThresholds(150, 50)
+
+	gh.Add(10, 10)
+	gh.Add(60, 5)
+	gh.Add(150, 3)
+	gh.Add(151, 2)
+
+	exp := []SLOStatus{
+		{Threshold: 50, Violations: 10, Total: 20},
+		{Threshold: 150, Violations: 2, Total: 20},
+	}
+	if got := gh.SLOReport(); !reflect.DeepEqual(got, exp) {
+		t.Errorf("expected: %+v, got: %+v", exp, got)
+	}
+
+	expGraph := `get (20 Total)
+[0 - 100]     75.00%   75.00% ############################## (15)
+[100 - 200]   25.00%  100.00% ########## (5)
+(slo <= 50: 50.00%, 10 over)
+(slo <= 150: 90.00%, 2 over)
+`
+	if got := gh.EmitGraph(nil, nil).String(); got != expGraph {
+		t.Errorf("expected graph:\n%s\ngot:\n%s", expGraph, got)
+	}
+
+	c := gh.CloneEmpty()
+	c.AddAll(gh)
+	c.AddAll(gh)
+	if got := c.SLOReport(); got[0].Violations != 20 || got[1].Total != 40 {
+		t.Errorf("expected AddAll to merge violations, got: %+v", got)
+	}
+
+	gh.Reset()
+	if got := gh.SLOReport(); got[0].Violations != 0 || got[1].Total != 0 {
+		t.Errorf("expected Reset to clear violations, got: %+v", got)
+	}
+	if c.SLOReport()[0].Violations != 20 {
+		t.Errorf("expected clone to have its own violations")
+	}
+
+	if gh.WithSLOThresholds().SLOReport() != nil {
+		t.Errorf("expected no thresholds")
+	}
+}
@@ -0,0 +1,53 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+//go:build go1.21
+// +build go1.21
+
+package ghistogram
+
+import (
+	"log/slog"
+)
+
+// LogValue implements slog.LogValuer, so logging a histogram emits its
+// count, estimated mean, min, max and key percentiles as structured
+// attributes instead of a multi-line ascii graph, for example:
+//
+//    slog.Info("interval stats", "get", gh)
+//
+// The attributes are empty, other than the name and count, while the
+// histogram is empty.
+func (gh *Histogram) LogValue() slog.Value {
+	gh.m.Lock()
+	defer gh.m.Unlock()
+
+	attrs := []slog.Attr{
+		slog.String("name", gh.Name),
+		slog.Uint64("count", gh.TotCount),
+	}
+
+	if gh.TotCount > 0 {
+		s := gh.summaryUNLOCKED()
+
+		attrs = append(attrs,
+			slog.Float64("mean", gh.meanUNLOCKED()),
+			slog.Uint64("min", s.Min),
+			slog.Uint64("max", s.Max),
+			slog.Uint64("p50", s.P50),
+			slog.Uint64("p90", s.P90),
+			slog.Uint64("p99", s.P99),
+			slog.Uint64("p99.9", s.P999))
+	}
+
+	if gh.unit != "" {
+		attrs = append(attrs, slog.String("unit", gh.unit))
+	}
+
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,44 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+//go:build go1.21
+// +build go1.21
+
+package ghistogram
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+func TestLogValue(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	gh := NewNamedHistogram("get", 4, 10, 2.0).WithUnit("µs")
+	gh.Add(5, 2)
+	gh.Add(15, 2)
+
+	logger.Info("stats", "get", gh,
+		"empty", NewNamedHistogram("set", 4, 10, 2.0))
+
+	exp := `level=INFO msg=stats get.name=get get.count=4 get.mean=10 ` +
+		`get.min=5 get.max=15 get.p50=10 get.p90=14 get.p99=14 ` +
+		`get.p99.9=14 get.unit=µs empty.name=set empty.count=0` + "\n"
+	if got := out.String(); got != exp {
+		t.Errorf("unexpected log, got:\n%s\nexp:\n%s", got, exp)
+	}
+}
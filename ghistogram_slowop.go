@@ -0,0 +1,136 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"time"
+)
+
+// SlowOpHookInterval is the default minimum time between two
+// invocations of a hook, see HookOptions.  It is read when a hook is
+// registered, not when data points are added.
+var SlowOpHookInterval = time.Second
+
+// HookOptions changes how a hook is invoked, see
+// OnThresholdExceededWithOptions().
+type HookOptions struct {
+	// Interval is the minimum time between two invocations of the
+	// hook, according to the histogram's clock.  An Interval of 0
+	// means SlowOpHookInterval, and a negative Interval invokes the
+	// hook for every data point.
+	Interval time.Duration
+}
+
+// slowOpHook holds the state of a registered hook.
+type slowOpHook struct {
+	threshold uint64
+	interval  time.Duration
+	fn        func(dataPoint uint64)
+	last      time.Time
+}
+
+func newSlowOpHook(threshold uint64, fn func(dataPoint uint64),
+	opts HookOptions) *slowOpHook {
+	if opts.Interval == 0 {
+		opts.Interval = SlowOpHookInterval
+	}
+	return &slowOpHook{threshold: threshold, interval: opts.Interval, fn: fn}
+}
+
+// OnThresholdExceeded registers fn to be invoked when Add() records a
+// data point above the threshold, so applications can log the stack or
+// context of outliers, or sample their traces.  A histogram may have a
+// hook for each of several thresholds, each rate-limited separately to
+// one invocation per SlowOpHookInterval.  Registering a hook for the
+// same threshold again replaces it, and a nil fn removes it.
+//
+// The fn is invoked while the histogram is locked, so it must not
+// call any of the histogram's methods.
+func (gh *Histogram) OnThresholdExceeded(threshold uint64,
+	fn func(value uint64)) {
+	gh.OnThresholdExceededWithOptions(threshold, fn, HookOptions{})
+}
+
+// OnThresholdExceededWithOptions is like OnThresholdExceeded(), but
+// invokes the hook according to the options.
+func (gh *Histogram) OnThresholdExceededWithOptions(threshold uint64,
+	fn func(value uint64), opts HookOptions) {
+	var h *slowOpHook
+	if fn != nil {
+		h = newSlowOpHook(threshold, fn, opts)
+	}
+
+	gh.m.Lock()
+	gh.setThresholdHookUNLOCKED(threshold, h)
+	gh.m.Unlock()
+}
+
+// setThresholdHookUNLOCKED replaces or removes, when h is nil, the
+// hook of the threshold.
+func (gh *Histogram) setThresholdHookUNLOCKED(threshold uint64,
+	h *slowOpHook) {
+	for i, old := range gh.thresholdHooks {
+		if old.threshold == threshold {
+			if h != nil {
+				gh.thresholdHooks[i] = h
+			} else {
+				gh.thresholdHooks = append(gh.thresholdHooks[:i:i],
+					gh.thresholdHooks[i+1:]...)
+			}
+			return
+		}
+	}
+
+	if h != nil {
+		gh.thresholdHooks = append(gh.thresholdHooks, h)
+	}
+}
+
+// SlowOpHook registers fn like OnThresholdExceeded(), where a later
+// SlowOpHook() call replaces the hook of this call, whatever its
+// threshold, and a nil fn removes it.  A hook registered for the same
+// threshold via OnThresholdExceeded() is replaced, and vice versa.
+func (gh *Histogram) SlowOpHook(threshold uint64, fn func(dataPoint uint64)) {
+	var h *slowOpHook
+	if fn != nil {
+		h = newSlowOpHook(threshold, fn, HookOptions{})
+	}
+
+	gh.m.Lock()
+	for i, old := range gh.thresholdHooks {
+		if old == gh.slowOp {
+			gh.thresholdHooks = append(gh.thresholdHooks[:i:i],
+				gh.thresholdHooks[i+1:]...)
+			break
+		}
+	}
+	gh.slowOp = h
+	if h != nil {
+		gh.setThresholdHookUNLOCKED(threshold, h)
+	}
+	gh.m.Unlock()
+}
+
+// check invokes the hook if the dataPoint is above the threshold and
+// the hook wasn't invoked recently according to the clock.
+func (h *slowOpHook) check(dataPoint uint64, clock Clock) {
+	if dataPoint <= h.threshold {
+		return
+	}
+
+	if h.interval > 0 {
+		now := clockNow(clock)
+		if !h.last.IsZero() && now.Sub(h.last) < h.interval {
+			return
+		}
+		h.last = now
+	}
+
+	h.fn(dataPoint)
+}
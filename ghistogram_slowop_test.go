@@ -0,0 +1,137 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSlowOpHook(t *testing.T) {
+	defer func(orig time.Duration) { SlowOpHookInterval = orig }(
+		SlowOpHookInterval)
+	SlowOpHookInterval = time.Hour
+
+	gh := NewHistogram(5, 10, 2.0)
+
+	var slow []uint64
+	gh.SlowOpHook(50, func(dataPoint uint64) {
+		slow = append(slow, dataPoint)
+	})
+
+	gh.Add(50, 1)
+	gh.Add(60, 1)
+	gh.Add(70, 1) // Rate-limited.
+
+	if len(slow) != 1 || slow[0] != 60 {
+		t.Errorf("unexpected slow ops: %v", slow)
+	}
+
+	// The interval is read when the hook is registered.
+	SlowOpHookInterval = 0
+	gh.Add(80, 1)
+	if len(slow) != 1 {
+		t.Errorf("unexpected slow ops: %v", slow)
+	}
+
+	// Replaces the hook of the threshold of 50.
+	gh.SlowOpHook(75, func(dataPoint uint64) {
+		slow = append(slow, dataPoint)
+	})
+	gh.Add(70, 1)
+	gh.Add(80, 1)
+	if len(slow) != 2 || slow[1] != 80 || len(gh.thresholdHooks) != 1 {
+		t.Errorf("unexpected slow ops: %v", slow)
+	}
+
+	gh.SlowOpHook(50, nil)
+	gh.Add(90, 1)
+	if len(slow) != 2 || gh.TotCount != 7 || len(gh.thresholdHooks) != 0 {
+		t.Errorf("expected the hook to be removed")
+	}
+}
+
+func TestOnThresholdExceededWithOptions(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+
+	gh := NewHistogram(5, 10, 2.0)
+	gh.SetClock(clock)
+
+	var every, minutely []uint64
+	gh.OnThresholdExceededWithOptions(50, func(value uint64) {
+		every = append(every, value)
+	}, HookOptions{Interval: -1})
+	gh.OnThresholdExceededWithOptions(100, func(value uint64) {
+		minutely = append(minutely, value)
+	}, HookOptions{Interval: time.Minute})
+
+	gh.Add(200, 1)
+	gh.Add(300, 1)
+	clock.Advance(time.Second)
+	gh.Add(400, 1)
+	clock.Advance(time.Minute)
+	gh.Add(500, 1)
+
+	if !reflect.DeepEqual(every, []uint64{200, 300, 400, 500}) ||
+		!reflect.DeepEqual(minutely, []uint64{200, 500}) {
+		t.Errorf("unexpected hooks, every: %v, minutely: %v",
+			every, minutely)
+	}
+}
+
+func TestOnThresholdExceeded(t *testing.T) {
+	defer func(orig time.Duration) { SlowOpHookInterval = orig }(
+		SlowOpHookInterval)
+	SlowOpHookInterval = time.Second
+
+	clock := NewManualClock(time.Unix(0, 0))
+
+	gh := NewHistogram(5, 10, 2.0)
+	gh.SetClock(clock)
+
+	var logged, traced []uint64
+	gh.OnThresholdExceeded(50, func(value uint64) {
+		logged = append(logged, value)
+	})
+	gh.OnThresholdExceeded(100, func(value uint64) {
+		traced = append(traced, value)
+	})
+
+	gh.Add(50, 1)
+	gh.Add(60, 1)
+	gh.Add(200, 1) // Rate-limited for the threshold of 50.
+
+	clock.Advance(time.Second)
+	gh.Add(300, 1)
+	gh.Add(400, 1) // Rate-limited for both thresholds.
+
+	if !reflect.DeepEqual(logged, []uint64{60, 300}) ||
+		!reflect.DeepEqual(traced, []uint64{200, 300}) {
+		t.Errorf("unexpected hooks, logged: %v, traced: %v",
+			logged, traced)
+	}
+
+	// Replace the hook of 50, and remove the hook of 100.
+	var replaced []uint64
+	gh.OnThresholdExceeded(50, func(value uint64) {
+		replaced = append(replaced, value)
+	})
+	gh.OnThresholdExceeded(100, nil)
+	gh.OnThresholdExceeded(1000, nil) // Not registered.
+
+	clock.Advance(time.Second)
+	gh.Add(500, 1)
+
+	if len(logged) != 2 || len(traced) != 2 ||
+		!reflect.DeepEqual(replaced, []uint64{500}) {
+		t.Errorf("unexpected hooks, logged: %v, traced: %v, replaced: %v",
+			logged, traced, replaced)
+	}
+}
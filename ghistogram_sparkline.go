@@ -0,0 +1,66 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sparkBlocks holds the block characters of increasing height.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline returns a one line rendering of the distribution, for
+// periodic log lines where a full graph is too verbose, for example:
+//
+//    ▂█ ▄▁ (141 Total)
+//
+// Each character is a bin, from the first to the last non-empty bin,
+// with a height relative to the fullest bin, and empty bins as spaces.
+func (gh *Histogram) Sparkline() string {
+	gh.m.Lock()
+	defer gh.m.Unlock()
+
+	if gh.TotCount == 0 {
+		return "(empty)"
+	}
+
+	first, last := -1, -1
+	var maxCount uint64
+	for i, c := range gh.Counts {
+		if c > 0 {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+		if maxCount < c {
+			maxCount = c
+		}
+	}
+
+	var b strings.Builder
+	for _, c := range gh.Counts[first : last+1] {
+		if c == 0 {
+			b.WriteByte(' ')
+			continue
+		}
+
+		// Rounds up, so any non-empty bin is at least the lowest block.
+		level := mulDiv(c, uint64(len(sparkBlocks)), maxCount)
+		if mulDiv(level, maxCount, uint64(len(sparkBlocks))) < c {
+			level++
+		}
+		b.WriteRune(sparkBlocks[level-1])
+	}
+
+	fmt.Fprintf(&b, " (%v Total)", gh.TotCount)
+
+	return b.String()
+}
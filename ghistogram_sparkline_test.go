@@ -0,0 +1,37 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"testing"
+)
+
+func TestSparkline(t *testing.T) {
+	gh := NewHistogram(10, 10, 0.0)
+
+	if got := gh.Sparkline(); got != "(empty)" {
+		t.Errorf("expected empty, got: %q", got)
+	}
+
+	gh.Add(15, 20)
+	gh.Add(25, 80)
+	gh.Add(45, 40)
+	gh.Add(55, 1)
+
+	exp := "▂█ ▄▁ (141 Total)"
+	if got := gh.Sparkline(); got != exp {
+		t.Errorf("expected: %q, got: %q", exp, got)
+	}
+
+	gh.Reset()
+	gh.Add(1000, 1)
+	if got := gh.Sparkline(); got != "█ (1 Total)" {
+		t.Errorf("expected a single block, got: %q", got)
+	}
+}
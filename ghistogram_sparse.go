@@ -0,0 +1,220 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// SparseHistogram is a histogram for value domains spanning many
+// orders of magnitude, such as bytes to terabytes, where a dense bin
+// array would waste memory or truncate the range.  It uses the same
+// log-linear buckets as NewLogLinearHistogram(), covering the whole
+// uint64 domain, but only keeps the buckets that have counts.
+//
+// Unlike Histogram, adding a data point to a new bucket allocates.
+// The SparseHistogram is concurrent safe.
+type SparseHistogram struct {
+	// Histogram name.
+	Name string
+
+	// Counts maps the lower bound of each non-empty bucket to the
+	// bucket's count.
+	Counts map[uint64]uint64
+
+	// TotCount is the sum of all counts.
+	TotCount uint64
+
+	TotDataPoint uint64 // TotDataPoint is the sum of all data points.
+	MinDataPoint uint64 // MinDataPoint is the smallest data point seen.
+	MaxDataPoint uint64 // MaxDataPoint is the largest data point seen.
+
+	layout *hdrLayout
+
+	m sync.Mutex
+}
+
+// NewSparseHistogram creates a new, ready to use SparseHistogram
+// whose buckets have a relative width of at most 10^-sigDigits.  The
+// sigDigits must be in [1, 5], otherwise NewSparseHistogram panics.
+func NewSparseHistogram(name string, sigDigits int) *SparseHistogram {
+	l, err := newHdrLayout(1, math.MaxInt64, int64(sigDigits))
+	if err != nil {
+		panic(err)
+	}
+
+	return &SparseHistogram{
+		Name:         name,
+		Counts:       make(map[uint64]uint64),
+		MinDataPoint: math.MaxUint64,
+		layout:       l,
+	}
+}
+
+// bucket returns the lower and upper bounds of the bucket holding
+// the dataPoint, where the upper bound of the last bucket saturates.
+func (sh *SparseHistogram) bucket(dataPoint uint64) (lower, upper uint64) {
+	idx := sh.layout.countsIndex(dataPoint)
+
+	lower = sh.layout.valueFromIndex(idx)
+	upper = sh.layout.valueFromIndex(idx + 1)
+	if upper <= lower {
+		upper = math.MaxUint64
+	}
+
+	return lower, upper
+}
+
+// Add increases the count in the bucket for the given dataPoint in a
+// concurrent-safe manner.
+func (sh *SparseHistogram) Add(dataPoint uint64, count uint64) {
+	lower, _ := sh.bucket(dataPoint)
+
+	sh.m.Lock()
+	sh.Counts[lower] += count
+	sh.TotCount += count
+
+	sh.TotDataPoint += dataPoint
+	if sh.MinDataPoint > dataPoint {
+		sh.MinDataPoint = dataPoint
+	}
+	if sh.MaxDataPoint < dataPoint {
+		sh.MaxDataPoint = dataPoint
+	}
+	sh.m.Unlock()
+}
+
+// AddAll adds all the Counts from the src histogram into this
+// histogram.  Both histograms must have the same sigDigits.
+func (sh *SparseHistogram) AddAll(src *SparseHistogram) {
+	src.m.Lock()
+	sh.m.Lock()
+
+	for k, c := range src.Counts {
+		sh.Counts[k] += c
+	}
+	sh.TotCount += src.TotCount
+
+	sh.TotDataPoint += src.TotDataPoint
+	if sh.MinDataPoint > src.MinDataPoint {
+		sh.MinDataPoint = src.MinDataPoint
+	}
+	if sh.MaxDataPoint < src.MaxDataPoint {
+		sh.MaxDataPoint = src.MaxDataPoint
+	}
+
+	sh.m.Unlock()
+	src.m.Unlock()
+}
+
+// Reset clears all the counts and data point statistics.
+func (sh *SparseHistogram) Reset() {
+	sh.m.Lock()
+	sh.Counts = make(map[uint64]uint64)
+	sh.TotCount = 0
+
+	sh.TotDataPoint = 0
+	sh.MinDataPoint = math.MaxUint64
+	sh.MaxDataPoint = 0
+	sh.m.Unlock()
+}
+
+// sortedBucketsUNLOCKED returns the lower bounds of the non-empty
+// buckets in increasing order.
+func (sh *SparseHistogram) sortedBucketsUNLOCKED() []uint64 {
+	lowers := make([]uint64, 0, len(sh.Counts))
+	for k, c := range sh.Counts {
+		if c > 0 {
+			lowers = append(lowers, k)
+		}
+	}
+
+	sort.Slice(lowers, func(i, j int) bool { return lowers[i] < lowers[j] })
+
+	return lowers
+}
+
+// Percentile returns an estimate of the data point at the given
+// percentile, see Histogram.Percentile().
+func (sh *SparseHistogram) Percentile(p float64) uint64 {
+	sh.m.Lock()
+	defer sh.m.Unlock()
+
+	if sh.TotCount == 0 {
+		return 0
+	}
+
+	if p <= 0 {
+		return sh.MinDataPoint
+	}
+	if p >= 100 {
+		return sh.MaxDataPoint
+	}
+
+	rank := p / 100 * float64(sh.TotCount)
+
+	var runCount uint64
+	for _, k := range sh.sortedBucketsUNLOCKED() {
+		c := sh.Counts[k]
+		if float64(runCount+c) < rank {
+			runCount += c
+			continue
+		}
+
+		lower, upper := sh.bucket(k)
+		if lower < sh.MinDataPoint {
+			lower = sh.MinDataPoint
+		}
+		if upper > sh.MaxDataPoint {
+			upper = sh.MaxDataPoint
+		}
+
+		if upper <= lower {
+			return lower
+		}
+
+		frac := (rank - float64(runCount)) / float64(c)
+
+		return lower + uint64(frac*float64(upper-lower))
+	}
+
+	return sh.MaxDataPoint
+}
+
+// EmitGraph emits an ascii graph of the non-empty buckets to the
+// optional out buffer, see Histogram.EmitGraph().
+func (sh *SparseHistogram) EmitGraph(prefix []byte,
+	out *bytes.Buffer) *bytes.Buffer {
+	sh.m.Lock()
+	defer sh.m.Unlock()
+
+	lowers := sh.sortedBucketsUNLOCKED()
+
+	if out == nil {
+		out = bytes.NewBuffer(make([]byte, 0, 80*(len(lowers)+1)))
+	}
+
+	bins := make([]string, len(lowers))
+	counts := make([]uint64, len(lowers))
+	for i, k := range lowers {
+		lower, upper := sh.bucket(k)
+		bins[i] = fmt.Sprintf("%v - %v", lower, upper)
+		counts[i] = sh.Counts[k]
+	}
+
+	fmt.Fprintf(out, "%s (%v Total)\n", sh.Name, sh.TotCount)
+
+	emitBins(prefix, out, bins, counts, sh.TotCount)
+
+	return out
+}
@@ -0,0 +1,68 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSparseHistogram(t *testing.T) {
+	sh := NewSparseHistogram("TestSparseHistogram", 1)
+
+	sh.Add(5, 1)
+	sh.Add(1000, 2)
+	sh.Add(1<<40, 3)
+	sh.Add(math.MaxUint64, 4)
+
+	if len(sh.Counts) != 4 || sh.TotCount != 10 {
+		t.Errorf("unexpected counts: %v", sh.Counts)
+	}
+
+	exp := `TestSparseHistogram (10 Total)
+[5 - 6]                                         10.00%   10.00% ####### (1)
+[992 - 1024]                                    20.00%   30.00% ############### (2)
+[1099511627776 - 1168231104512]                 30.00%   60.00% ###################### (3)
+[17870283321406128128 - 18446744073709551615]   40.00%  100.00% ############################## (4)
+`
+	got := sh.EmitGraph(nil, nil).String()
+	if got != exp {
+		t.Errorf("didn't get expected graph,\ngot: %s\nexp: %s", got, exp)
+	}
+
+	// Every bucket is at most 10% wide.
+	for k := range sh.Counts {
+		lower, upper := sh.bucket(k)
+		if k > 32 && (upper-lower)/10 > lower/100 {
+			t.Errorf("bucket too wide: [%d - %d)", lower, upper)
+		}
+	}
+
+	if p := sh.Percentile(0); p != 5 {
+		t.Errorf("Percentile(0) wrong, got: %d", p)
+	}
+	if p := sh.Percentile(25); p < 992 || p >= 1024 {
+		t.Errorf("Percentile(25) wrong, got: %d", p)
+	}
+	if p := sh.Percentile(100); p != math.MaxUint64 {
+		t.Errorf("Percentile(100) wrong, got: %d", p)
+	}
+
+	sh2 := NewSparseHistogram("sh2", 1)
+	sh2.AddAll(sh)
+	sh2.AddAll(sh)
+	if sh2.TotCount != 20 || sh2.Counts[992] != 4 || sh2.MinDataPoint != 5 {
+		t.Errorf("AddAll wrong: %v", sh2.Counts)
+	}
+
+	sh2.Reset()
+	if sh2.TotCount != 0 || len(sh2.Counts) != 0 {
+		t.Errorf("Reset wrong")
+	}
+}
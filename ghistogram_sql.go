@@ -0,0 +1,246 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"time"
+)
+
+// The operations timed by WrapDriver(), which are the keys of its
+// Histograms map.
+const (
+	SQLPrepare  = "prepare"
+	SQLExec     = "exec"
+	SQLQuery    = "query"
+	SQLBegin    = "begin"
+	SQLCommit   = "commit"
+	SQLRollback = "rollback"
+)
+
+var sqlOps = []string{
+	SQLPrepare, SQLExec, SQLQuery, SQLBegin, SQLCommit, SQLRollback,
+}
+
+// WrapDriver returns a database/sql driver that records the latencies
+// of the prepares, execs, queries and transactions of the wrapped
+// driver into a histogram per operation, which are empty clones of
+// the proto histogram keyed by the SQL operation constants, for
+// example:
+//
+//    d, dbStats := ghistogram.WrapDriver(&pq.Driver{},
+//        ghistogram.NewNamedHistogram("sql", 20, 10, 2.0).WithUnit("µs"))
+//    sql.Register("postgres-ghistogram", d)
+//    db, err := sql.Open("postgres-ghistogram", dsn)
+//
+// The latency of a query is the time until its rows are returned, not
+// until they're read.  The latencies are recorded in the time unit of
+// the proto histogram, see WithUnit(), or in microseconds by default.
+// Failed operations are recorded as well.
+func WrapDriver(d driver.Driver,
+	proto *Histogram) (driver.Driver, Histograms) {
+	proto.m.Lock()
+	hmap := make(Histograms, len(sqlOps))
+	for _, op := range sqlOps {
+		hmap[op] = proto.CloneEmpty()
+		hmap[op].Name = proto.Name + "{op=" + op + "}"
+	}
+	unit := proto.durationUnit()
+	proto.m.Unlock()
+
+	return &sqlDriver{d: d, s: &sqlStats{hmap: hmap, unit: unit}}, hmap
+}
+
+type sqlStats struct {
+	hmap Histograms // Read-only, the histograms are concurrent safe.
+	unit time.Duration
+}
+
+func (s *sqlStats) record(op string, start time.Time) {
+	s.hmap[op].Add(uint64(time.Since(start)/s.unit), 1)
+}
+
+type sqlDriver struct {
+	d driver.Driver
+	s *sqlStats
+}
+
+func (d *sqlDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.d.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlConn{c: c, s: d.s}, nil
+}
+
+// sqlConn wraps a driver.Conn, timing its operations.  The optional
+// interfaces the wrapped conn lacks fall back to the database/sql
+// defaults through driver.ErrSkip or the required methods.
+type sqlConn struct {
+	c driver.Conn
+	s *sqlStats
+}
+
+func (c *sqlConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *sqlConn) PrepareContext(ctx context.Context,
+	query string) (stmt driver.Stmt, err error) {
+	defer c.s.record(SQLPrepare, time.Now())
+
+	if cp, ok := c.c.(driver.ConnPrepareContext); ok {
+		stmt, err = cp.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.c.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sqlStmt{st: stmt, s: c.s}, nil
+}
+
+func (c *sqlConn) Close() error {
+	return c.c.Close()
+}
+
+func (c *sqlConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *sqlConn) BeginTx(ctx context.Context,
+	opts driver.TxOptions) (tx driver.Tx, err error) {
+	defer c.s.record(SQLBegin, time.Now())
+
+	if cb, ok := c.c.(driver.ConnBeginTx); ok {
+		tx, err = cb.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.c.Begin()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sqlTx{tx: tx, s: c.s}, nil
+}
+
+func (c *sqlConn) ExecContext(ctx context.Context, query string,
+	args []driver.NamedValue) (driver.Result, error) {
+	ec, ok := c.c.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip // Falls back to a prepared stmt.
+	}
+
+	defer c.s.record(SQLExec, time.Now())
+	return ec.ExecContext(ctx, query, args)
+}
+
+func (c *sqlConn) QueryContext(ctx context.Context, query string,
+	args []driver.NamedValue) (driver.Rows, error) {
+	qc, ok := c.c.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip // Falls back to a prepared stmt.
+	}
+
+	defer c.s.record(SQLQuery, time.Now())
+	return qc.QueryContext(ctx, query, args)
+}
+
+func (c *sqlConn) Ping(ctx context.Context) error {
+	if p, ok := c.c.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *sqlConn) ResetSession(ctx context.Context) error {
+	if sr, ok := c.c.(driver.SessionResetter); ok {
+		return sr.ResetSession(ctx)
+	}
+	return nil
+}
+
+type sqlStmt struct {
+	st driver.Stmt
+	s  *sqlStats
+}
+
+func (st *sqlStmt) Close() error {
+	return st.st.Close()
+}
+
+func (st *sqlStmt) NumInput() int {
+	return st.st.NumInput()
+}
+
+func (st *sqlStmt) Exec(args []driver.Value) (driver.Result, error) {
+	defer st.s.record(SQLExec, time.Now())
+	return st.st.Exec(args)
+}
+
+func (st *sqlStmt) Query(args []driver.Value) (driver.Rows, error) {
+	defer st.s.record(SQLQuery, time.Now())
+	return st.st.Query(args)
+}
+
+func (st *sqlStmt) ExecContext(ctx context.Context,
+	args []driver.NamedValue) (driver.Result, error) {
+	if sc, ok := st.st.(driver.StmtExecContext); ok {
+		defer st.s.record(SQLExec, time.Now())
+		return sc.ExecContext(ctx, args)
+	}
+
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return st.Exec(values)
+}
+
+func (st *sqlStmt) QueryContext(ctx context.Context,
+	args []driver.NamedValue) (driver.Rows, error) {
+	if sc, ok := st.st.(driver.StmtQueryContext); ok {
+		defer st.s.record(SQLQuery, time.Now())
+		return sc.QueryContext(ctx, args)
+	}
+
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return st.Query(values)
+}
+
+func namedValuesToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	rv := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("ghistogram: WrapDriver," +
+				" the driver does not support named parameters")
+		}
+		rv[i] = arg.Value
+	}
+	return rv, nil
+}
+
+type sqlTx struct {
+	tx driver.Tx
+	s  *sqlStats
+}
+
+func (tx *sqlTx) Commit() error {
+	defer tx.s.record(SQLCommit, time.Now())
+	return tx.tx.Commit()
+}
+
+func (tx *sqlTx) Rollback() error {
+	defer tx.s.record(SQLRollback, time.Now())
+	return tx.tx.Rollback()
+}
@@ -0,0 +1,135 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+)
+
+// fakeDriver is a database/sql driver whose conns, stmts and txs do
+// nothing, with the context interfaces of the conn optional.
+type fakeDriver struct{ withContext bool }
+
+func (d fakeDriver) Open(name string) (driver.Conn, error) {
+	if d.withContext {
+		return fakeConnContext{}, nil
+	}
+	return fakeConn{}, nil
+}
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+type fakeConnContext struct{ fakeConn }
+
+func (fakeConnContext) ExecContext(ctx context.Context, query string,
+	args []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func (fakeConnContext) QueryContext(ctx context.Context, query string,
+	args []driver.NamedValue) (driver.Rows, error) {
+	return fakeRows{}, nil
+}
+
+type fakeStmt struct{}
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+
+func (fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func (fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return fakeRows{}, nil
+}
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string              { return []string{"x"} }
+func (fakeRows) Close() error                   { return nil }
+func (fakeRows) Next(dest []driver.Value) error { return io.EOF }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+func TestWrapDriver(t *testing.T) {
+	for i, withContext := range []bool{false, true} {
+		d, hmap := WrapDriver(fakeDriver{withContext},
+			NewNamedHistogram("sql", 10, 10, 2.0))
+
+		name := []string{"ghistogram-fake", "ghistogram-fake-ctx"}[i]
+		sql.Register(name, d)
+
+		db, err := sql.Open(name, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = db.Exec("UPDATE t SET x = ?", 1); err != nil {
+			t.Fatal(err)
+		}
+
+		rows, err := db.Query("SELECT x FROM t")
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows.Close()
+
+		stmt, err := db.Prepare("SELECT x FROM t WHERE x = ?")
+		if err != nil {
+			t.Fatal(err)
+		}
+		stmt.Exec(1)
+		stmt.Close()
+
+		tx, _ := db.Begin()
+		tx.Commit()
+		tx, _ = db.Begin()
+		tx.Rollback()
+
+		db.Close()
+
+		// Without the context interfaces, database/sql prepares the
+		// exec and query statements.
+		expPrepares := uint64(3)
+		if withContext {
+			expPrepares = 1
+		}
+
+		exp := map[string]uint64{
+			SQLPrepare:  expPrepares,
+			SQLExec:     2,
+			SQLQuery:    1,
+			SQLBegin:    2,
+			SQLCommit:   1,
+			SQLRollback: 1,
+		}
+		if len(hmap) != len(exp) {
+			t.Errorf("expected %d histograms, got: %v", len(exp), hmap)
+		}
+		for op, count := range exp {
+			if gh := hmap[op]; gh == nil || gh.TotCount != count ||
+				gh.Name != "sql{op="+op+"}" {
+				t.Errorf("withContext: %t, expected %s count %d, got: %v",
+					withContext, op, count, gh)
+			}
+		}
+	}
+}
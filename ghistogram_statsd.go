@@ -0,0 +1,106 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// maxStatsdPacket is the largest payload written to the statsd
+// connection at once, which keeps UDP datagrams below common MTUs.
+const maxStatsdPacket = 1432
+
+// summaryPercentiles are the percentiles exported as flat stats.
+var summaryPercentiles = []struct {
+	name string
+	p    float64
+}{
+	{"p50", 50},
+	{"p90", 90},
+	{"p99", 99},
+	{"p999", 99.9},
+}
+
+// FlushStatsd sends the histogram to a statsd or DogStatsD server as
+// gauges, one "<prefix>.bin_<start>" gauge for the count of every bin,
+// plus "<prefix>.count", "<prefix>.min", "<prefix>.max" and the
+// percentile gauges "<prefix>.p50", "<prefix>.p90", "<prefix>.p99"
+// and "<prefix>.p999", or the bare names for an empty prefix.  The
+// lines are batched into writes that fit a UDP datagram.
+func (gh *Histogram) FlushStatsd(w io.Writer, prefix string) error {
+	prefix = metricName(prefix)
+
+	buf := bytes.NewBuffer(make([]byte, 0, maxStatsdPacket))
+
+	var lines []string
+
+	gh.m.Lock()
+	for i, c := range gh.Counts {
+		lines = append(lines, fmt.Sprintf("%s:%d|g\n", statKey(prefix,
+			fmt.Sprintf("bin_%d", gh.rangeLabel(gh.Ranges[i]))), c))
+	}
+	lines = append(lines, fmt.Sprintf("%s:%d|g\n",
+		statKey(prefix, "count"), gh.TotCount))
+	if gh.TotCount > 0 {
+		lines = append(lines,
+			fmt.Sprintf("%s:%d|g\n", statKey(prefix, "min"), gh.MinDataPoint),
+			fmt.Sprintf("%s:%d|g\n", statKey(prefix, "max"), gh.MaxDataPoint))
+	}
+	for _, sp := range summaryPercentiles {
+		lines = append(lines, fmt.Sprintf("%s:%d|g\n",
+			statKey(prefix, sp.name), gh.percentileUNLOCKED(sp.p)))
+	}
+	gh.m.Unlock()
+
+	for _, line := range lines {
+		if buf.Len() > 0 && buf.Len()+len(line) > maxStatsdPacket {
+			if _, err := w.Write(buf.Bytes()); err != nil {
+				return err
+			}
+			buf.Reset()
+		}
+		buf.WriteString(line)
+	}
+
+	if buf.Len() > 0 {
+		_, err := w.Write(buf.Bytes())
+		return err
+	}
+
+	return nil
+}
+
+// FlushStatsd sends all the histograms of the map to a statsd server,
+// using "<prefix>.<name>" as the prefix of each histogram's gauges,
+// see Histogram.FlushStatsd().
+func (hmap Histograms) FlushStatsd(w io.Writer, prefix string) error {
+	for _, k := range hmap.SortedNames(nil) {
+		if err := hmap[k].FlushStatsd(w, statKey(prefix, k)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// metricName replaces the characters that have a special meaning in
+// the statsd or graphite line protocols, or are unsafe in metric
+// names, with '_'.
+func metricName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+			c >= '0' && c <= '9' || c == '.' || c == '_' || c == '-') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,88 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"testing"
+)
+
+// packetWriter records each write as a separate packet.
+type packetWriter struct {
+	packets []string
+}
+
+func (pw *packetWriter) Write(b []byte) (int, error) {
+	pw.packets = append(pw.packets, string(b))
+	return len(b), nil
+}
+
+func TestFlushStatsd(t *testing.T) {
+	histograms, _, _ := initAndFetchHistograms(t)
+
+	var buf bytes.Buffer
+	if err := histograms["test2"].FlushStatsd(&buf, "kv.test2 (µs)"); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	exp := `kv.test2____s_.bin_0:0|g
+kv.test2____s_.bin_2:1|g
+kv.test2____s_.bin_4:3|g
+kv.test2____s_.bin_8:0|g
+kv.test2____s_.bin_16:0|g
+kv.test2____s_.bin_32:0|g
+kv.test2____s_.bin_64:0|g
+kv.test2____s_.bin_128:0|g
+kv.test2____s_.bin_256:0|g
+kv.test2____s_.bin_512:0|g
+kv.test2____s_.count:4|g
+kv.test2____s_.min:2|g
+kv.test2____s_.max:4|g
+kv.test2____s_.p50:4|g
+kv.test2____s_.p90:4|g
+kv.test2____s_.p99:4|g
+kv.test2____s_.p999:4|g
+`
+	if buf.String() != exp {
+		t.Errorf("unexpected statsd output, got: %s", buf.String())
+	}
+
+	// Many bins are split across multiple packets.
+	pw := &packetWriter{}
+	gh := NewHistogram(200, 10, 0.0)
+	if err := gh.FlushStatsd(pw, "big"); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(pw.packets) < 2 {
+		t.Errorf("expected multiple packets, got: %d", len(pw.packets))
+	}
+	for _, p := range pw.packets {
+		if len(p) > maxStatsdPacket {
+			t.Errorf("packet too large: %d", len(p))
+		}
+	}
+
+	pw = &packetWriter{}
+	if err := histograms.FlushStatsd(pw, "kv"); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(pw.packets) != 2 {
+		t.Errorf("expected a packet per histogram, got: %d", len(pw.packets))
+	}
+
+	buf.Reset()
+	if err := histograms["test2"].FlushStatsd(&buf, ""); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("bin_0:0|g\n")) ||
+		!bytes.Contains(buf.Bytes(), []byte("\ncount:4|g\n")) {
+		t.Errorf("expected bare names for an empty prefix, got: %s",
+			buf.String())
+	}
+}
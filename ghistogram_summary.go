@@ -0,0 +1,110 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"time"
+)
+
+// Summary holds the commonly polled statistics of a histogram.  The
+// Min and Max are 0 while the histogram is empty.  The FirstSample
+// and LastSample are zero unless they are tracked, see SampleTimes().
+type Summary struct {
+	Count uint64
+	Min   uint64
+	Max   uint64
+	P50   uint64
+	P90   uint64
+	P99   uint64
+	P999  uint64
+
+	FirstSample time.Time
+	LastSample  time.Time
+}
+
+// summaryCache is an immutable, atomically swapped cached Summary,
+// which also holds the histogram's clock, so the cache can be checked
+// without the lock.
+type summaryCache struct {
+	maxStaleness time.Duration
+	clock        Clock
+	valid        bool
+	at           time.Time
+	summary      Summary
+}
+
+// SetSummaryMaxStaleness allows Summary() to return a cached result
+// that is up to maxStaleness old, so dashboards polling many
+// histograms at a high frequency don't pay for the lock and the walk
+// over the bins on every poll.  A maxStaleness <= 0, the default,
+// disables the cache.
+func (gh *Histogram) SetSummaryMaxStaleness(maxStaleness time.Duration) {
+	gh.m.Lock()
+	gh.summary.Store(&summaryCache{
+		maxStaleness: maxStaleness,
+		clock:        gh.clock,
+	})
+	gh.m.Unlock()
+}
+
+// Summary returns the count, min, max and summary percentiles of the
+// histogram, possibly from the cache, see SetSummaryMaxStaleness().
+func (gh *Histogram) Summary() Summary {
+	c, _ := gh.summary.Load().(*summaryCache)
+	if c != nil && c.valid &&
+		clockNow(c.clock).Sub(c.at) < c.maxStaleness {
+		return c.summary
+	}
+
+	gh.m.Lock()
+	s := gh.summaryUNLOCKED()
+	if c, _ = gh.summary.Load().(*summaryCache); c != nil &&
+		c.maxStaleness > 0 {
+		gh.summary.Store(&summaryCache{
+			maxStaleness: c.maxStaleness,
+			clock:        gh.clock,
+			valid:        true,
+			at:           gh.now(),
+			summary:      s,
+		})
+	}
+	gh.m.Unlock()
+
+	return s
+}
+
+func (gh *Histogram) summaryUNLOCKED() Summary {
+	s := Summary{
+		Count: gh.TotCount,
+		P50:   gh.percentileUNLOCKED(50),
+		P90:   gh.percentileUNLOCKED(90),
+		P99:   gh.percentileUNLOCKED(99),
+		P999:  gh.percentileUNLOCKED(99.9),
+	}
+	if gh.TotCount > 0 {
+		s.Min = gh.MinDataPoint
+		s.Max = gh.MaxDataPoint
+	}
+	if gh.firstSample != 0 {
+		s.FirstSample = time.Unix(0, gh.firstSample)
+		s.LastSample = time.Unix(0, gh.lastSample)
+	}
+	return s
+}
+
+// invalidateSummaryUNLOCKED drops any cached Summary, for changes that
+// must be visible immediately, like a reset.
+func (gh *Histogram) invalidateSummaryUNLOCKED() {
+	if c, _ := gh.summary.Load().(*summaryCache); c != nil && c.valid {
+		gh.summary.Store(&summaryCache{
+			maxStaleness: c.maxStaleness,
+			clock:        gh.clock,
+		})
+	}
+}
@@ -0,0 +1,125 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummary(t *testing.T) {
+	gh := NewHistogram(10, 10, 0.0)
+
+	if s := gh.Summary(); s != (Summary{}) {
+		t.Errorf("expected empty summary, got: %+v", s)
+	}
+
+	for i := uint64(1); i <= 100; i++ {
+		gh.Add(i, 1)
+	}
+
+	s := gh.Summary()
+	if s.Count != 100 || s.Min != 1 || s.Max != 100 ||
+		s.P50 != gh.Percentile(50) || s.P999 != gh.Percentile(99.9) {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+
+	gh.Add(200, 1)
+	if gh.Summary().Count != 101 {
+		t.Errorf("expected no caching by default")
+	}
+}
+
+func TestSummaryCache(t *testing.T) {
+	gh := NewHistogram(10, 10, 0.0)
+	gh.SetSummaryMaxStaleness(time.Hour)
+
+	gh.Add(5, 1)
+	if gh.Summary().Count != 1 {
+		t.Errorf("expected first summary to be computed")
+	}
+
+	gh.Add(5, 1)
+	if gh.Summary().Count != 1 {
+		t.Errorf("expected cached summary")
+	}
+
+	gh.Reset()
+	gh.Add(5, 3)
+	if gh.Summary().Count != 3 {
+		t.Errorf("expected reset to invalidate the cache")
+	}
+
+	if gh.CloneEmpty().summary.Load().(*summaryCache).maxStaleness !=
+		time.Hour {
+		t.Errorf("expected CloneEmpty to keep the max staleness")
+	}
+
+	gh.SetSummaryMaxStaleness(0)
+	gh.Add(5, 1)
+	if gh.Summary().Count != 4 {
+		t.Errorf("expected cache to be disabled")
+	}
+}
+
+func TestSummarySampleTimes(t *testing.T) {
+	clock := NewManualClock(time.Unix(1000, 0))
+
+	gh := NewHistogram(10, 10, 0.0)
+	gh.SetClock(clock)
+	gh.SetTrackSampleTimes(true)
+
+	gh.Add(5, 1)
+	clock.Advance(time.Minute)
+	gh.Add(5, 1)
+
+	first, last := gh.SampleTimes()
+	s := gh.Summary()
+	if !s.FirstSample.Equal(first) || !s.LastSample.Equal(last) ||
+		!s.LastSample.Equal(clock.Now()) {
+		t.Errorf("unexpected sample times: %+v", s)
+	}
+}
+
+// TestSummaryClockRace is meant for go test -race, where cached
+// summaries must not race with SetClock().
+func TestSummaryClockRace(t *testing.T) {
+	gh := NewHistogram(10, 10, 0.0)
+	gh.SetSummaryMaxStaleness(time.Hour)
+	gh.Add(5, 1)
+
+	clock := NewManualClock(time.Unix(1000, 0))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			gh.Summary()
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		gh.SetClock(clock)
+		gh.SetClock(nil)
+	}
+
+	<-done
+
+	// The cache is of the histogram's current clock.
+	gh.SetClock(clock)
+	gh.Summary()
+	gh.Add(5, 1)
+	if gh.Summary().Count != 1 {
+		t.Errorf("expected a cached summary")
+	}
+	clock.Advance(time.Hour)
+	if gh.Summary().Count != 2 {
+		t.Errorf("expected the cache to expire on the histogram's clock")
+	}
+}
@@ -0,0 +1,293 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"container/list"
+	"io"
+	"sync"
+)
+
+// SyncHistograms is a concurrent safe map of histograms identified
+// by unique names.  Unlike the plain Histograms map, entries may be
+// inserted, removed and iterated from multiple goroutines.
+type SyncHistograms struct {
+	m    sync.RWMutex
+	hmap Histograms
+
+	// See SetMaxLen().
+	maxLen  int
+	onEvict func(name string, gh *Histogram)
+
+	lruM sync.Mutex               // Guards lru and used, taken after m.
+	lru  *list.List               // Of *lruEntry, most recently used first.
+	used map[string]*list.Element // Keyed by name, nil without a cap.
+}
+
+// lruEntry is the use of a histogram, see SetMaxLen().
+type lruEntry struct {
+	name  string
+	total uint64 // The Total() of the histogram when last used.
+}
+
+type evicted struct {
+	name string
+	gh   *Histogram
+}
+
+// NewSyncHistograms creates a new, empty SyncHistograms.
+func NewSyncHistograms() *SyncHistograms {
+	return &SyncHistograms{hmap: make(Histograms)}
+}
+
+// Get returns the histogram registered under name, or nil.
+func (s *SyncHistograms) Get(name string) *Histogram {
+	s.m.RLock()
+	gh := s.hmap[name]
+	if gh != nil {
+		s.touch(name, gh)
+	}
+	s.m.RUnlock()
+	return gh
+}
+
+// touch marks the histogram as the most recently used, while s.m is
+// held.
+func (s *SyncHistograms) touch(name string, gh *Histogram) {
+	s.lruM.Lock()
+	if e := s.used[name]; e != nil {
+		e.Value.(*lruEntry).total = gh.Total()
+		s.lru.MoveToFront(e)
+	}
+	s.lruM.Unlock()
+}
+
+// GetOrCreate returns the histogram registered under name, invoking
+// ctor to create and register it if it doesn't exist yet.  The ctor
+// is invoked at most once per name.
+func (s *SyncHistograms) GetOrCreate(name string,
+	ctor func() *Histogram) *Histogram {
+	gh := s.Get(name)
+	if gh != nil {
+		return gh
+	}
+
+	var evicts []evicted
+
+	s.m.Lock()
+	gh = s.hmap[name]
+	if gh == nil {
+		gh = ctor()
+		s.hmap[name] = gh
+		evicts = s.evictUNLOCKED(name)
+	} else {
+		s.touch(name, gh)
+	}
+	s.m.Unlock()
+
+	s.notifyEvicted(evicts)
+
+	return gh
+}
+
+// SetMaxLen caps the number of registered histograms.  When a new
+// histogram would exceed the cap, the least recently used histograms
+// are removed and passed to the optional onEvict callback.  This
+// protects against unbounded growth from, for example, per-client
+// histograms.  A maxLen <= 0 removes the cap.
+//
+// A histogram is used by a Get() or GetOrCreate() of its name, or by
+// data points added to it, like through a retained pointer, which are
+// noticed when it is next up for eviction, so it gets a second chance
+// as the most recently used.
+func (s *SyncHistograms) SetMaxLen(maxLen int,
+	onEvict func(name string, gh *Histogram)) {
+	var evicts []evicted
+
+	s.m.Lock()
+	s.maxLen = maxLen
+	s.onEvict = onEvict
+	s.lruM.Lock()
+	if maxLen > 0 {
+		if s.used == nil {
+			s.lru = list.New()
+			s.used = make(map[string]*list.Element, len(s.hmap))
+			for name, gh := range s.hmap {
+				s.used[name] = s.lru.PushFront(
+					&lruEntry{name: name, total: gh.Total()})
+			}
+		}
+	} else {
+		s.lru, s.used = nil, nil
+	}
+	s.lruM.Unlock()
+	evicts = s.evictUNLOCKED("")
+	s.m.Unlock()
+
+	s.notifyEvicted(evicts)
+}
+
+// evictUNLOCKED starts tracking the use of a newly inserted name, if
+// not "", and then removes least recently used histograms until the
+// cap is met.  The removed histograms are returned.
+func (s *SyncHistograms) evictUNLOCKED(inserted string) (rv []evicted) {
+	if s.maxLen <= 0 {
+		return nil
+	}
+
+	s.lruM.Lock()
+	defer s.lruM.Unlock()
+
+	if inserted != "" {
+		s.used[inserted] = s.lru.PushFront(&lruEntry{
+			name: inserted, total: s.hmap[inserted].Total()})
+	}
+
+	// Each histogram gets at most one second chance per eviction.
+	secondChances := s.lru.Len()
+
+	for len(s.hmap) > s.maxLen {
+		e := s.lru.Back()
+		if e == nil {
+			break
+		}
+
+		u := e.Value.(*lruEntry)
+		if u.name == inserted {
+			break
+		}
+
+		gh := s.hmap[u.name]
+		if total := gh.Total(); total != u.total && secondChances > 0 {
+			secondChances--
+			u.total = total
+			s.lru.MoveToFront(e)
+			continue
+		}
+
+		rv = append(rv, evicted{u.name, gh})
+		delete(s.hmap, u.name)
+		delete(s.used, u.name)
+		s.lru.Remove(e)
+	}
+
+	return rv
+}
+
+// notifyEvicted invokes the eviction callback, outside of the lock.
+func (s *SyncHistograms) notifyEvicted(evicts []evicted) {
+	if len(evicts) == 0 {
+		return
+	}
+
+	s.m.RLock()
+	onEvict := s.onEvict
+	s.m.RUnlock()
+
+	if onEvict != nil {
+		for _, e := range evicts {
+			onEvict(e.name, e.gh)
+		}
+	}
+}
+
+// Remove unregisters the histogram with the given name, if any.
+func (s *SyncHistograms) Remove(name string) {
+	s.m.Lock()
+	delete(s.hmap, name)
+	s.lruM.Lock()
+	if e := s.used[name]; e != nil {
+		s.lru.Remove(e)
+		delete(s.used, name)
+	}
+	s.lruM.Unlock()
+	s.m.Unlock()
+}
+
+// Len returns the number of registered histograms.
+func (s *SyncHistograms) Len() int {
+	s.m.RLock()
+	n := len(s.hmap)
+	s.m.RUnlock()
+	return n
+}
+
+// ResetAll resets every registered histogram.
+func (s *SyncHistograms) ResetAll() {
+	s.ResetAllWithReason("")
+}
+
+// ResetAllWithReason resets every registered histogram, recording the
+// reason for the reset, see Histogram.ResetWithReason().
+func (s *SyncHistograms) ResetAllWithReason(reason string) {
+	s.Snapshot().ResetAllWithReason(reason)
+}
+
+// Range invokes f for every registered histogram until f returns
+// false.  The set of histograms visited is captured before the
+// first callback, so f may safely call other SyncHistograms methods.
+func (s *SyncHistograms) Range(f func(name string, gh *Histogram) bool) {
+	hmap := s.Snapshot()
+	for name, gh := range hmap {
+		if !f(name, gh) {
+			return
+		}
+	}
+}
+
+// Snapshot returns a plain Histograms map holding the currently
+// registered histograms.  The histograms themselves are shared, not
+// copied.
+func (s *SyncHistograms) Snapshot() Histograms {
+	s.m.RLock()
+	hmap := make(Histograms, len(s.hmap))
+	for name, gh := range s.hmap {
+		hmap[name] = gh
+	}
+	s.m.RUnlock()
+	return hmap
+}
+
+// AddAll adds all entries from the histograms of the source map, see
+// Histograms.AddAll().  Newly added histograms count as used, as do
+// existing ones through their added data points, and may evict others
+// when a cap is set, see SetMaxLen().
+func (s *SyncHistograms) AddAll(srcmap Histograms) error {
+	var evicts []evicted
+
+	s.m.Lock()
+	err := s.hmap.AddAll(srcmap)
+	if s.maxLen > 0 {
+		for name := range srcmap {
+			s.lruM.Lock()
+			e := s.used[name]
+			s.lruM.Unlock()
+
+			if e == nil && s.hmap[name] != nil {
+				evicts = append(evicts, s.evictUNLOCKED(name)...)
+			}
+		}
+	}
+	s.m.Unlock()
+
+	s.notifyEvicted(evicts)
+
+	return err
+}
+
+// String returns the ASCII graphs of all registered histograms.
+func (s *SyncHistograms) String() string {
+	return s.Snapshot().String()
+}
+
+// Fprint emits the ASCII graphs of all registered histograms through
+// the provided writer.
+func (s *SyncHistograms) Fprint(w io.Writer) (int, error) {
+	return s.Snapshot().Fprint(w)
+}
@@ -0,0 +1,138 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSyncHistograms(t *testing.T) {
+	s := NewSyncHistograms()
+
+	ctor := func() *Histogram {
+		return NewNamedHistogram("test", 5, 10, 2.0)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			for j := 0; j < 1000; j++ {
+				s.GetOrCreate("test", ctor).Add(uint64(j), 1)
+			}
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+
+	if s.Len() != 1 {
+		t.Errorf("expected 1 histogram, got: %d", s.Len())
+	}
+	if s.Get("test").TotCount != 10000 {
+		t.Errorf("TotCount wrong, got: %d", s.Get("test").TotCount)
+	}
+
+	s.ResetAllWithReason("test")
+	if s.Get("test").TotCount != 0 {
+		t.Errorf("expected TotCount 0 after ResetAllWithReason")
+	}
+
+	s.Get("test").Add(5, 1)
+	s.ResetAll()
+	if s.Get("test").TotCount != 0 {
+		t.Errorf("expected TotCount 0 after ResetAll")
+	}
+
+	s.GetOrCreate("other", ctor)
+
+	var visited int
+	s.Range(func(name string, gh *Histogram) bool {
+		visited++
+		s.Remove(name)
+		return true
+	})
+	if visited != 2 || s.Len() != 0 {
+		t.Errorf("Range/Remove wrong, visited: %d, len: %d",
+			visited, s.Len())
+	}
+}
+
+func TestSyncHistogramsMaxLen(t *testing.T) {
+	s := NewSyncHistograms()
+
+	ctor := func() *Histogram {
+		return NewHistogram(5, 10, 2.0)
+	}
+
+	var evictedNames []string
+	s.SetMaxLen(2, func(name string, gh *Histogram) {
+		evictedNames = append(evictedNames, name)
+	})
+
+	s.GetOrCreate("a", ctor)
+	s.GetOrCreate("b", ctor)
+	s.GetOrCreate("c", ctor)
+	if s.Len() != 2 || s.Get("a") != nil {
+		t.Errorf("expected a to be evicted, got: %v", evictedNames)
+	}
+
+	s.Get("b") // Now c is the least recently used.
+	s.GetOrCreate("d", ctor)
+	if s.Len() != 2 || s.Get("c") != nil || s.Get("b") == nil {
+		t.Errorf("expected c to be evicted, got: %v", evictedNames)
+	}
+
+	s.AddAll(Histograms{"e": ctor()})
+	if s.Len() != 2 || s.Get("e") == nil {
+		t.Errorf("expected e to be added, got: %v", evictedNames)
+	}
+	if len(evictedNames) != 3 || evictedNames[0] != "a" ||
+		evictedNames[1] != "c" {
+		t.Errorf("unexpected evictions: %v", evictedNames)
+	}
+
+	s.SetMaxLen(0, nil)
+	s.GetOrCreate("f", ctor)
+	if s.Len() != 3 {
+		t.Errorf("expected no cap, got len: %d", s.Len())
+	}
+}
+
+func TestSyncHistogramsMaxLenRetained(t *testing.T) {
+	s := NewSyncHistograms()
+
+	ctor := func() *Histogram {
+		return NewHistogram(5, 10, 2.0)
+	}
+
+	var evictedNames []string
+	s.SetMaxLen(2, func(name string, gh *Histogram) {
+		evictedNames = append(evictedNames, name)
+	})
+
+	a := s.GetOrCreate("a", ctor)
+	s.GetOrCreate("b", ctor)
+
+	a.Add(10, 1) // Used through the retained pointer, not by name.
+
+	s.GetOrCreate("c", ctor)
+	if s.Len() != 2 || s.Get("a") == nil || s.Get("b") != nil {
+		t.Errorf("expected b to be evicted, got: %v", evictedNames)
+	}
+
+	s.GetOrCreate("d", ctor) // Nothing added to a or c since.
+	s.GetOrCreate("e", ctor)
+	if s.Len() != 2 || s.Get("d") == nil || s.Get("e") == nil {
+		t.Errorf("expected d and e, got evictions: %v", evictedNames)
+	}
+	if len(evictedNames) != 3 || evictedNames[0] != "b" {
+		t.Errorf("unexpected evictions: %v", evictedNames)
+	}
+}
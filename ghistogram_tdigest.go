@@ -0,0 +1,301 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// TDigestHistogram is an alternative to Histogram that keeps a
+// t-digest sketch (Dunning's merging digest) instead of bins, for
+// accurate extreme percentiles, like p99.9 or p99.99, without having to
+// choose a bin layout up front.  The sketch's centroids are small near
+// the extremes and large near the median, so its memory stays bounded
+// by the compression parameter.
+//
+// The TDigestHistogram implements the HistogramMutator and
+// HistogramReader interfaces, and is concurrent safe.
+type TDigestHistogram struct {
+	// Histogram name.
+	Name string
+
+	m sync.Mutex
+
+	compression float64
+
+	centroids []centroid // Merged, sorted by mean.
+	buffer    []centroid // Not yet merged, preallocated.
+	scratch   []centroid // Merge output, preallocated.
+
+	totCount     uint64
+	minDataPoint uint64
+	maxDataPoint uint64
+}
+
+type centroid struct {
+	mean  float64
+	count uint64
+}
+
+type centroidsByMean []centroid
+
+func (c centroidsByMean) Len() int           { return len(c) }
+func (c centroidsByMean) Less(i, j int) bool { return c[i].mean < c[j].mean }
+func (c centroidsByMean) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+var _ HistogramMutator = (*TDigestHistogram)(nil)
+var _ HistogramReader = (*TDigestHistogram)(nil)
+
+// NewTDigestHistogram creates a new, ready to use TDigestHistogram.
+// A larger compression keeps more centroids, for more accuracy; 100
+// is a common choice, keeping at most a few hundred centroids.  A
+// compression < 20 is treated as 20.
+func NewTDigestHistogram(name string, compression float64) *TDigestHistogram {
+	if !(compression >= 20) {
+		compression = 20
+	}
+
+	maxCentroids := int(math.Ceil(compression*math.Pi/2)) + 1
+	bufferSize := 5 * int(compression)
+
+	return &TDigestHistogram{
+		Name:         name,
+		compression:  compression,
+		centroids:    make([]centroid, 0, maxCentroids),
+		buffer:       make([]centroid, 0, bufferSize),
+		scratch:      make([]centroid, 0, maxCentroids+bufferSize),
+		minDataPoint: math.MaxUint64,
+	}
+}
+
+// Add adds the dataPoint with the given count to the sketch.
+func (td *TDigestHistogram) Add(dataPoint uint64, count uint64) {
+	if count == 0 {
+		return
+	}
+
+	td.m.Lock()
+
+	td.buffer = append(td.buffer, centroid{float64(dataPoint), count})
+
+	td.totCount += count
+	if td.minDataPoint > dataPoint {
+		td.minDataPoint = dataPoint
+	}
+	if td.maxDataPoint < dataPoint {
+		td.maxDataPoint = dataPoint
+	}
+
+	if len(td.buffer) == cap(td.buffer) {
+		td.mergeUNLOCKED()
+	}
+
+	td.m.Unlock()
+}
+
+// AddAll adds the sketch of the src TDigestHistogram to this one.
+func (td *TDigestHistogram) AddAll(src *TDigestHistogram) {
+	src.m.Lock()
+	src.mergeUNLOCKED()
+	centroids := append([]centroid(nil), src.centroids...)
+	totCount, minDataPoint, maxDataPoint :=
+		src.totCount, src.minDataPoint, src.maxDataPoint
+	src.m.Unlock()
+
+	td.m.Lock()
+	for _, c := range centroids {
+		td.buffer = append(td.buffer, c)
+		if len(td.buffer) == cap(td.buffer) {
+			td.mergeUNLOCKED()
+		}
+	}
+	td.totCount += totCount
+	if td.minDataPoint > minDataPoint {
+		td.minDataPoint = minDataPoint
+	}
+	if td.maxDataPoint < maxDataPoint {
+		td.maxDataPoint = maxDataPoint
+	}
+	td.m.Unlock()
+}
+
+// Reset clears the sketch.
+func (td *TDigestHistogram) Reset() {
+	td.m.Lock()
+	td.centroids = td.centroids[:0]
+	td.buffer = td.buffer[:0]
+	td.totCount = 0
+	td.minDataPoint = math.MaxUint64
+	td.maxDataPoint = 0
+	td.m.Unlock()
+}
+
+// mergeUNLOCKED merges the buffered centroids into the sorted
+// centroids, combining neighbours while the combined centroid stays
+// within the size bound of the k1 scale function at its quantile.
+func (td *TDigestHistogram) mergeUNLOCKED() {
+	if len(td.buffer) == 0 {
+		return
+	}
+
+	all := append(td.scratch[:0], td.centroids...)
+	all = append(all, td.buffer...)
+	sort.Sort(centroidsByMean(all))
+
+	total := float64(td.totCount)
+
+	merged := td.centroids[:0]
+
+	cur := all[0]
+	var countSoFar float64
+	qLimit := td.kInverse(td.k(0) + 1)
+
+	for _, c := range all[1:] {
+		q := (countSoFar + float64(cur.count+c.count)) / total
+		if q <= qLimit {
+			n := cur.count + c.count
+			cur.mean += (c.mean - cur.mean) * float64(c.count) / float64(n)
+			cur.count = n
+			continue
+		}
+
+		countSoFar += float64(cur.count)
+		merged = append(merged, cur)
+		qLimit = td.kInverse(td.k(countSoFar/total) + 1)
+		cur = c
+	}
+
+	td.centroids = append(merged, cur)
+	td.buffer = td.buffer[:0]
+	td.scratch = all[:0]
+}
+
+// k is the k1 scale function, mapping a quantile to a centroid index.
+func (td *TDigestHistogram) k(q float64) float64 {
+	return td.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+func (td *TDigestHistogram) kInverse(k float64) float64 {
+	if k >= td.compression/4 {
+		return 1
+	}
+	return (math.Sin(k*2*math.Pi/td.compression) + 1) / 2
+}
+
+// Total returns the total count of data points.
+func (td *TDigestHistogram) Total() uint64 {
+	td.m.Lock()
+	n := td.totCount
+	td.m.Unlock()
+	return n
+}
+
+// Percentile returns an estimate of the data point at the given
+// percentile, in the range of [0.0, 100.0], by interpolating between
+// the centroids.  The estimate is clamped to the smallest and largest
+// data points seen, and is 0 for an empty sketch.
+func (td *TDigestHistogram) Percentile(p float64) uint64 {
+	td.m.Lock()
+	defer td.m.Unlock()
+
+	if td.totCount == 0 {
+		return 0
+	}
+	if p <= 0 {
+		return td.minDataPoint
+	}
+	if p >= 100 {
+		return td.maxDataPoint
+	}
+
+	td.mergeUNLOCKED()
+
+	rank := p / 100 * float64(td.totCount)
+
+	// Centroid i is centered at the cumulative count before it plus
+	// half its count; interpolate between neighbouring centers, and
+	// with the min and max at the ends.
+	prevCenter, prevMean := 0.0, float64(td.minDataPoint)
+
+	var cum float64
+	for _, c := range td.centroids {
+		center := cum + float64(c.count)/2
+		if rank < center {
+			return td.clampUNLOCKED(interpolate(rank,
+				prevCenter, prevMean, center, c.mean))
+		}
+		prevCenter, prevMean = center, c.mean
+		cum += float64(c.count)
+	}
+
+	return td.clampUNLOCKED(interpolate(rank,
+		prevCenter, prevMean, cum, float64(td.maxDataPoint)))
+}
+
+func interpolate(x, x0, y0, x1, y1 float64) float64 {
+	if x1 <= x0 {
+		return y1
+	}
+	return y0 + (x-x0)*(y1-y0)/(x1-x0)
+}
+
+func (td *TDigestHistogram) clampUNLOCKED(v float64) uint64 {
+	rv := uint64(math.Round(v))
+	if rv < td.minDataPoint {
+		rv = td.minDataPoint
+	}
+	if rv > td.maxDataPoint {
+		rv = td.maxDataPoint
+	}
+	return rv
+}
+
+// BinCounts returns the counts of the sketch's centroids, which take
+// the place of bins, in ascending order of their means.
+func (td *TDigestHistogram) BinCounts() []uint64 {
+	td.m.Lock()
+	td.mergeUNLOCKED()
+	rv := make([]uint64, len(td.centroids))
+	for i, c := range td.centroids {
+		rv[i] = c.count
+	}
+	td.m.Unlock()
+	return rv
+}
+
+// EmitGraph emits an ascii graph of the centroids, labeled by their
+// means, to the optional out buffer, see Histogram.EmitGraph().
+func (td *TDigestHistogram) EmitGraph(prefix []byte,
+	out *bytes.Buffer) *bytes.Buffer {
+	td.m.Lock()
+	defer td.m.Unlock()
+
+	td.mergeUNLOCKED()
+
+	if out == nil {
+		out = bytes.NewBuffer(make([]byte, 0, 80*len(td.centroids)))
+	}
+
+	bins := make([]string, len(td.centroids))
+	counts := make([]uint64, len(td.centroids))
+	for i, c := range td.centroids {
+		bins[i] = fmt.Sprintf("~%d", uint64(math.Round(c.mean)))
+		counts[i] = c.count
+	}
+
+	fmt.Fprintf(out, "%s (%v Total)\n", td.Name, td.totCount)
+
+	emitBins(prefix, out, bins, counts, td.totCount)
+
+	return out
+}
@@ -0,0 +1,97 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestTDigestHistogram(t *testing.T) {
+	td := NewTDigestHistogram("td", 100)
+
+	if td.Percentile(50) != 0 || td.Total() != 0 {
+		t.Errorf("expected 0 for an empty sketch")
+	}
+	got := td.EmitGraph(nil, nil).String()
+	if got != "td (0 Total)\n(empty)\n" {
+		t.Errorf("unexpected empty graph: %s", got)
+	}
+
+	// A shuffled uniform sequence of 0 to 99999, so the exact value at
+	// percentile p is about p * 1000.
+	rng := rand.New(rand.NewSource(1))
+	for _, v := range rng.Perm(100000) {
+		td.Add(uint64(v), 1)
+	}
+
+	if td.Total() != 100000 {
+		t.Errorf("expected Total 100000, got: %d", td.Total())
+	}
+
+	tests := []struct {
+		p         float64
+		exp       uint64
+		tolerance uint64
+	}{
+		{0, 0, 0},
+		{1, 1000, 100},
+		{50, 50000, 500},
+		{99, 99000, 100},
+		{99.9, 99900, 20},
+		{99.99, 99990, 5},
+		{100, 99999, 0},
+	}
+
+	for testi, test := range tests {
+		got := td.Percentile(test.p)
+		if got+test.tolerance < test.exp || got > test.exp+test.tolerance {
+			t.Errorf("test #%d, p: %v, exp: %d +/- %d, got: %d",
+				testi, test.p, test.exp, test.tolerance, got)
+		}
+	}
+
+	counts := td.BinCounts()
+	if len(counts) > 200 || counts[0] > 200 ||
+		counts[len(counts)/2] < 1000 || counts[len(counts)-1] > 200 {
+		t.Errorf("expected a bounded number of centroids, small at the"+
+			" extremes, got: %v", counts)
+	}
+
+	var sum uint64
+	for _, c := range counts {
+		sum += c
+	}
+	if sum != 100000 {
+		t.Errorf("expected centroid counts to sum to the total, got: %d", sum)
+	}
+
+	other := NewTDigestHistogram("other", 100)
+	other.Add(1000000, 100)
+	other.AddAll(td)
+	if other.Total() != 100100 || other.Percentile(100) != 1000000 ||
+		other.Percentile(50) < 49000 || other.Percentile(50) > 51000 {
+		t.Errorf("unexpected AddAll result, total: %d, p50: %d",
+			other.Total(), other.Percentile(50))
+	}
+
+	td.Reset()
+	if td.Total() != 0 || len(td.BinCounts()) != 0 {
+		t.Errorf("expected Reset to clear the sketch")
+	}
+}
+
+func TestTDigestHistogramAllocs(t *testing.T) {
+	td := NewTDigestHistogram("td", 100)
+
+	allocs := testing.AllocsPerRun(10000, func() { td.Add(5, 1) })
+	if allocs > 0.01 {
+		t.Errorf("expected Add to mostly not allocate, got: %v", allocs)
+	}
+}
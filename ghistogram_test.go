@@ -10,7 +10,13 @@ package ghistogram
 
 import (
 	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestSearch(t *testing.T) {
@@ -205,6 +211,64 @@ func TestGraph(t *testing.T) {
 	}
 }
 
+func TestGraphEmpty(t *testing.T) {
+	gh := NewNamedHistogram("TestGraphEmpty", 5, 10, 2.0)
+
+	got := gh.EmitGraph([]byte("- "), nil).String()
+	exp := "TestGraphEmpty (0 Total)\n- (empty)\n"
+	if got != exp {
+		t.Errorf("didn't get expected graph,\ngot: %s\nexp: %s",
+			got, exp)
+	}
+}
+
+func TestGraphHugeCounts(t *testing.T) {
+	gh := NewNamedHistogram("TestGraphHugeCounts", 3, 10, 0.0)
+
+	// Counts beyond 2^53 lose precision as float64's.
+	gh.Add(0, 1<<62+1)
+	gh.Add(10, 1<<61-1)
+	gh.Add(20, 1<<62)
+
+	exp := `TestGraphHugeCounts (11529215046068469760 Total)
+[0 - 10]     40.00%   40.00% ############################## (4611686018427387905)
+[10 - 20]    20.00%   60.00% ############## (2305843009213693951)
+[20 - inf]   40.00%  100.00% ############################# (4611686018427387904)
+`
+
+	got := gh.EmitGraph(nil, nil).String()
+	if got != exp {
+		t.Errorf("didn't get expected graph,\ngot: %s\nexp: %s",
+			got, exp)
+	}
+}
+
+func TestPercentHundredths(t *testing.T) {
+	tests := []struct {
+		n, d uint64
+		exp  uint64
+	}{
+		{0, 0, 0},
+		{1, 0, 0},
+		{0, 3, 0},
+		{1, 3, 3333},
+		{2, 3, 6667},
+		{1, 8, 1250},
+		{1, 16000, 1},
+		{3, 3, 10000},
+		{1<<62 + 1, 1<<64 - 1, 2500},
+		{1<<64 - 2, 1<<64 - 1, 10000},
+	}
+
+	for testi, test := range tests {
+		got := percentHundredths(test.n, test.d)
+		if got != test.exp {
+			t.Errorf("test #%d, n: %d, d: %d, exp: %d, got: %d",
+				testi, test.n, test.d, test.exp, got)
+		}
+	}
+}
+
 func BenchmarkAdd_100_10_0p0(b *testing.B) {
 	benchmarkAdd(b, 100, 10, 0.0)
 }
@@ -265,3 +329,248 @@ func benchmarkEmitGraph(b *testing.B,
 		buf.Reset()
 	}
 }
+
+func TestSampleTimes(t *testing.T) {
+	gh := NewHistogram(5, 10, 2.0)
+
+	gh.SetTrackSampleTimes(true)
+
+	first, last := gh.SampleTimes()
+	if !first.IsZero() || !last.IsZero() {
+		t.Errorf("expected zero sample times for an empty histogram")
+	}
+
+	before := time.Now()
+	gh.Add(1, 1)
+	time.Sleep(time.Millisecond)
+	gh.Add(2, 1)
+	after := time.Now()
+
+	first, last = gh.SampleTimes()
+	if first.Before(before) || !last.After(first) || last.After(after) {
+		t.Errorf("unexpected sample times: %v, %v", first, last)
+	}
+
+	gh2 := gh.CloneEmpty()
+	gh2.AddAll(gh)
+	first2, last2 := gh2.SampleTimes()
+	if !first2.Equal(first) || !last2.Equal(last) {
+		t.Errorf("expected AddAll to merge sample times")
+	}
+
+	gh.Reset()
+	if first, _ = gh.SampleTimes(); !first.IsZero() {
+		t.Errorf("expected Reset to clear sample times")
+	}
+}
+
+func TestBinAccessors(t *testing.T) {
+	gh := NewHistogram(3, 10, 2.0)
+	gh.Add(15, 2)
+
+	if gh.NumBins() != 3 {
+		t.Errorf("expected 3 bins, got: %d", gh.NumBins())
+	}
+
+	expRanges := [][2]uint64{{0, 10}, {10, 20}, {20, math.MaxUint64}}
+	if got := gh.BinRanges(); !reflect.DeepEqual(got, expRanges) {
+		t.Errorf("expected ranges: %v, got: %v", expRanges, got)
+	}
+
+	counts := gh.BinCounts()
+	if !reflect.DeepEqual(counts, []uint64{0, 2, 0}) {
+		t.Errorf("unexpected counts: %v", counts)
+	}
+
+	counts[1] = 100
+	if gh.Counts[1] != 2 {
+		t.Errorf("expected BinCounts to return a copy")
+	}
+}
+
+func TestNewHistogramOverflow(t *testing.T) {
+	tests := []struct {
+		numBins         int
+		binFirst        uint64
+		binGrowthFactor float64
+		expNumBins      int
+		expLastButOne   uint64
+	}{
+		{64, 1, 2.0, 64, 1 << 61},
+		{65, 1, 2.0, 65, 1 << 62},
+		{66, 1, 2.0, 66, 1 << 63},
+		{100, 1, 2.0, 66, 1 << 63},
+		{6, 1 << 62, 0, 5, 3 << 62},
+	}
+
+	for testi, test := range tests {
+		gh := NewHistogram(test.numBins, test.binFirst, test.binGrowthFactor)
+		if err := gh.Validate(); err != nil {
+			t.Errorf("test #%d, unexpected err: %v", testi, err)
+		}
+
+		n := gh.NumBins()
+		if n != test.expNumBins || gh.Ranges[n-2] != test.expLastButOne {
+			t.Errorf("test #%d, expected %d bins, got: %d, ranges: %v",
+				testi, test.expNumBins, n, gh.Ranges)
+		}
+
+		gh.Add(math.MaxUint64, 1)
+		if gh.Counts[n-1] != 1 {
+			t.Errorf("test #%d, expected MaxUint64 in the last bin", testi)
+		}
+	}
+}
+
+func TestSetNameAndString(t *testing.T) {
+	gh := NewNamedHistogram("before", 3, 10, 2.0)
+	gh.Add(15, 2)
+
+	gh.SetName("after")
+
+	exp := `after (2 Total)
+[10 - 20]  100.00%  100.00% ############################## (2)
+`
+	if got := fmt.Sprintf("%v", gh); got != exp || gh.Name != "after" {
+		t.Errorf("unexpected String(), got:\n%s\nexp:\n%s", got, exp)
+	}
+
+	var _ fmt.Stringer = gh
+}
+
+func TestVisitBins(t *testing.T) {
+	gh := NewHistogram(3, 10, 2.0)
+	gh.Add(15, 2)
+	gh.Add(50, 1)
+
+	var got [][3]uint64
+	gh.VisitBins(func(start, end, count uint64) bool {
+		got = append(got, [3]uint64{start, end, count})
+		return true
+	})
+
+	exp := [][3]uint64{{0, 10, 0}, {10, 20, 2}, {20, math.MaxUint64, 1}}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("expected bins: %v, got: %v", exp, got)
+	}
+
+	var visited int
+	gh.VisitBins(func(start, end, count uint64) bool {
+		visited++
+		return count == 0
+	})
+	if visited != 2 {
+		t.Errorf("expected early exit after 2 bins, got: %d", visited)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		gh.VisitBins(func(start, end, count uint64) bool { return true })
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got: %v", allocs)
+	}
+}
+
+func TestSnapshotConsistency(t *testing.T) {
+	gh := NewHistogram(20, 10, 0.0)
+	src := NewHistogram(20, 10, 0.0)
+	for i := uint64(0); i < 200; i += 10 {
+		src.Add(i, 3)
+	}
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if g == 0 && i%10 == 0 {
+					gh.AddAll(src)
+				} else {
+					gh.Add(uint64(i%250), 1)
+				}
+			}
+		}(g)
+	}
+
+	var lastTotal uint64
+	for i := 0; i < 2000; i++ {
+		snap := gh.Snapshot()
+
+		var sum uint64
+		for _, c := range snap.Counts {
+			sum += c
+		}
+		if sum != snap.TotCount {
+			t.Fatalf("torn snapshot, sum of counts: %d, TotCount: %d",
+				sum, snap.TotCount)
+		}
+
+		total := gh.Total()
+		if total < lastTotal || total < snap.TotCount {
+			t.Fatalf("Total went backwards, last: %d, snapshot: %d, got: %d",
+				lastTotal, snap.TotCount, total)
+		}
+		lastTotal = total
+	}
+
+	close(done)
+	wg.Wait()
+}
+
+func TestSnapshotAndReset(t *testing.T) {
+	gh := NewNamedHistogram("get", 5, 10, 0.0)
+	gh.Add(5, 2)
+	gh.Add(25, 1)
+
+	snap := gh.SnapshotAndReset("rebalance")
+	if snap.TotCount != 3 || snap.Counts[0] != 2 || snap.Counts[2] != 1 {
+		t.Errorf("unexpected snapshot: %v", snap)
+	}
+	if gh.Total() != 0 || gh.TotCount != 0 || gh.Counts[0] != 0 {
+		t.Errorf("expected reset histogram, got: %v", gh)
+	}
+	if !strings.Contains(gh.String(), "rebalance") {
+		t.Errorf("expected reset reason, got: %s", gh)
+	}
+
+	gh.Add(5, 1)
+	if snap = gh.SnapshotAndReset(""); snap.TotCount != 1 ||
+		strings.Contains(gh.String(), "rebalance") {
+		t.Errorf("unexpected snapshot: %v, histogram: %v", snap, gh)
+	}
+}
+
+func TestTotal(t *testing.T) {
+	gh := NewHistogram(5, 10, 0.0)
+	gh.Add(5, 2)
+	gh.CallSyncEx(func(hm HistogramMutator) { hm.Add(15, 1) })
+	if gh.Total() != 3 {
+		t.Errorf("expected Total 3, got: %d", gh.Total())
+	}
+
+	gh.AddAll(gh.Snapshot())
+	if gh.Total() != 6 || gh.Snapshot().Total() != 6 ||
+		gh.Compact(2).Total() != 6 {
+		t.Errorf("expected Total 6, got: %d", gh.Total())
+	}
+
+	b, _ := gh.MarshalJSON()
+
+	gh.Reset()
+	if gh.Total() != 0 {
+		t.Errorf("expected Total 0 after Reset, got: %d", gh.Total())
+	}
+
+	if err := gh.UnmarshalJSON(b); err != nil || gh.Total() != 6 {
+		t.Errorf("expected Total 6 after UnmarshalJSON, got: %d, err: %v",
+			gh.Total(), err)
+	}
+}
@@ -0,0 +1,181 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Timer bundles a histogram of timings with an event counter and
+// rolling 1 and 5 minute event rates, which is the composite metric
+// most dashboards display.
+//
+// The Timer is concurrent safe.
+type Timer struct {
+	hist *Histogram
+	unit time.Duration
+
+	m        sync.Mutex
+	count    uint64
+	start    time.Time
+	lastTick time.Time
+	rate1    ewma
+	rate5    ewma
+	now      func() time.Time
+}
+
+// TimerSnapshot holds the throughput and latency statistics of a
+// Timer at a point in time.
+type TimerSnapshot struct {
+	Count uint64 // Count is the number of events since creation.
+
+	MeanRate float64 // MeanRate is the events per second since creation.
+	Rate1    float64 // Rate1 is the 1 minute moving average rate.
+	Rate5    float64 // Rate5 is the 5 minute moving average rate.
+
+	Min, Max uint64 // Min and Max are the smallest and largest timings.
+
+	P50, P90, P99, P999 uint64 // Percentiles of the timings.
+}
+
+// timerTickInterval is how often the moving average rates are updated.
+const timerTickInterval = 5 * time.Second
+
+// NewTimer creates a new Timer whose histogram records timings in
+// multiples of unit, for example time.Microsecond, with the given
+// histogram creation parameters, see NewNamedHistogram().
+func NewTimer(name string, unit time.Duration,
+	numBins int, binFirst uint64, binGrowthFactor float64) *Timer {
+	return newTimer(name, unit, numBins, binFirst, binGrowthFactor, time.Now)
+}
+
+// NewTimerWithClock is NewTimer() where the rates are based on the
+// clock, which is also the clock of the Timer's histogram, see
+// SetClock().
+func NewTimerWithClock(name string, unit time.Duration,
+	numBins int, binFirst uint64, binGrowthFactor float64,
+	clock Clock) *Timer {
+	t := newTimer(name, unit, numBins, binFirst, binGrowthFactor, clock.Now)
+	t.hist.SetClock(clock)
+	return t
+}
+
+func newTimer(name string, unit time.Duration,
+	numBins int, binFirst uint64, binGrowthFactor float64,
+	now func() time.Time) *Timer {
+	start := now()
+
+	return &Timer{
+		hist:     NewNamedHistogram(name, numBins, binFirst, binGrowthFactor),
+		unit:     unit,
+		start:    start,
+		lastTick: start,
+		rate1:    newEWMA(1),
+		rate5:    newEWMA(5),
+		now:      now,
+	}
+}
+
+// Histogram returns the histogram of timings of the Timer.
+func (t *Timer) Histogram() *Histogram {
+	return t.hist
+}
+
+// Add records a timing that's already expressed in the Timer's unit.
+func (t *Timer) Add(dataPoint uint64) {
+	t.hist.Add(dataPoint, 1)
+
+	t.m.Lock()
+	t.tickUNLOCKED()
+	t.count++
+	t.rate1.uncounted++
+	t.rate5.uncounted++
+	t.m.Unlock()
+}
+
+// Update records the timing of duration d.
+func (t *Timer) Update(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	t.Add(uint64(d / t.unit))
+}
+
+// UpdateSince records the time elapsed since start.
+func (t *Timer) UpdateSince(start time.Time) {
+	t.Update(t.now().Sub(start))
+}
+
+// Snapshot returns the current throughput and latency statistics.
+func (t *Timer) Snapshot() TimerSnapshot {
+	var s TimerSnapshot
+
+	t.m.Lock()
+	t.tickUNLOCKED()
+	s.Count = t.count
+	if elapsed := t.now().Sub(t.start).Seconds(); elapsed > 0 {
+		s.MeanRate = float64(t.count) / elapsed
+	}
+	s.Rate1 = t.rate1.rate
+	s.Rate5 = t.rate5.rate
+	t.m.Unlock()
+
+	t.hist.m.Lock()
+	if t.hist.TotCount > 0 {
+		s.Min = t.hist.MinDataPoint
+		s.Max = t.hist.MaxDataPoint
+	}
+	s.P50 = t.hist.percentileUNLOCKED(50)
+	s.P90 = t.hist.percentileUNLOCKED(90)
+	s.P99 = t.hist.percentileUNLOCKED(99)
+	s.P999 = t.hist.percentileUNLOCKED(99.9)
+	t.hist.m.Unlock()
+
+	return s
+}
+
+// tickUNLOCKED catches up the moving averages with the elapsed ticks.
+func (t *Timer) tickUNLOCKED() {
+	elapsed := t.now().Sub(t.lastTick)
+	for elapsed >= timerTickInterval {
+		t.rate1.tick()
+		t.rate5.tick()
+		t.lastTick = t.lastTick.Add(timerTickInterval)
+		elapsed -= timerTickInterval
+	}
+}
+
+// ewma is an exponentially weighted moving average of an event rate,
+// in the style of the UNIX load averages.
+type ewma struct {
+	alpha     float64
+	rate      float64 // Events per second.
+	uncounted uint64  // Events since the last tick.
+	init      bool
+}
+
+func newEWMA(minutes float64) ewma {
+	return ewma{
+		alpha: 1 - math.Exp(-timerTickInterval.Minutes()/minutes),
+	}
+}
+
+func (e *ewma) tick() {
+	instantRate := float64(e.uncounted) / timerTickInterval.Seconds()
+	e.uncounted = 0
+
+	if e.init {
+		e.rate += e.alpha * (instantRate - e.rate)
+	} else {
+		e.rate = instantRate
+		e.init = true
+	}
+}
@@ -0,0 +1,53 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTimer(t *testing.T) {
+	now := time.Unix(1000, 0)
+	tm := newTimer("TestTimer", time.Microsecond, 10, 10, 2.0,
+		func() time.Time { return now })
+
+	// 10 events per second for a minute, each taking 15µs.
+	for i := 0; i < 600; i++ {
+		tm.Update(15 * time.Microsecond)
+		now = now.Add(100 * time.Millisecond)
+	}
+
+	s := tm.Snapshot()
+	if s.Count != 600 {
+		t.Errorf("Count wrong, got: %d", s.Count)
+	}
+	if math.Abs(s.MeanRate-10) > 0.01 {
+		t.Errorf("MeanRate wrong, got: %v", s.MeanRate)
+	}
+	if math.Abs(s.Rate1-10) > 0.01 || math.Abs(s.Rate5-10) > 0.01 {
+		t.Errorf("Rate1/Rate5 wrong, got: %v/%v", s.Rate1, s.Rate5)
+	}
+	if s.Min != 15 || s.Max != 15 || s.P50 != 15 || s.P999 != 15 {
+		t.Errorf("percentiles wrong, got: %+v", s)
+	}
+
+	// The 1 minute rate decays faster than the 5 minute rate when idle.
+	now = now.Add(time.Minute)
+
+	s = tm.Snapshot()
+	if s.Rate1 >= s.Rate5 || s.Rate5 >= 10 {
+		t.Errorf("expected decaying rates, got: %v/%v", s.Rate1, s.Rate5)
+	}
+
+	if tm.Histogram().TotCount != 600 {
+		t.Errorf("Histogram TotCount wrong")
+	}
+}
@@ -0,0 +1,142 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"math/bits"
+)
+
+// Transform maps data points into the domain of a histogram's bin
+// ranges before the bin lookup, which allows compact bin layouts for
+// metrics with extremely wide ranges.
+type Transform interface {
+	// Forward maps a data point into the bin ranges domain.
+	Forward(dataPoint uint64) uint64
+
+	// Inverse maps a bin range boundary back into data point units,
+	// which is used to render the bin labels.
+	Inverse(binValue uint64) uint64
+}
+
+// SetTransform changes the transform applied to data points before
+// the bin lookup, where nil means no transform.  The TotDataPoint,
+// MinDataPoint and MaxDataPoint remain in the original data point
+// units.  SetTransform panics on a DivTransform with a Unit of 0,
+// rather than Add() dividing by zero later.
+func (gh *Histogram) SetTransform(t Transform) {
+	switch dt := t.(type) {
+	case DivTransform:
+		dt.check()
+	case *DivTransform:
+		if dt != nil {
+			dt.check()
+		}
+	}
+
+	gh.m.Lock()
+	gh.transform = t
+	gh.layout32 = nil
+	gh.m.Unlock()
+}
+
+// binValue maps the dataPoint into the bin ranges domain.
+func (gh *Histogram) binValue(dataPoint uint64) uint64 {
+	if gh.transform != nil {
+		return gh.transform.Forward(dataPoint)
+	}
+	return dataPoint
+}
+
+// rangeLabel maps a bin range boundary into data point units.
+func (gh *Histogram) rangeLabel(binValue uint64) uint64 {
+	if gh.transform != nil {
+		return gh.transform.Inverse(binValue)
+	}
+	return binValue
+}
+
+// Log2Transform bins data points by their bit length, so a data
+// point v maps to 0 if v is 0, otherwise to floor(log2(v)) + 1.  With
+// constant bins of width 1, bin i then holds data points from
+// 2^(i-1) up to 2^i.
+type Log2Transform struct{}
+
+// Forward returns the bit length of the data point.
+func (Log2Transform) Forward(dataPoint uint64) uint64 {
+	return uint64(bits.Len64(dataPoint))
+}
+
+// Inverse returns the smallest data point of the bit length, which is
+// math.MaxUint64 beyond a bit length of 64.
+func (Log2Transform) Inverse(binValue uint64) uint64 {
+	if binValue == 0 {
+		return 0
+	}
+	if binValue > 64 {
+		return math.MaxUint64
+	}
+	return 1 << (binValue - 1)
+}
+
+// SqrtTransform bins data points by their integer square root.
+type SqrtTransform struct{}
+
+// Forward returns the integer square root of the data point.
+func (SqrtTransform) Forward(dataPoint uint64) uint64 {
+	r := uint64(math.Sqrt(float64(dataPoint)))
+	// Correct any float64 rounding of large data points.
+	for r > 0 && (r > math.MaxUint32 || r*r > dataPoint) {
+		r--
+	}
+	for r < math.MaxUint32 && (r+1)*(r+1) <= dataPoint {
+		r++
+	}
+	return r
+}
+
+// Inverse returns the square of the bin value, saturated at
+// math.MaxUint64.
+func (SqrtTransform) Inverse(binValue uint64) uint64 {
+	return saturatingMul(binValue, binValue)
+}
+
+// DivTransform bins data points in multiples of Unit, for example,
+// nanosecond timings binned by microsecond with a Unit of 1000.  The
+// Unit must be > 0, which SetTransform() checks.
+type DivTransform struct {
+	Unit uint64
+}
+
+// Forward returns the number of whole Units of the data point.
+func (t DivTransform) Forward(dataPoint uint64) uint64 {
+	return dataPoint / t.Unit
+}
+
+// Inverse returns the bin value in data point units, saturated at
+// math.MaxUint64.
+func (t DivTransform) Inverse(binValue uint64) uint64 {
+	return saturatingMul(binValue, t.Unit)
+}
+
+// check panics when the Unit is 0.
+func (t DivTransform) check() {
+	if t.Unit == 0 {
+		panic("ghistogram: DivTransform, invalid Unit: 0")
+	}
+}
+
+// saturatingMul returns a*b, or math.MaxUint64 on overflow.
+func saturatingMul(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	if hi != 0 {
+		return math.MaxUint64
+	}
+	return lo
+}
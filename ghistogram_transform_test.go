@@ -0,0 +1,98 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTransforms(t *testing.T) {
+	tests := []struct {
+		transform Transform
+		val       uint64
+		expFwd    uint64
+		expInv    uint64
+	}{
+		{Log2Transform{}, 0, 0, 0},
+		{Log2Transform{}, 1, 1, 1},
+		{Log2Transform{}, 3, 2, 2},
+		{Log2Transform{}, 4, 3, 4},
+		{Log2Transform{}, math.MaxUint64, 64, 1 << 63},
+
+		{SqrtTransform{}, 0, 0, 0},
+		{SqrtTransform{}, 15, 3, 9},
+		{SqrtTransform{}, 16, 4, 16},
+		{SqrtTransform{}, math.MaxUint64, math.MaxUint32,
+			math.MaxUint32 * math.MaxUint32},
+
+		{DivTransform{1000}, 999, 0, 0},
+		{DivTransform{1000}, 12345, 12, 12000},
+	}
+
+	for testi, test := range tests {
+		fwd := test.transform.Forward(test.val)
+		if fwd != test.expFwd {
+			t.Errorf("test #%d, Forward(%d), exp: %d, got: %d",
+				testi, test.val, test.expFwd, fwd)
+		}
+		if inv := test.transform.Inverse(fwd); inv != test.expInv {
+			t.Errorf("test #%d, Inverse(%d), exp: %d, got: %d",
+				testi, fwd, test.expInv, inv)
+		}
+	}
+}
+
+func TestTransformGraph(t *testing.T) {
+	gh := NewNamedHistogram("TestTransformGraph", 10, 1, 0.0)
+	gh.SetTransform(Log2Transform{})
+
+	gh.Add(0, 1)
+	gh.Add(5, 2)
+	gh.Add(100, 3)
+	gh.Add(1000000, 4)
+
+	exp := `TestTransformGraph (10 Total)
+[0 - 1]       10.00%   10.00% ####### (1)
+[4 - 8]       20.00%   30.00% ############### (2)
+[64 - 128]    30.00%   60.00% ###################### (3)
+[256 - inf]   40.00%  100.00% ############################## (4)
+`
+
+	got := gh.EmitGraph(nil, nil).String()
+	if got != exp {
+		t.Errorf("didn't get expected graph,\ngot: %s\nexp: %s",
+			got, exp)
+	}
+
+	if gh.MaxDataPoint != 1000000 || gh.TotDataPoint != 1000105 {
+		t.Errorf("expected data points in original units")
+	}
+}
+
+func TestDivTransformZeroUnit(t *testing.T) {
+	for _, tr := range []Transform{DivTransform{}, &DivTransform{}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected SetTransform(%#v) to panic", tr)
+				}
+			}()
+
+			NewHistogram(5, 10, 0).SetTransform(tr)
+		}()
+	}
+
+	gh := NewHistogram(5, 10, 0)
+	gh.SetTransform(&DivTransform{Unit: 10})
+	gh.Add(55, 1)
+	if gh.Counts[0] != 1 {
+		t.Errorf("unexpected counts: %v", gh.Counts)
+	}
+}
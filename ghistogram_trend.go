@@ -0,0 +1,144 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// Trend returns the estimated data point at the given percentile, see
+// Percentile(), of each retained window of the history, oldest first,
+// so a percentile can be followed across intervals and resets, or nil
+// when the history is not enabled, see EnableHistory().  Windows
+// without data points have a percentile of 0.
+func (gh *Histogram) Trend(p float64) []uint64 {
+	gh.m.Lock()
+	defer gh.m.Unlock()
+
+	return gh.trendUNLOCKED(p)
+}
+
+func (gh *Histogram) trendUNLOCKED(p float64) []uint64 {
+	h := gh.history
+	if h == nil {
+		return nil
+	}
+
+	h.rotate(gh.now())
+
+	rv := make([]uint64, 0, len(h.windows))
+	for i := range h.windows {
+		w := h.windows[(h.next+i)%len(h.windows)]
+		rv = append(rv, w.Histogram.percentileUNLOCKED(p))
+	}
+
+	return rv
+}
+
+// EmitTrend emits an ascii chart of the Trend() of the given
+// percentile, of up to height lines, to the optional out buffer,
+// allocating an out buffer if none was supplied.  Each retained window
+// of the history is a column, oldest first, with a height relative to
+// the largest percentile, which labels the top line.  Each line
+// emitted may have an optional prefix.
+//
+// For example:
+//    get p99 trend (6 windows of 1m0s)
+//    88 |    #
+//       |    ##
+//       |#  ###
+//     0 +------
+func (gh *Histogram) EmitTrend(prefix []byte, out *bytes.Buffer,
+	p float64, height int) *bytes.Buffer {
+	if height < 1 {
+		height = 1
+	}
+
+	gh.m.Lock()
+	values := gh.trendUNLOCKED(p)
+	var interval string
+	if gh.history != nil {
+		interval = gh.history.interval.String()
+	}
+	name := gh.Name
+	gh.m.Unlock()
+
+	if out == nil {
+		out = bytes.NewBuffer(make([]byte, 0, 80*(height+2)))
+	}
+
+	fmt.Fprintf(out, "%s p%s trend",
+		name, strconv.FormatFloat(p, 'f', -1, 64))
+	if values != nil {
+		fmt.Fprintf(out, " (%d windows of %s)\n", len(values), interval)
+	} else {
+		out.WriteString(" (no history)\n")
+	}
+
+	emitPrefix := func() {
+		if prefix != nil {
+			out.Write(prefix)
+		}
+	}
+
+	var maxValue uint64
+	for _, v := range values {
+		if maxValue < v {
+			maxValue = v
+		}
+	}
+
+	if maxValue == 0 {
+		emitPrefix()
+		out.WriteString("(empty)\n")
+		return out
+	}
+
+	top := strconv.FormatUint(maxValue, 10)
+	blank := bytes.Repeat([]byte(" "), len(top))
+
+	levels := make([]int, 0, len(values))
+	for _, v := range values {
+		// Rounds up, so any non-zero percentile has at least one line.
+		level := mulDiv(v, uint64(height), maxValue)
+		if mulDiv(level, maxValue, uint64(height)) < v {
+			level++
+		}
+		levels = append(levels, int(level))
+	}
+
+	for row := height; row >= 1; row-- {
+		line := append([]byte(nil), blank...)
+		if row == height {
+			copy(line, top)
+		}
+		line = append(line, " |"...)
+		for _, level := range levels {
+			if level >= row {
+				line = append(line, '#')
+			} else {
+				line = append(line, ' ')
+			}
+		}
+
+		emitPrefix()
+		out.Write(bytes.TrimRight(line, " "))
+		out.WriteByte('\n')
+	}
+
+	emitPrefix()
+	out.Write(blank[:len(top)-1])
+	out.WriteString("0 +")
+	out.Write(bytes.Repeat([]byte("-"), len(levels)))
+	out.WriteByte('\n')
+
+	return out
+}
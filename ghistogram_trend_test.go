@@ -0,0 +1,72 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTrend(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+
+	gh := NewNamedHistogram("get", 10, 10, 2.0)
+	gh.SetClock(clock)
+
+	if gh.Trend(99) != nil {
+		t.Errorf("expected no trend when the history is not enabled")
+	}
+
+	buf := gh.EmitTrend(nil, nil, 99, 3)
+	exp := "get p99 trend (no history)\n(empty)\n"
+	if buf.String() != exp {
+		t.Errorf("got:\n%s\nexp:\n%s", buf.String(), exp)
+	}
+
+	gh.EnableHistory(4, time.Minute)
+
+	for _, v := range []uint64{10, 0, 40, 80} {
+		if v > 0 {
+			gh.Add(v, 10)
+		}
+		gh.Reset() // The trend is not affected by resets.
+		clock.Advance(time.Minute)
+	}
+
+	got := gh.Trend(99)
+	if !reflect.DeepEqual(got, []uint64{10, 0, 40, 80}) {
+		t.Errorf("unexpected trend: %v", got)
+	}
+
+	tests := []struct {
+		height int
+		exp    string
+	}{
+		{0, `get p50 trend (4 windows of 1m0s)
+> 80 |# ##
+>  0 +----
+`},
+		{4, `get p50 trend (4 windows of 1m0s)
+> 80 |   #
+>    |   #
+>    |  ##
+>    |# ##
+>  0 +----
+`},
+	}
+
+	for testi, test := range tests {
+		buf := gh.EmitTrend([]byte("> "), nil, 50, test.height)
+		if buf.String() != test.exp {
+			t.Errorf("test #%d, got:\n%s\nexp:\n%s",
+				testi, buf.String(), test.exp)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// WithUnit sets the unit of the histogram's data points, which is used
+// to render the bin ranges of EmitGraph() and similar outputs with
+// human-readable suffixes, and is carried by the JSON and CSV
+// encodings, for example:
+//
+//    gh := ghistogram.NewNamedHistogram("get", 20, 10, 2.0).
+//        WithUnit("µs")
+//
+// The time units "ns", "µs" (or "us"), "ms" and "s" are scaled up, so
+// 1200µs renders as 1.2ms, and the units "bytes" (or "B") are scaled
+// by powers of 1024, so 4096 bytes render as 4KiB.  Other units are
+// simply appended, like 10req.  Returns the histogram.
+func (gh *Histogram) WithUnit(unit string) *Histogram {
+	gh.m.Lock()
+	gh.unit = unit
+	gh.m.Unlock()
+
+	return gh
+}
+
+// Unit returns the unit of the histogram's data points, see
+// WithUnit().
+func (gh *Histogram) Unit() string {
+	gh.m.Lock()
+	rv := gh.unit
+	gh.m.Unlock()
+	return rv
+}
+
+// unitScale is a suffix for values of at least its size, in the
+// histogram's unit.
+type unitScale struct {
+	size   float64
+	suffix string
+}
+
+var timeScales = []unitScale{
+	{1, "ns"}, {1e3, "µs"}, {1e6, "ms"}, {1e9, "s"},
+}
+
+var byteScales = []unitScale{
+	{1, "B"}, {1 << 10, "KiB"}, {1 << 20, "MiB"}, {1 << 30, "GiB"},
+	{1 << 40, "TiB"}, {1 << 50, "PiB"}, {1 << 60, "EiB"},
+}
+
+// unitScales returns the scales of a unit, in increasing size, and the
+// size of the unit in terms of the smallest scale, or nil when the
+// unit isn't scaled.
+func unitScales(unit string) ([]unitScale, float64) {
+	switch unit {
+	case "ns":
+		return timeScales, 1
+	case "µs", "us":
+		return timeScales, 1e3
+	case "ms":
+		return timeScales, 1e6
+	case "s":
+		return timeScales, 1e9
+	case "bytes", "B":
+		return byteScales, 1
+	}
+	return nil, 0
+}
+
+// durationUnit returns the duration of a data point of the histogram
+// of a time unit, see WithUnit(), or time.Microsecond by default.
+func (gh *Histogram) durationUnit() time.Duration {
+	scales, unitSize := unitScales(gh.unit)
+	if len(scales) > 0 && scales[0] == timeScales[0] {
+		return time.Duration(unitSize)
+	}
+	return time.Microsecond
+}
+
+// boundLabel renders a bin range boundary, in data point units, for
+// the human-readable outputs, see WithUnit() and SetRangeFormat().
+func (gh *Histogram) boundLabel(v uint64) string {
+	// An unscaled unit is appended after any range format suffix.
+	suffix := ""
+
+	scales, unitSize := unitScales(gh.unit)
+	if scales == nil {
+		scales, unitSize, suffix = gh.rangeFormatScales(), 1, gh.unit
+		if scales == nil {
+			return strconv.FormatUint(v, 10) + suffix
+		}
+	}
+
+	x := float64(v) * unitSize
+
+	// Never scale below the unit itself, so 0µs doesn't render as 0ns.
+	var scale unitScale
+	for _, s := range scales {
+		if s.size >= unitSize && (x >= s.size || scale.size == 0) {
+			scale = s
+		}
+	}
+
+	return formatScaled(x/scale.size) + scale.suffix + suffix
+}
+
+// formatScaled formats v with at most 2 decimals, without trailing
+// zeros.
+func formatScaled(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
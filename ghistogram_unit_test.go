@@ -0,0 +1,85 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestBoundLabel(t *testing.T) {
+	tests := []struct {
+		unit string
+		v    uint64
+		exp  string
+	}{
+		{"", 1073741824, "1073741824"},
+		{"µs", 0, "0µs"},
+		{"µs", 10, "10µs"},
+		{"us", 1200, "1.2ms"},
+		{"µs", 1234567, "1.23s"},
+		{"ns", 999, "999ns"},
+		{"ms", 90000, "90s"},
+		{"s", 5, "5s"},
+		{"bytes", 4096, "4KiB"},
+		{"B", 1536, "1.5KiB"},
+		{"bytes", 1 << 40, "1TiB"},
+		{"bytes", 100, "100B"},
+		{"req", 10, "10req"},
+	}
+
+	for testi, test := range tests {
+		gh := NewHistogram(2, 1, 0).WithUnit(test.unit)
+		if got := gh.boundLabel(test.v); got != test.exp {
+			t.Errorf("test #%d, expected %q, got: %q", testi, test.exp, got)
+		}
+	}
+}
+
+func TestWithUnit(t *testing.T) {
+	gh := NewNamedHistogram("get", 4, 500, 2.0).WithUnit("µs")
+	gh.Add(100, 1)
+	gh.Add(1500, 3)
+
+	exp := `get (4 Total)
+[0µs - 500µs]   25.00%   25.00% ########## (1)
+[1ms - 2ms]     75.00%  100.00% ############################## (3)
+`
+	if got := gh.EmitGraph(nil, nil).String(); got != exp {
+		t.Errorf("unexpected graph, got:\n%s\nexp:\n%s", got, exp)
+	}
+
+	b, err := json.Marshal(gh)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Histogram
+	if err = json.Unmarshal(b, &decoded); err != nil ||
+		decoded.Unit() != "µs" || decoded.CloneEmpty().Unit() != "µs" {
+		t.Errorf("expected the unit to round trip JSON, got: %s, %v",
+			b, err)
+	}
+
+	var out bytes.Buffer
+	gh.WriteCSV(&out)
+	if !strings.HasPrefix(out.String(),
+		"start,end,count,percent,cumulative_percent,unit\n"+
+			"0,500,1,25.00,25.00,µs\n") {
+		t.Errorf("unexpected CSV, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	Histograms{"get": gh, "set": NewHistogram(2, 1, 0)}.WriteCSV(&out)
+	if !strings.Contains(out.String(), "set,1,inf,0,0.00,0.00,\n") {
+		t.Errorf("expected an empty unit column, got:\n%s", out.String())
+	}
+}
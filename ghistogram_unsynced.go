@@ -8,6 +8,10 @@
 
 package ghistogram
 
+import (
+	"bytes"
+)
+
 // HistogramMutator represents the subset of Histogram methods related
 // to mutation operations.
 type HistogramMutator interface {
@@ -22,5 +26,50 @@ type histogramMutator struct {
 
 // Add increases the count in the histogram bin for the given dataPoint.
 func (h *histogramMutator) Add(dataPoint uint64, count uint64) {
+	h.audit.assertHeld("HistogramMutator.Add")
 	h.addUNLOCKED(dataPoint, count)
 }
+
+// HistogramReader represents the subset of Histogram methods related
+// to read-only operations, so a stats collector may be handed a view
+// of a histogram that cannot mutate or reset it.
+type HistogramReader interface {
+	Total() uint64
+	Percentile(p float64) uint64
+	BinCounts() []uint64
+	EmitGraph(prefix []byte, out *bytes.Buffer) *bytes.Buffer
+}
+
+// histogramReader implements the HistogramReader interface for a
+// given Histogram.  The Histogram is not embedded, so that its
+// mutating methods are not reachable through a type assertion.
+type histogramReader struct {
+	gh *Histogram
+}
+
+// Total returns the total count of data points.
+func (h *histogramReader) Total() uint64 {
+	h.gh.audit.assertHeld("HistogramReader.Total")
+	return h.gh.TotCount
+}
+
+// Percentile returns an estimate of the data point at the given
+// percentile, see Histogram.Percentile().
+func (h *histogramReader) Percentile(p float64) uint64 {
+	h.gh.audit.assertHeld("HistogramReader.Percentile")
+	return h.gh.percentileUNLOCKED(p)
+}
+
+// BinCounts returns a copy of the count of every bin.
+func (h *histogramReader) BinCounts() []uint64 {
+	h.gh.audit.assertHeld("HistogramReader.BinCounts")
+	return append([]uint64(nil), h.gh.Counts...)
+}
+
+// EmitGraph emits an ascii graph to the optional out buffer, see
+// Histogram.EmitGraph().
+func (h *histogramReader) EmitGraph(prefix []byte,
+	out *bytes.Buffer) *bytes.Buffer {
+	h.gh.audit.assertHeld("HistogramReader.EmitGraph")
+	return h.gh.emitGraphUNLOCKED(prefix, out, 0)
+}
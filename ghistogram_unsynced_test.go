@@ -53,3 +53,37 @@ func TestUnsyncedAdd(t *testing.T) {
 		t.Errorf("Unexpected content in histograms!")
 	}
 }
+
+func TestUnsyncedRead(t *testing.T) {
+	gh := NewNamedHistogram("hist", 5, 10, 0.0)
+	gh.Add(5, 3)
+	gh.Add(15, 1)
+
+	var total, p50 uint64
+	var counts []uint64
+	var graph string
+
+	gh.CallSyncReadEx(func(hr HistogramReader) {
+		if _, ok := hr.(HistogramMutator); ok {
+			t.Errorf("expected HistogramReader to not be a mutator")
+		}
+
+		total = hr.Total()
+		p50 = hr.Percentile(50)
+		counts = hr.BinCounts()
+		graph = hr.EmitGraph(nil, nil).String()
+	})
+
+	if total != 4 || p50 != gh.Percentile(50) {
+		t.Errorf("unexpected total: %d, p50: %d", total, p50)
+	}
+
+	counts[0] = 100
+	if gh.Counts[0] != 3 {
+		t.Errorf("expected BinCounts to return a copy")
+	}
+
+	if graph != gh.EmitGraph(nil, nil).String() {
+		t.Errorf("unexpected graph: %s", graph)
+	}
+}
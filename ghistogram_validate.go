@@ -0,0 +1,77 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// Validate checks the bins and counts of the histogram, such as one
+// decoded from JSON or with hand-built Ranges, and returns an error
+// that describes the first problem found, like a bin that's out of
+// order because its boundary wrapped around a uint64, or nil.  The
+// bins must start at 0 and strictly increase, so they neither overlap
+// nor leave gaps, and the TotCount must be the sum of the Counts.
+// UnmarshalJSON() and the checked constructors, like
+// NewNamedHistogramChecked(), also return this error.
+func (gh *Histogram) Validate() error {
+	gh.m.Lock()
+	err := gh.validateUNLOCKED()
+	gh.m.Unlock()
+	if err != nil {
+		return fmt.Errorf("ghistogram: %v", err)
+	}
+	return nil
+}
+
+// validateUNLOCKED returns the error of Validate() without its prefix.
+func (gh *Histogram) validateUNLOCKED() error {
+	if len(gh.Ranges) != len(gh.Counts) {
+		return fmt.Errorf("histogram %q has %d Ranges"+
+			" but %d Counts", gh.Name, len(gh.Ranges), len(gh.Counts))
+	}
+
+	if len(gh.Ranges) == 0 {
+		return fmt.Errorf("histogram %q has no bins", gh.Name)
+	}
+
+	if gh.Ranges[0] != 0 {
+		return fmt.Errorf("histogram %q has a gap, its"+
+			" first bin starts at %d instead of 0", gh.Name, gh.Ranges[0])
+	}
+
+	for i := 1; i < len(gh.Ranges); i++ {
+		prev, cur := gh.Ranges[i-1], gh.Ranges[i]
+		if cur == prev {
+			return fmt.Errorf("histogram %q bin %d [%d - %d)"+
+				" is empty and overlaps bin %d", gh.Name, i-1, prev, cur, i)
+		}
+		if cur < prev {
+			return fmt.Errorf("histogram %q bin %d starts at"+
+				" %d, out of order after bin %d that starts at %d,"+
+				" possibly a uint64 overflow", gh.Name, i, cur, i-1, prev)
+		}
+	}
+
+	var sum uint64
+	for _, c := range gh.Counts {
+		var carry uint64
+		if sum, carry = bits.Add64(sum, c, 0); carry != 0 {
+			sum = math.MaxUint64 // Saturated, see Saturated().
+		}
+	}
+	if sum != gh.TotCount {
+		return fmt.Errorf("histogram %q has TotCount %d,"+
+			" but its Counts sum to %d", gh.Name, gh.TotCount, sum)
+	}
+
+	return nil
+}
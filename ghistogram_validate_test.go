@@ -0,0 +1,68 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		ranges   []uint64
+		counts   []uint64
+		totCount uint64
+		expErr   string // Empty for no error.
+	}{
+		{[]uint64{0, 10, 20}, []uint64{1, 2, 3}, 6, ""},
+		{[]uint64{0}, []uint64{0}, 0, ""},
+		{[]uint64{0, 10}, []uint64{math.MaxUint64, 1}, math.MaxUint64, ""},
+		{[]uint64{0, 10}, []uint64{1}, 1, "2 Ranges but 1 Counts"},
+		{nil, nil, 0, "no bins"},
+		{[]uint64{5, 10}, []uint64{0, 0}, 0, "starts at 5 instead of 0"},
+		{[]uint64{0, 10, 10, 20}, []uint64{0, 0, 0, 0}, 0,
+			"bin 1 [10 - 10) is empty and overlaps bin 2"},
+		{[]uint64{0, 10, 1 << 63, 2}, []uint64{0, 0, 0, 0}, 0,
+			"bin 3 starts at 2, out of order after bin 2"},
+		{[]uint64{0, 10, 20}, []uint64{1, 2, 3}, 7,
+			"has TotCount 7, but its Counts sum to 6"},
+	}
+
+	for testi, test := range tests {
+		gh := &Histogram{
+			Name:     "h",
+			Ranges:   test.ranges,
+			Counts:   test.counts,
+			TotCount: test.totCount,
+		}
+
+		err := gh.Validate()
+		if test.expErr == "" {
+			if err != nil {
+				t.Errorf("test #%d, unexpected err: %v", testi, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), test.expErr) {
+			t.Errorf("test #%d, expected err %q, got: %v",
+				testi, test.expErr, err)
+		}
+	}
+
+	if err := NewHistogram(10, 2, 1.5).Validate(); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	var gh Histogram
+	err := json.Unmarshal([]byte(`{"Name":"h","Ranges":[0,10,5],`+
+		`"Counts":[0,0,0]}`), &gh)
+	if err == nil || !strings.Contains(err.Error(), "out of order") {
+		t.Errorf("expected UnmarshalJSON to validate, got: %v", err)
+	}
+}
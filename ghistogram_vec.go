@@ -0,0 +1,169 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// HistogramVec is a set of identically shaped histograms keyed by the
+// values of a fixed list of labels, like operation and collection,
+// which replaces maintaining a Histograms map with concatenated keys.
+// The child histograms are created lazily, for example:
+//
+//    vec := ghistogram.NewHistogramVec(
+//        ghistogram.NewNamedHistogram("latency", 20, 10, 2.0),
+//        "op", "collection")
+//    vec.WithLabelValues("get", "users").Add(120, 1)
+//
+// The HistogramVec is concurrent safe.
+type HistogramVec struct {
+	proto      *Histogram
+	labelNames []string
+
+	m        sync.RWMutex
+	children map[string]*vecChild // Keyed by vecKey() of label values.
+}
+
+type vecChild struct {
+	labelValues []string
+	gh          *Histogram
+}
+
+// NewHistogramVec creates a new HistogramVec whose children are empty
+// clones of the proto histogram, see CloneEmpty().
+func NewHistogramVec(proto *Histogram, labelNames ...string) *HistogramVec {
+	proto.m.Lock()
+	p := proto.CloneEmpty()
+	proto.m.Unlock()
+
+	return &HistogramVec{
+		proto:      p,
+		labelNames: append([]string(nil), labelNames...),
+		children:   make(map[string]*vecChild),
+	}
+}
+
+// vecKey joins label values with a separator that's not expected in
+// label values.
+func vecKey(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
+
+// WithLabelValues returns the child histogram for the given label
+// values, in the order of the label names, creating it if needed.  It
+// panics when the number of values does not match the label names.
+func (v *HistogramVec) WithLabelValues(labelValues ...string) *Histogram {
+	if len(labelValues) != len(v.labelNames) {
+		panic(fmt.Sprintf("ghistogram: HistogramVec, expected %d label"+
+			" values, got: %d", len(v.labelNames), len(labelValues)))
+	}
+
+	key := vecKey(labelValues)
+
+	v.m.RLock()
+	c := v.children[key]
+	v.m.RUnlock()
+	if c != nil {
+		return c.gh
+	}
+
+	v.m.Lock()
+	c = v.children[key]
+	if c == nil {
+		c = &vecChild{labelValues: append([]string(nil), labelValues...)}
+
+		c.gh = v.proto.CloneEmpty()
+		c.gh.Name = v.proto.Name + "{" +
+			v.labelString(v.labelNames, c.labelValues) + "}"
+
+		v.children[key] = c
+	}
+	v.m.Unlock()
+
+	return c.gh
+}
+
+// labelString returns a "name1=value1,name2=value2" string.
+func (v *HistogramVec) labelString(names, values []string) string {
+	pairs := make([]string, len(names))
+	for i := range names {
+		pairs[i] = names[i] + "=" + values[i]
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Histograms returns the child histograms, keyed by their label
+// strings, like "op=get,collection=users", for export through the
+// Histograms methods.  The histograms are shared, not copied.
+func (v *HistogramVec) Histograms() Histograms {
+	v.m.RLock()
+	rv := make(Histograms, len(v.children))
+	for _, c := range v.children {
+		rv[v.labelString(v.labelNames, c.labelValues)] = c.gh
+	}
+	v.m.RUnlock()
+	return rv
+}
+
+// Aggregate merges the child histograms across all the labels other
+// than the given labels, returning new histograms keyed by the label
+// strings of the given labels.  For example, Aggregate("op") returns
+// a histogram per operation across all collections, and Aggregate()
+// returns a single histogram keyed by "" across all children.
+func (v *HistogramVec) Aggregate(labelNames ...string) (Histograms, error) {
+	idxs := make([]int, len(labelNames))
+	for i, name := range labelNames {
+		idxs[i] = -1
+		for j, vname := range v.labelNames {
+			if name == vname {
+				idxs[i] = j
+			}
+		}
+		if idxs[i] < 0 {
+			return nil, fmt.Errorf("ghistogram: HistogramVec Aggregate,"+
+				" unknown label: %q", name)
+		}
+	}
+
+	v.m.RLock()
+	defer v.m.RUnlock()
+
+	rv := make(Histograms)
+
+	values := make([]string, len(idxs))
+	for _, c := range v.children {
+		for i, idx := range idxs {
+			values[i] = c.labelValues[idx]
+		}
+		key := v.labelString(labelNames, values)
+
+		agg := rv[key]
+		if agg == nil {
+			agg = v.proto.CloneEmpty()
+			agg.Name = v.proto.Name + "{" + key + "}"
+			rv[key] = agg
+		}
+
+		agg.AddAll(c.gh)
+	}
+
+	return rv, nil
+}
+
+// Reset resets every child histogram, keeping the children.
+func (v *HistogramVec) Reset() {
+	v.m.RLock()
+	for _, c := range v.children {
+		c.gh.Reset()
+	}
+	v.m.RUnlock()
+}
@@ -0,0 +1,81 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHistogramVec(t *testing.T) {
+	vec := NewHistogramVec(NewNamedHistogram("lat", 4, 10, 0.0),
+		"op", "coll")
+
+	vec.WithLabelValues("get", "a").Add(5, 1)
+	vec.WithLabelValues("get", "b").Add(15, 2)
+	vec.WithLabelValues("set", "a").Add(25, 4)
+	vec.WithLabelValues("get", "a").Add(35, 8)
+
+	hmap := vec.Histograms()
+	if len(hmap) != 3 || hmap["op=get,coll=a"].TotCount != 9 ||
+		hmap["op=get,coll=a"].Name != "lat{op=get,coll=a}" {
+		t.Errorf("unexpected children: %v", hmap.SortedNames(nil))
+	}
+
+	tests := []struct {
+		labels    []string
+		expCounts map[string][]uint64
+	}{
+		{nil, map[string][]uint64{
+			"": {1, 2, 4, 8},
+		}},
+		{[]string{"op"}, map[string][]uint64{
+			"op=get": {1, 2, 0, 8},
+			"op=set": {0, 0, 4, 0},
+		}},
+		{[]string{"coll", "op"}, map[string][]uint64{
+			"coll=a,op=get": {1, 0, 0, 8},
+			"coll=b,op=get": {0, 2, 0, 0},
+			"coll=a,op=set": {0, 0, 4, 0},
+		}},
+	}
+
+	for testi, test := range tests {
+		agg, err := vec.Aggregate(test.labels...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := map[string][]uint64{}
+		for k, gh := range agg {
+			got[k] = gh.Counts
+		}
+		if !reflect.DeepEqual(got, test.expCounts) {
+			t.Errorf("test #%d, expected: %v, got: %v",
+				testi, test.expCounts, got)
+		}
+	}
+
+	if _, err := vec.Aggregate("vbucket"); err == nil {
+		t.Errorf("expected error for unknown label")
+	}
+
+	vec.Reset()
+	if vec.WithLabelValues("get", "a").TotCount != 0 ||
+		len(vec.Histograms()) != 3 {
+		t.Errorf("expected Reset to keep the children and clear counts")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic on wrong number of label values")
+		}
+	}()
+	vec.WithLabelValues("get")
+}
@@ -0,0 +1,126 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// EmitGraphVertical emits an ascii graph with vertical bars, of up to
+// height lines, to the optional out buffer, allocating an out buffer
+// if none was supplied.  This reads better than EmitGraph() when there
+// are many bins.  Each bin from the first to the last non-empty bin is
+// a column, labeled along the bottom with the bin's lower bound.  Each
+// line emitted may have an optional prefix.
+//
+// For example:
+//    get (142 Total)
+//       ##
+//       ##
+//       ##    ##
+//    ## ## ## ## ##
+//    10 20 30 40 50
+func (gh *Histogram) EmitGraphVertical(prefix []byte,
+	out *bytes.Buffer, height int) *bytes.Buffer {
+	if height < 1 {
+		height = 1
+	}
+
+	gh.m.Lock()
+	defer gh.m.Unlock()
+
+	if out == nil {
+		out = bytes.NewBuffer(make([]byte, 0, 80*(height+2)))
+	}
+
+	fmt.Fprintf(out, "%s (%v Total)\n", gh.Name, gh.TotCount)
+
+	emitPrefix := func() {
+		if prefix != nil {
+			out.Write(prefix)
+		}
+	}
+
+	if gh.TotCount == 0 {
+		emitPrefix()
+		out.WriteString("(empty)\n")
+		return out
+	}
+
+	first, last := -1, -1
+	var maxCount uint64
+	for i, c := range gh.Counts {
+		if c > 0 {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+		if maxCount < c {
+			maxCount = c
+		}
+	}
+
+	labels := make([]string, 0, last-first+1)
+	levels := make([]int, 0, last-first+1)
+	width := 1
+
+	for i := first; i <= last; i++ {
+		label := strconv.FormatUint(gh.rangeLabel(gh.Ranges[i]), 10)
+		if width < len(label) {
+			width = len(label)
+		}
+		labels = append(labels, label)
+
+		// Rounds up, so any non-empty bin has at least one line.
+		c := gh.Counts[i]
+		level := mulDiv(c, uint64(height), maxCount)
+		if mulDiv(level, maxCount, uint64(height)) < c {
+			level++
+		}
+		levels = append(levels, int(level))
+	}
+
+	column := bytes.Repeat([]byte("#"), width)
+	blank := bytes.Repeat([]byte(" "), width)
+
+	for row := height; row >= 1; row-- {
+		var line []byte
+		for i, level := range levels {
+			if i > 0 {
+				line = append(line, ' ')
+			}
+			if level >= row {
+				line = append(line, column...)
+			} else {
+				line = append(line, blank...)
+			}
+		}
+
+		emitPrefix()
+		out.Write(bytes.TrimRight(line, " "))
+		out.WriteByte('\n')
+	}
+
+	emitPrefix()
+	for i, label := range labels {
+		if i > 0 {
+			out.WriteByte(' ')
+		}
+		out.WriteString(label)
+		if i < len(labels)-1 {
+			out.Write(blank[:width-len(label)])
+		}
+	}
+	out.WriteByte('\n')
+
+	return out
+}
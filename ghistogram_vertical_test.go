@@ -0,0 +1,52 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"testing"
+)
+
+func TestEmitGraphVertical(t *testing.T) {
+	gh := NewNamedHistogram("get", 10, 10, 0.0)
+
+	exp := "get (0 Total)\n(empty)\n"
+	if got := gh.EmitGraphVertical(nil, nil, 4).String(); got != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, got)
+	}
+
+	gh.Add(15, 20)
+	gh.Add(25, 80)
+	gh.Add(35, 1)
+	gh.Add(45, 40)
+	gh.Add(55, 1)
+
+	exp = `get (142 Total)
+   ##
+   ##
+   ##    ##
+## ## ## ## ##
+10 20 30 40 50
+`
+	if got := gh.EmitGraphVertical(nil, nil, 4).String(); got != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, got)
+	}
+
+	gh.Reset()
+	gh.Add(5, 1)
+	gh.Add(95, 1)
+
+	exp = `get (2 Total)
+> ##                         ##
+> 0  10 20 30 40 50 60 70 80 90
+`
+	got := gh.EmitGraphVertical([]byte("> "), nil, 1).String()
+	if got != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, got)
+	}
+}
@@ -0,0 +1,99 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"fmt"
+	"time"
+)
+
+// Window is a histogram of the data points of the time window
+// [Start, End), as kept by a rotating-window history.
+type Window struct {
+	Start     time.Time
+	End       time.Time
+	Histogram *Histogram
+}
+
+// Window returns the time window of the data points of the histogram,
+// which starts at its creation or last reset, and ends now, or at the
+// time a copy of it was captured, like by Snapshot(), according to its
+// clock, see SetClock().  The window is unknown, as zero times, for
+// histograms without a time base, like those decoded from JSON.
+func (gh *Histogram) Window() (start, end time.Time) {
+	gh.m.Lock()
+	start, end = gh.windowUNLOCKED()
+	gh.m.Unlock()
+	return start, end
+}
+
+func (gh *Histogram) windowUNLOCKED() (start, end time.Time) {
+	if gh.start.IsZero() {
+		return time.Time{}, time.Time{}
+	}
+
+	if gh.end.IsZero() {
+		return gh.start, gh.now()
+	}
+
+	return gh.start, gh.end
+}
+
+// windowLabelUNLOCKED returns the elapsed time of the window, rounded
+// to seconds, and the throughput of data points over it, like
+// " in 2m0s, 0.40/sec", or "" when the window is unknown or shorter
+// than a second, where the throughput would be mostly noise.
+func (gh *Histogram) windowLabelUNLOCKED() string {
+	start, end := gh.windowUNLOCKED()
+
+	elapsed := end.Sub(start).Round(time.Second)
+	if start.IsZero() || elapsed < time.Second {
+		return ""
+	}
+
+	return fmt.Sprintf(" in %v, %.2f/sec",
+		elapsed, float64(gh.TotCount)/elapsed.Seconds())
+}
+
+// RollupWindows merges every n consecutive windows, which must be in
+// time order, into one coarser window, like 1 second windows into 1
+// minute windows, so long histories may be retained at decreasing
+// resolution, as in an RRD.  A trailing group of less than n windows
+// becomes a final, shorter window.  The histograms of the windows
+// must have the same bins, and are not modified.
+func RollupWindows(windows []Window, n int) ([]Window, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("ghistogram: RollupWindows, invalid n: %d", n)
+	}
+
+	rv := make([]Window, 0, (len(windows)+n-1)/n)
+
+	for i := 0; i < len(windows); i += n {
+		first := windows[i]
+
+		w := Window{
+			Start:     first.Start,
+			End:       first.End,
+			Histogram: first.Histogram.Snapshot(),
+		}
+
+		for j := i + 1; j < i+n && j < len(windows); j++ {
+			if !w.Histogram.addAllSameLayout(windows[j].Histogram) {
+				return nil, fmt.Errorf("ghistogram: RollupWindows,"+
+					" window %d has different bins", j)
+			}
+
+			w.End = windows[j].End
+		}
+
+		rv = append(rv, w)
+	}
+
+	return rv, nil
+}
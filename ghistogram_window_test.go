@@ -0,0 +1,131 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogram
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRollupWindows(t *testing.T) {
+	start := time.Unix(1000, 0)
+
+	var windows []Window
+	for i := 0; i < 5; i++ {
+		gh := NewHistogram(3, 10, 0.0)
+		gh.Add(uint64(i*10), uint64(i+1))
+
+		windows = append(windows, Window{
+			Start:     start.Add(time.Duration(i) * time.Second),
+			End:       start.Add(time.Duration(i+1) * time.Second),
+			Histogram: gh,
+		})
+	}
+
+	got, err := RollupWindows(windows, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []struct {
+		start, end int64
+		counts     []uint64
+	}{
+		{1000, 1002, []uint64{1, 2, 0}},
+		{1002, 1004, []uint64{0, 0, 7}},
+		{1004, 1005, []uint64{0, 0, 5}},
+	}
+
+	if len(got) != len(exp) {
+		t.Fatalf("expected %d windows, got: %d", len(exp), len(got))
+	}
+	for i, e := range exp {
+		if got[i].Start.Unix() != e.start || got[i].End.Unix() != e.end ||
+			!reflect.DeepEqual(got[i].Histogram.Counts, e.counts) {
+			t.Errorf("window %d, expected: %v, got: %v - %v, %v", i, e,
+				got[i].Start.Unix(), got[i].End.Unix(), got[i].Histogram.Counts)
+		}
+	}
+
+	if windows[0].Histogram.TotCount != 1 {
+		t.Errorf("expected source windows to be unmodified")
+	}
+
+	if _, err = RollupWindows(windows, 0); err == nil {
+		t.Errorf("expected error for n of 0")
+	}
+
+	windows[1].Histogram = NewHistogram(4, 10, 0.0)
+	if _, err = RollupWindows(windows, 2); err == nil {
+		t.Errorf("expected error for mismatched bins")
+	}
+}
+
+func TestHistogramWindow(t *testing.T) {
+	start := time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+
+	gh := NewNamedHistogram("get", 3, 10, 2.0)
+	gh.SetClock(clock)
+
+	gh.Add(5, 60)
+	gh.Add(15, 60)
+
+	if s, e := gh.Window(); !s.Equal(start) || !e.Equal(start) {
+		t.Errorf("unexpected window: %v - %v", s, e)
+	}
+
+	header := func(h *Histogram) string {
+		return strings.SplitN(h.EmitGraph(nil, nil).String(), "\n", 2)[0]
+	}
+
+	if got := header(gh); got != "get (120 Total)" {
+		t.Errorf("expected no window under a second, got: %s", got)
+	}
+
+	clock.Advance(time.Minute)
+
+	snapshot := gh.Snapshot()
+
+	clock.Advance(time.Minute)
+
+	if s, e := gh.Window(); !s.Equal(start) ||
+		!e.Equal(start.Add(2*time.Minute)) {
+		t.Errorf("unexpected window: %v - %v", s, e)
+	}
+
+	if s, e := snapshot.Window(); !s.Equal(start) ||
+		!e.Equal(start.Add(time.Minute)) {
+		t.Errorf("expected the snapshot window to end at its capture,"+
+			" got: %v - %v", s, e)
+	}
+
+	if got := header(snapshot); got != "get (120 Total in 1m0s, 2.00/sec)" {
+		t.Errorf("unexpected header: %s", got)
+	}
+
+	gh.Reset()
+
+	if s, e := gh.Window(); !s.Equal(start.Add(2*time.Minute)) ||
+		!s.Equal(e) {
+		t.Errorf("expected the window to restart on reset,"+
+			" got: %v - %v", s, e)
+	}
+
+	var decoded Histogram
+	if err := decoded.UnmarshalJSON([]byte(`{"Name":"get",` +
+		`"Ranges":[0,10],"Counts":[0,0]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if s, e := decoded.Window(); !s.IsZero() || !e.IsZero() {
+		t.Errorf("expected an unknown window, got: %v - %v", s, e)
+	}
+}
@@ -0,0 +1,167 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+// Package ghistogramrpc implements the HistogramStats service of
+// ghistogram.proto over a ghistogram.Histograms map, so cluster
+// managers can pull and merge node-level histograms.
+//
+// The package has no dependencies beyond ghistogram, so the message
+// types mirror the ones protoc-gen-go generates from ghistogram.proto,
+// and Server has the method set of the generated HistogramStatsServer
+// interface.  A service registers the Server with its own gRPC server
+// through the generated code and a conversion of the messages.
+package ghistogramrpc
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/couchbase/ghistogram"
+)
+
+// HistogramSnapshot mirrors the HistogramSnapshot message.
+type HistogramSnapshot struct {
+	Name         string
+	Ranges       []uint64
+	Counts       []uint64
+	TotCount     uint64
+	TotDataPoint uint64
+	MinDataPoint uint64
+	MaxDataPoint uint64
+	Unit         string
+}
+
+// GetHistogramsRequest mirrors the GetHistogramsRequest message.
+type GetHistogramsRequest struct {
+	Names []string
+}
+
+// GetHistogramsResponse mirrors the GetHistogramsResponse message.
+type GetHistogramsResponse struct {
+	Histograms []*HistogramSnapshot
+}
+
+// GetHistogramRequest mirrors the GetHistogramRequest message.
+type GetHistogramRequest struct {
+	Name string
+}
+
+// ResetHistogramRequest mirrors the ResetHistogramRequest message.
+type ResetHistogramRequest struct {
+	Name   string
+	Reason string
+}
+
+// Server implements the HistogramStats service over a Histograms map,
+// which must not be modified while the Server is in use.
+type Server struct {
+	hmap ghistogram.Histograms
+}
+
+// NewServer returns a Server of the histograms of the map.
+func NewServer(hmap ghistogram.Histograms) *Server {
+	return &Server{hmap: hmap}
+}
+
+// GetHistograms returns snapshots of the requested histograms, or of
+// all the histograms when no names are requested, in name order.
+// Unknown names are skipped.
+func (s *Server) GetHistograms(ctx context.Context,
+	req *GetHistogramsRequest) (*GetHistogramsResponse, error) {
+	names := req.Names
+	if len(names) == 0 {
+		names = s.hmap.SortedNames(nil)
+	}
+
+	rv := &GetHistogramsResponse{}
+	for _, name := range names {
+		if gh := s.hmap[name]; gh != nil {
+			rv.Histograms = append(rv.Histograms, FromHistogram(gh.Snapshot()))
+		}
+	}
+
+	return rv, nil
+}
+
+// GetHistogram returns a snapshot of the named histogram.
+func (s *Server) GetHistogram(ctx context.Context,
+	req *GetHistogramRequest) (*HistogramSnapshot, error) {
+	gh, err := s.lookup("GetHistogram", req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return FromHistogram(gh.Snapshot()), nil
+}
+
+// ResetHistogram resets the named histogram, with the optional reason
+// of the request, and returns its snapshot from right before the
+// reset, so no counts are lost to the caller.
+func (s *Server) ResetHistogram(ctx context.Context,
+	req *ResetHistogramRequest) (*HistogramSnapshot, error) {
+	gh, err := s.lookup("ResetHistogram", req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return FromHistogram(gh.SnapshotAndReset(req.Reason)), nil
+}
+
+func (s *Server) lookup(op, name string) (*ghistogram.Histogram, error) {
+	gh := s.hmap[name]
+	if gh == nil {
+		return nil, fmt.Errorf("ghistogramrpc: %s, unknown histogram: %q",
+			op, name)
+	}
+	return gh, nil
+}
+
+// FromHistogram converts a histogram, such as one returned by
+// Histogram.Snapshot(), which must not be concurrently modified.
+func FromHistogram(gh *ghistogram.Histogram) *HistogramSnapshot {
+	return &HistogramSnapshot{
+		Name:         gh.Name,
+		Ranges:       append([]uint64(nil), gh.Ranges...),
+		Counts:       append([]uint64(nil), gh.Counts...),
+		TotCount:     gh.TotCount,
+		TotDataPoint: gh.TotDataPoint,
+		MinDataPoint: gh.MinDataPoint,
+		MaxDataPoint: gh.MaxDataPoint,
+		Unit:         gh.Unit(),
+	}
+}
+
+// Histogram converts the snapshot back to a histogram, which can be
+// merged with the snapshots of other nodes through AddAll(), and
+// returns an error when the snapshot is invalid, see
+// Histogram.Validate().
+func (hs *HistogramSnapshot) Histogram() (*ghistogram.Histogram, error) {
+	gh := &ghistogram.Histogram{
+		Name:         hs.Name,
+		Ranges:       append([]uint64(nil), hs.Ranges...),
+		Counts:       append([]uint64(nil), hs.Counts...),
+		TotCount:     hs.TotCount,
+		TotDataPoint: hs.TotDataPoint,
+		MinDataPoint: hs.MinDataPoint,
+		MaxDataPoint: hs.MaxDataPoint,
+	}
+	if hs.TotCount == 0 {
+		gh.MinDataPoint = math.MaxUint64
+	}
+
+	if err := gh.Validate(); err != nil {
+		return nil, err
+	}
+
+	if hs.Unit != "" {
+		gh = gh.WithUnit(hs.Unit)
+	}
+
+	return gh, nil
+}
@@ -0,0 +1,94 @@
+//  Copyright 2017-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package ghistogramrpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/couchbase/ghistogram"
+)
+
+func TestServer(t *testing.T) {
+	hmap := ghistogram.Histograms{
+		"get": ghistogram.NewNamedHistogram("get", 5, 10, 0.0).WithUnit("µs"),
+		"set": ghistogram.NewNamedHistogram("set", 5, 10, 0.0),
+	}
+	hmap["get"].Add(5, 2)
+	hmap["get"].Add(25, 1)
+	hmap["set"].Add(15, 4)
+
+	s := NewServer(hmap)
+	ctx := context.Background()
+
+	resp, err := s.GetHistograms(ctx, &GetHistogramsRequest{})
+	if err != nil || len(resp.Histograms) != 2 ||
+		resp.Histograms[0].Name != "get" || resp.Histograms[1].Name != "set" {
+		t.Fatalf("unexpected response: %+v, err: %v", resp, err)
+	}
+
+	resp, _ = s.GetHistograms(ctx, &GetHistogramsRequest{
+		Names: []string{"set", "unknown"},
+	})
+	if len(resp.Histograms) != 1 || resp.Histograms[0].TotCount != 4 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	hs, err := s.GetHistogram(ctx, &GetHistogramRequest{Name: "get"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hs.TotCount != 3 || hs.Counts[0] != 2 || hs.Counts[2] != 1 ||
+		hs.MinDataPoint != 5 || hs.MaxDataPoint != 25 || hs.Unit != "µs" {
+		t.Errorf("unexpected snapshot: %+v", hs)
+	}
+
+	// The cluster manager merges the snapshots of the nodes.
+	merged := hmap["get"].CloneEmpty()
+	for i := 0; i < 2; i++ {
+		gh, err := hs.Histogram()
+		if err != nil {
+			t.Fatal(err)
+		}
+		merged.AddAll(gh)
+	}
+	if merged.TotCount != 6 || merged.Counts[0] != 4 {
+		t.Errorf("unexpected merged histogram: %v", merged)
+	}
+
+	hs, err = s.ResetHistogram(ctx,
+		&ResetHistogramRequest{Name: "get", Reason: "test"})
+	if err != nil || hs.TotCount != 3 {
+		t.Errorf("expected pre-reset snapshot, got: %+v, err: %v", hs, err)
+	}
+	if hmap["get"].Total() != 0 {
+		t.Errorf("expected reset histogram")
+	}
+
+	hs, _ = s.GetHistogram(ctx, &GetHistogramRequest{Name: "get"})
+	if gh, err := hs.Histogram(); err != nil || gh.TotCount != 0 {
+		t.Errorf("expected empty histogram, got: %v, err: %v", gh, err)
+	}
+
+	_, err = s.GetHistogram(ctx, &GetHistogramRequest{Name: "unknown"})
+	if err == nil || !strings.Contains(err.Error(), "unknown histogram") {
+		t.Errorf("expected unknown histogram err, got: %v", err)
+	}
+
+	_, err = s.ResetHistogram(ctx, &ResetHistogramRequest{Name: "unknown"})
+	if err == nil {
+		t.Errorf("expected unknown histogram err")
+	}
+
+	bad := &HistogramSnapshot{Name: "bad", Ranges: []uint64{0, 10}}
+	if _, err = bad.Histogram(); err == nil {
+		t.Errorf("expected invalid snapshot err")
+	}
+}